@@ -10,6 +10,18 @@ import (
 //go:embed fonts/defaultAppFont.ttf
 var defaultFontData []byte
 
+const defaultFontFamilyName string = "Default"
+
+// Fonts of the UI are sized in UI units, but rasterized for the display scale
 func GetAppFont(size int) *ttf.Font {
+	return pkg.LoadScaledFont(defaultFontData, size, uiScale)
+}
+
+// Text drawn on the screenshot is measured in its pixels, whatever the display scale is
+func GetCanvasAppFont(size int) *ttf.Font {
 	return pkg.LoadFont(defaultFontData, size)
 }
+
+func GetAppFontFamily() pkg.FontFamily {
+	return pkg.NewEmbeddedFontFamily(defaultFontFamilyName, defaultFontData)
+}
@@ -4,8 +4,15 @@ import (
 	_ "embed"
 
 	"github.com/Wine1y/trigat/pkg"
+	"github.com/veandco/go-sdl2/sdl"
 )
 
+var uiScale float64 = 1
+
+//go:embed icons/eraser_tool.png
+var eraserIconData []byte
+var EraserIcon = pkg.LoadPNGSurface(eraserIconData)
+
 //go:embed icons/line_tool.png
 var lineIconData []byte
 var LineIcon = pkg.LoadPNGSurface(lineIconData)
@@ -22,6 +29,10 @@ var PipetteIcon = pkg.LoadPNGSurface(pipetteIconData)
 var rectIconData []byte
 var RectIcon = pkg.LoadPNGSurface(rectIconData)
 
+//go:embed icons/redact_tool.png
+var redactIconData []byte
+var RedactIcon = pkg.LoadPNGSurface(redactIconData)
+
 //go:embed icons/selection_tool.png
 var selectionIconData []byte
 var SelectionIcon = pkg.LoadPNGSurface(selectionIconData)
@@ -42,5 +53,27 @@ var SaveIcon = pkg.LoadPNGSurface(saveIconData)
 var searchIconData []byte
 var SearchIcon = pkg.LoadPNGSurface(searchIconData)
 
+//go:embed icons/upload_action.png
+var uploadIconData []byte
+var UploadIcon = pkg.LoadPNGSurface(uploadIconData)
+
+//go:embed icons/extract_text_action.png
+var extractTextIconData []byte
+var ExtractTextIcon = pkg.LoadPNGSurface(extractTextIconData)
+
+//go:embed icons/decode_codes_action.png
+var decodeCodesIconData []byte
+var DecodeCodesIcon = pkg.LoadPNGSurface(decodeCodesIconData)
+
+// Fonts and icons created after this are rasterized for the display scale
+func SetUIScale(scale float64) {
+	uiScale = scale
+}
+
+// Returns the icon resampled for the display scale, the caller frees it
+func GetScaledIcon(icon *sdl.Surface) *sdl.Surface {
+	return pkg.ScaleSurface(icon, uiScale)
+}
+
 //go:embed icons/tray_icon.ico
 var TrayIconData []byte
@@ -1,7 +1,221 @@
 package config
 
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
 const fps uint64 = 60
+const appVersion string = "0.0.0.1"
+const appDirName string = "trigat"
+const configFileName string = "config.json"
+const dataDirName string = "data"
+
+type MetadataConfig struct {
+	Enabled bool   `json:"enabled"`
+	Comment string `json:"comment"`
+}
+
+type UploadConfig struct {
+	Backend        string                `json:"backend"`
+	TimeoutSeconds int                   `json:"timeout_seconds"`
+	HistorySize    int                   `json:"history_size"`
+	ImgBB          ImgBBUploadConfig     `json:"imgbb"`
+	Multipart      MultipartUploadConfig `json:"multipart"`
+	S3             S3UploadConfig        `json:"s3"`
+}
+
+type ImgBBUploadConfig struct {
+	Expiration string `json:"expiration"`
+}
+
+type MultipartUploadConfig struct {
+	URL          string            `json:"url"`
+	FieldName    string            `json:"field_name"`
+	Fields       map[string]string `json:"fields"`
+	Headers      map[string]string `json:"headers"`
+	ResultPath   string            `json:"result_path"`
+	DeletePath   string            `json:"delete_path"`
+	DeleteMethod string            `json:"delete_method"`
+}
+
+type S3UploadConfig struct {
+	Endpoint  string `json:"endpoint"`
+	Region    string `json:"region"`
+	Bucket    string `json:"bucket"`
+	AccessKey string `json:"access_key"`
+	SecretKey string `json:"secret_key"`
+	KeyPrefix string `json:"key_prefix"`
+	PathStyle bool   `json:"path_style"`
+	ACL       string `json:"acl"`
+	PublicURL string `json:"public_url"`
+}
+
+type SearchConfig struct {
+	Engine        string                     `json:"engine"`
+	Mode          string                     `json:"mode"`
+	LocalServer   LocalSearchServerConfig    `json:"local_server"`
+	CustomEngines []CustomSearchEngineConfig `json:"custom_engines"`
+}
+
+type LocalSearchServerConfig struct {
+	Address         string `json:"address"`
+	PublicURL       string `json:"public_url"`
+	LifetimeSeconds int    `json:"lifetime_seconds"`
+}
+
+type CustomSearchEngineConfig struct {
+	Name        string `json:"name"`
+	URLTemplate string `json:"url_template"`
+	UploadURL   string `json:"upload_url"`
+	UploadField string `json:"upload_field"`
+	ResultPath  string `json:"result_path"`
+}
+
+type OCRConfig struct {
+	Engine         string             `json:"engine"`
+	TimeoutSeconds int                `json:"timeout_seconds"`
+	Tesseract      TesseractOCRConfig `json:"tesseract"`
+}
+
+type TesseractOCRConfig struct {
+	Path      string `json:"path"`
+	Languages string `json:"languages"`
+}
+
+type RedactionConfig struct {
+	Style    string                   `json:"style"`
+	Patterns []RedactionPatternConfig `json:"patterns"`
+}
+
+type RedactionPatternConfig struct {
+	Name    string `json:"name"`
+	Pattern string `json:"pattern"`
+}
+
+type PipetteConfig struct {
+	Format      string `json:"format"`
+	HistorySize int    `json:"history_size"`
+}
+
+type ColorPickerConfig struct {
+	Presets []string `json:"presets"`
+}
+
+type appConfig struct {
+	Metadata    MetadataConfig    `json:"metadata"`
+	Upload      UploadConfig      `json:"upload"`
+	Search      SearchConfig      `json:"search"`
+	OCR         OCRConfig         `json:"ocr"`
+	Redaction   RedactionConfig   `json:"redaction"`
+	Pipette     PipetteConfig     `json:"pipette"`
+	ColorPicker ColorPickerConfig `json:"color_picker"`
+}
+
+var currentConfig = loadConfig()
 
 func GetAppFPS() uint64 {
 	return fps
 }
+
+func GetAppVersion() string {
+	return appVersion
+}
+
+func GetMetadataConfig() MetadataConfig {
+	return currentConfig.Metadata
+}
+
+func GetUploadConfig() UploadConfig {
+	return currentConfig.Upload
+}
+
+func GetSearchConfig() SearchConfig {
+	return currentConfig.Search
+}
+
+func GetOCRConfig() OCRConfig {
+	return currentConfig.OCR
+}
+
+func GetRedactionConfig() RedactionConfig {
+	return currentConfig.Redaction
+}
+
+func GetPipetteConfig() PipetteConfig {
+	return currentConfig.Pipette
+}
+
+func GetColorPickerConfig() ColorPickerConfig {
+	return currentConfig.ColorPicker
+}
+
+func GetConfigDir() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, appDirName)
+}
+
+func GetDataDir() string {
+	return filepath.Join(GetConfigDir(), dataDirName)
+}
+
+func defaultConfig() appConfig {
+	return appConfig{
+		Metadata: MetadataConfig{
+			Enabled: true,
+			Comment: "",
+		},
+		Upload: UploadConfig{
+			Backend:        "imgbb",
+			TimeoutSeconds: 30,
+			HistorySize:    10,
+			ImgBB:          ImgBBUploadConfig{Expiration: "PT5M"},
+			Multipart:      MultipartUploadConfig{FieldName: "file"},
+			S3:             S3UploadConfig{Region: "us-east-1", PathStyle: true},
+		},
+		Search: SearchConfig{
+			Engine:        "Google Lens",
+			Mode:          "upload",
+			LocalServer:   LocalSearchServerConfig{LifetimeSeconds: 120},
+			CustomEngines: make([]CustomSearchEngineConfig, 0),
+		},
+		OCR: OCRConfig{
+			Engine:         "tesseract",
+			TimeoutSeconds: 30,
+			Tesseract:      TesseractOCRConfig{Path: "tesseract", Languages: "eng"},
+		},
+		Redaction: RedactionConfig{
+			Style:    "blackout",
+			Patterns: make([]RedactionPatternConfig, 0),
+		},
+		Pipette: PipetteConfig{
+			Format:      "hex",
+			HistorySize: 16,
+		},
+		ColorPicker: ColorPickerConfig{
+			Presets: []string{"#e53935", "#fb8c00", "#fdd835", "#43a047", "#1e88e5", "#8e24aa", "#000000", "#ffffff"},
+		},
+	}
+}
+
+func loadConfig() appConfig {
+	config := defaultConfig()
+	data, err := os.ReadFile(filepath.Join(GetConfigDir(), configFileName))
+	if err != nil {
+		if !errors.Is(err, os.ErrNotExist) {
+			println(fmt.Sprintf("Can't read config file: %v", err))
+		}
+		return config
+	}
+	if err := json.Unmarshal(data, &config); err != nil {
+		println(fmt.Sprintf("Invalid config file, using defaults: %v", err))
+		return defaultConfig()
+	}
+	return config
+}
@@ -11,6 +11,7 @@ type App struct {
 	currentWindow  gui.Window
 	defaultHotKeys *hotkeys.HotKeySet
 	currentHotKeys *hotkeys.HotKeySet
+	recentUploads  *recentUploadsMenu
 	exitCh         chan struct{}
 }
 
@@ -63,6 +64,7 @@ func (app *App) startSystemTray() {
 func (app *App) onTrayStart() {
 	systray.SetIcon(assets.TrayIconData)
 	systray.SetTooltip("Trigat")
+	app.recentUploads = newRecentUploadsMenu()
 	exitItem := systray.AddMenuItem("Exit", "Close the app")
 	go func() {
 		<-exitItem.ClickedCh
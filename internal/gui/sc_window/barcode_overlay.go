@@ -0,0 +1,252 @@
+package scWindow
+
+import (
+	"fmt"
+	"net/url"
+
+	"github.com/Wine1y/trigat/assets"
+	"github.com/Wine1y/trigat/internal/gui"
+	"github.com/Wine1y/trigat/pkg"
+	"github.com/veandco/go-sdl2/sdl"
+	"github.com/veandco/go-sdl2/ttf"
+	"golang.design/x/clipboard"
+)
+
+const barcodeFontSize int = 14
+const barcodeLabelPadding int32 = 6
+const barcodeLabelMargin int32 = 6
+const barcodeButtonPadding int32 = 4
+const barcodeCornerRadius int32 = 4
+const barcodeBorderThickness int32 = 2
+const barcodeMaxPayloadLength int = 60
+
+var barcodeBorderColor = sdl.Color{R: 0, G: 200, B: 255, A: 255}
+var barcodeLabelBackgroundColor = sdl.Color{R: 0, G: 0, B: 0, A: 200}
+var barcodeButtonBackgroundColor = sdl.Color{R: 255, G: 255, B: 255, A: 40}
+var barcodeButtonHoverColor = sdl.Color{R: 255, G: 255, B: 255, A: 90}
+var barcodeTextColor = sdl.Color{R: 255, G: 255, B: 255, A: 255}
+
+type barcodeOverlay struct {
+	labels   []*barcodeLabel
+	font     *ttf.Font
+	closed   bool
+	onClosed func()
+}
+
+type barcodeLabel struct {
+	barcode      pkg.DecodedBarcode
+	codeBBox     sdl.Rect
+	bbox         sdl.Rect
+	textTexture  *pkg.StringTexture
+	buttons      []*barcodeButton
+	hoveredIndex int
+}
+
+type barcodeButton struct {
+	texture  *pkg.StringTexture
+	bbox     sdl.Rect
+	callback func()
+}
+
+func newBarcodeOverlay(
+	ren *sdl.Renderer,
+	barcodes []pkg.DecodedBarcode,
+	offset sdl.Point,
+	bounds *sdl.Rect,
+	onClosed func(),
+) *barcodeOverlay {
+	overlay := barcodeOverlay{
+		labels:   make([]*barcodeLabel, 0, len(barcodes)),
+		font:     assets.GetCanvasAppFont(barcodeFontSize),
+		onClosed: onClosed,
+	}
+	for _, barcode := range barcodes {
+		overlay.labels = append(overlay.labels, overlay.newLabel(ren, barcode, offset, bounds))
+	}
+	return &overlay
+}
+
+func (overlay *barcodeOverlay) newLabel(
+	ren *sdl.Renderer,
+	barcode pkg.DecodedBarcode,
+	offset sdl.Point,
+	vp *sdl.Rect,
+) *barcodeLabel {
+	label := barcodeLabel{
+		barcode: barcode,
+		codeBBox: sdl.Rect{
+			X: int32(barcode.BBox.Min.X) + offset.X, Y: int32(barcode.BBox.Min.Y) + offset.Y,
+			W: int32(barcode.BBox.Dx()), H: int32(barcode.BBox.Dy()),
+		},
+		hoveredIndex: -1,
+	}
+	payload := []rune(barcode.Text)
+	if len(payload) > barcodeMaxPayloadLength {
+		payload = append(payload[:barcodeMaxPayloadLength], []rune("...")...)
+	}
+	label.textTexture = pkg.NewStringTexture(
+		ren, overlay.font,
+		fmt.Sprintf("%v: %v", barcode.Format, string(payload)),
+		barcodeTextColor,
+	)
+
+	label.buttons = append(label.buttons, overlay.newButton(ren, "Copy", func() {
+		clipboard.Write(clipboard.FmtText, []byte(barcode.Text))
+	}))
+	if isBrowsableURL(barcode.Text) {
+		label.buttons = append(label.buttons, overlay.newButton(ren, "Open", func() {
+			pkg.OpenUrlInBrowser(barcode.Text)
+		}))
+	}
+
+	label.bbox.W = label.textTexture.TextWidth + barcodeLabelPadding*2
+	label.bbox.H = label.textTexture.TextHeight + barcodeLabelPadding*2
+	for _, button := range label.buttons {
+		label.bbox.W += button.bbox.W + barcodeLabelPadding
+	}
+
+	//Label is shown under the code, or above it if it doesn't fit the screen
+	label.bbox.X = pkg.Clamp(0, label.codeBBox.X, vp.W-label.bbox.W)
+	label.bbox.Y = label.codeBBox.Y + label.codeBBox.H + barcodeLabelMargin
+	if label.bbox.Y+label.bbox.H > vp.H {
+		label.bbox.Y = pkg.Max(0, label.codeBBox.Y-label.bbox.H-barcodeLabelMargin)
+	}
+
+	x := label.bbox.X + barcodeLabelPadding + label.textTexture.TextWidth + barcodeLabelPadding
+	for _, button := range label.buttons {
+		button.bbox.X = x
+		button.bbox.Y = label.bbox.Y + (label.bbox.H-button.bbox.H)/2
+		x += button.bbox.W + barcodeLabelPadding
+	}
+	return &label
+}
+
+func (overlay *barcodeOverlay) newButton(ren *sdl.Renderer, text string, callback func()) *barcodeButton {
+	texture := pkg.NewStringTexture(ren, overlay.font, text, barcodeTextColor)
+	return &barcodeButton{
+		texture:  texture,
+		bbox:     sdl.Rect{W: texture.TextWidth + barcodeButtonPadding*2, H: texture.TextHeight + barcodeButtonPadding},
+		callback: callback,
+	}
+}
+
+func (overlay *barcodeOverlay) draw(ren *sdl.Renderer) {
+	if overlay.closed {
+		return
+	}
+	for _, label := range overlay.labels {
+		pkg.DrawThickRectangle(ren, &label.codeBBox, barcodeBorderThickness, barcodeBorderColor)
+		pkg.DrawRoundedFilledRectangle(ren, &label.bbox, barcodeCornerRadius, barcodeLabelBackgroundColor)
+		label.textTexture.Draw(ren, &sdl.Point{
+			X: label.bbox.X + barcodeLabelPadding,
+			Y: label.bbox.Y + barcodeLabelPadding,
+		})
+		for i, button := range label.buttons {
+			backgroundColor := barcodeButtonBackgroundColor
+			if i == label.hoveredIndex {
+				backgroundColor = barcodeButtonHoverColor
+			}
+			pkg.DrawRoundedFilledRectangle(ren, &button.bbox, barcodeCornerRadius, backgroundColor)
+			button.texture.Draw(ren, &sdl.Point{
+				X: button.bbox.X + barcodeButtonPadding,
+				Y: button.bbox.Y + barcodeButtonPadding/2,
+			})
+		}
+	}
+}
+
+func (overlay *barcodeOverlay) callbacks() *gui.WindowCallbackSet {
+	callbacks := gui.NewWindowCallbackSet()
+
+	callbacks.MouseDown = append(callbacks.MouseDown, func(button uint8, x, y int32) bool {
+		if overlay.closed {
+			return false
+		}
+		click := sdl.Point{X: x, Y: y}
+		for _, label := range overlay.labels {
+			for _, labelButton := range label.buttons {
+				if click.InRect(&labelButton.bbox) && button == sdl.BUTTON_LEFT {
+					labelButton.callback()
+					return true
+				}
+			}
+			if click.InRect(&label.bbox) {
+				return true
+			}
+		}
+		overlay.close()
+		return true
+	})
+
+	callbacks.MouseMove = append(callbacks.MouseMove, func(x, y int32) bool {
+		if overlay.closed {
+			return false
+		}
+		move := sdl.Point{X: x, Y: y}
+		buttonHovered := false
+		for _, label := range overlay.labels {
+			label.hoveredIndex = -1
+			for i, button := range label.buttons {
+				if move.InRect(&button.bbox) {
+					label.hoveredIndex = i
+					buttonHovered = true
+				}
+			}
+		}
+		if buttonHovered {
+			sdl.SetCursor(gui.HandCursor)
+		} else {
+			sdl.SetCursor(gui.ArrowCursor)
+		}
+		return true
+	})
+
+	callbacks.MouseUp = append(callbacks.MouseUp, func(button uint8, x, y int32) bool {
+		return !overlay.closed
+	})
+
+	callbacks.KeyDown = append(callbacks.KeyDown, func(keysym sdl.Keysym) bool {
+		if overlay.closed {
+			return false
+		}
+		if keysym.Sym == sdl.K_ESCAPE {
+			overlay.close()
+			return true
+		}
+		return false
+	})
+
+	callbacks.Quit = append(callbacks.Quit, func() bool {
+		overlay.destroy()
+		return false
+	})
+	return callbacks
+}
+
+func (overlay *barcodeOverlay) close() {
+	overlay.closed = true
+	sdl.SetCursor(gui.ArrowCursor)
+	overlay.onClosed()
+}
+
+func (overlay *barcodeOverlay) destroy() {
+	if overlay.font == nil {
+		return
+	}
+	for _, label := range overlay.labels {
+		label.textTexture.Destroy()
+		for _, button := range label.buttons {
+			button.texture.Destroy()
+		}
+	}
+	overlay.font.Close()
+	overlay.font = nil
+}
+
+func isBrowsableURL(text string) bool {
+	parsedURL, err := url.Parse(text)
+	if err != nil {
+		return false
+	}
+	return (parsedURL.Scheme == "http" || parsedURL.Scheme == "https") && parsedURL.Host != ""
+}
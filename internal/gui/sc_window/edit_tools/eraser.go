@@ -0,0 +1,239 @@
+package editTools
+
+import (
+	"math"
+
+	"github.com/Wine1y/trigat/assets"
+	"github.com/Wine1y/trigat/internal/gui"
+	"github.com/Wine1y/trigat/internal/gui/sc_window/settings"
+	"github.com/Wine1y/trigat/pkg"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+const (
+	eraserModeObject int = iota
+	eraserModePartial
+)
+
+const minEraserRadius uint = 2
+const maxEraserRadius uint = 40
+const defaultEraserRadius uint = 10
+
+var eraserModeNames = []string{"Object", "Partial"}
+var eraserCursorColor = sdl.Color{R: 255, G: 255, B: 255, A: 255}
+var eraserCursorOutlineColor = sdl.Color{R: 0, G: 0, B: 0, A: 150}
+
+type EraserTool struct {
+	tools      []ScreenshotErasableTool
+	mode       int
+	radius     int32
+	isErasing  bool
+	isActive   bool
+	cursorPos  *sdl.Point
+	lastErased sdl.Point
+	action     *eraserAction
+	settings   []settings.ToolSetting
+	DefaultScreenshotEditTool
+}
+
+// Erases annotations of the given tools, tools that aren't erasable are ignored
+func NewEraserTool(tools []ScreenshotEditTool) *EraserTool {
+	tool := EraserTool{
+		tools: make([]ScreenshotErasableTool, 0, len(tools)),
+	}
+	for _, editTool := range tools {
+		if erasable, ok := editTool.(ScreenshotErasableTool); ok {
+			tool.tools = append(tool.tools, erasable)
+		}
+	}
+
+	radiusSlider := settings.NewSliderSetting(minEraserRadius, maxEraserRadius, func(value uint) {
+		tool.radius = int32(value)
+	})
+	radiusSlider.SetValue(defaultEraserRadius)
+
+	modeOptions := settings.NewOptionsSetting(eraserModeNames, eraserModeObject, func(option int) {
+		tool.mode = option
+	})
+
+	tool.mode = modeOptions.CurrentOption()
+	tool.settings = []settings.ToolSetting{radiusSlider, modeOptions}
+	return &tool
+}
+
+func (tool EraserTool) ToolIcon() *sdl.Surface {
+	return assets.EraserIcon
+}
+
+func (tool *EraserTool) ToolCallbacks(queue *ActionsQueue) *gui.WindowCallbackSet {
+	callbacks := gui.NewWindowCallbackSet()
+
+	callbacks.MouseDown = append(callbacks.MouseDown, func(button uint8, x, y int32) bool {
+		if button != sdl.BUTTON_LEFT {
+			return false
+		}
+		tool.isErasing = true
+		tool.action = nil
+		tool.lastErased = sdl.Point{X: x, Y: y}
+		tool.eraseAt(tool.lastErased, queue)
+		return false
+	})
+
+	callbacks.MouseMove = append(callbacks.MouseMove, func(x, y int32) bool {
+		tool.cursorPos = &sdl.Point{X: x, Y: y}
+		if !tool.isErasing {
+			return false
+		}
+		//Fast mouse moves are filled with intermediate points, so the eraser doesn't skip annotations
+		distance := math.Hypot(float64(x-tool.lastErased.X), float64(y-tool.lastErased.Y))
+		step := math.Max(1, float64(tool.radius)/2)
+		steps := pkg.Max(1, int(math.Ceil(distance/step)))
+		from := tool.lastErased
+		for i := 1; i <= steps; i++ {
+			t := float64(i) / float64(steps)
+			tool.eraseAt(sdl.Point{
+				X: from.X + int32(math.Round(float64(x-from.X)*t)),
+				Y: from.Y + int32(math.Round(float64(y-from.Y)*t)),
+			}, queue)
+		}
+		tool.lastErased = sdl.Point{X: x, Y: y}
+		return false
+	})
+
+	callbacks.MouseUp = append(callbacks.MouseUp, func(button uint8, x, y int32) bool {
+		if button == sdl.BUTTON_LEFT {
+			tool.isErasing = false
+		}
+		return false
+	})
+
+	return callbacks
+}
+
+// Whole drag is a single action, it's pushed on the first erased annotation and grows while dragging
+func (tool *EraserTool) eraseAt(point sdl.Point, queue *ActionsQueue) {
+	for _, erasable := range tool.tools {
+		var action ToolAction
+		if tool.mode == eraserModePartial {
+			cuttable, ok := erasable.(ScreenshotCuttableTool)
+			if !ok {
+				continue
+			}
+			action = cuttable.CutAnnotations(point, tool.radius)
+		} else {
+			action = erasable.EraseAnnotations(point, tool.radius)
+		}
+		if action == nil {
+			continue
+		}
+		if tool.action == nil {
+			tool.action = &eraserAction{}
+			queue.Push(tool.action)
+		}
+		tool.action.actions = append(tool.action.actions, action)
+	}
+}
+
+func (tool EraserTool) RenderCurrentState(_ *sdl.Renderer) {}
+
+// Cursor shows the erased area, so it's zoomed with the canvas, while its outline stays thin
+func (tool EraserTool) RenderControls(ren *sdl.Renderer, toWindow func(x, y int32) (int32, int32)) {
+	if !tool.isActive || tool.cursorPos == nil {
+		return
+	}
+	x, y := toWindow(tool.cursorPos.X, tool.cursorPos.Y)
+	edgeX, _ := toWindow(tool.cursorPos.X+tool.radius, tool.cursorPos.Y)
+	center, radius := sdl.Point{X: x, Y: y}, pkg.Max(1, edgeX-x)
+	pkg.DrawCircle(ren, &center, radius+1, eraserCursorOutlineColor)
+	pkg.DrawCircle(ren, &center, radius, eraserCursorColor)
+}
+
+func (tool EraserTool) ControlsCallbacks() *gui.WindowCallbackSet {
+	return gui.NewWindowCallbackSet()
+}
+
+func (tool EraserTool) RenderScreenshot(ren *sdl.Renderer) {}
+
+func (tool EraserTool) ToolSettings() []settings.ToolSetting {
+	return tool.settings
+}
+
+func (tool *EraserTool) OnToolActivated() {
+	tool.isActive = true
+}
+
+func (tool *EraserTool) OnToolDeactivated() {
+	tool.isActive = false
+	tool.isErasing = false
+	tool.cursorPos = nil
+}
+
+type eraserAction struct {
+	actions []ToolAction
+}
+
+func (action *eraserAction) Undo() {
+	for i := len(action.actions) - 1; i >= 0; i-- {
+		action.actions[i].Undo()
+	}
+}
+
+func (action *eraserAction) Redo() {
+	for _, subAction := range action.actions {
+		subAction.Redo()
+	}
+}
+
+type erasedAnnotation[T any] struct {
+	index      int
+	annotation T
+}
+
+// Restores erased annotations at their original positions
+type annotationsErasedAction[T any] struct {
+	annotations *[]T
+	erased      []erasedAnnotation[T]
+	onRemoved   func(annotation T)
+}
+
+func (action annotationsErasedAction[T]) Undo() {
+	for _, erased := range action.erased {
+		*action.annotations = append(*action.annotations, erased.annotation)
+		copy((*action.annotations)[erased.index+1:], (*action.annotations)[erased.index:])
+		(*action.annotations)[erased.index] = erased.annotation
+	}
+}
+
+func (action annotationsErasedAction[T]) Redo() {
+	for i := len(action.erased) - 1; i >= 0; i-- {
+		index := action.erased[i].index
+		*action.annotations = append((*action.annotations)[:index], (*action.annotations)[index+1:]...)
+		action.removed(action.erased[i].annotation)
+	}
+}
+
+func (action annotationsErasedAction[T]) removed(annotation T) {
+	if action.onRemoved != nil {
+		action.onRemoved(annotation)
+	}
+}
+
+// Removes the touched annotations, returns nil if none of them were touched.
+// onRemoved (if not nil) is called every time an annotation leaves the screen, on erasing and on redo
+func eraseAnnotations[T any](annotations *[]T, touched func(annotation T) bool, onRemoved func(annotation T)) ToolAction {
+	action := annotationsErasedAction[T]{annotations: annotations, onRemoved: onRemoved}
+	kept := (*annotations)[:0]
+	for i, annotation := range *annotations {
+		if touched(annotation) {
+			action.erased = append(action.erased, erasedAnnotation[T]{index: i, annotation: annotation})
+			action.removed(annotation)
+		} else {
+			kept = append(kept, annotation)
+		}
+	}
+	if len(action.erased) == 0 {
+		return nil
+	}
+	*annotations = kept
+	return action
+}
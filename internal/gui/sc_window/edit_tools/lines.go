@@ -18,7 +18,10 @@ type LinesTool struct {
 	lastCursorPos  sdl.Point
 	lineThickness  int32
 	lineColor      sdl.Color
+	lineOpacity    uint8
+	linePattern    pkg.StrokePattern
 	settings       []settings.ToolSetting
+	colorPicker    *settings.ColorPickerSetting
 	DefaultScreenshotEditTool
 }
 
@@ -37,11 +40,22 @@ func NewLinesTool() *LinesTool {
 		tool.lineColor = color
 	})
 
-	toolSettings := []settings.ToolSetting{widthSlider, colorPicker}
+	opacitySlider := newOpacitySlider(func(opacity uint8) {
+		tool.lineOpacity = opacity
+	})
+
+	strokeSettings := newStrokeStyleSettings(func(pattern pkg.StrokePattern) {
+		tool.linePattern = pattern
+	})
+
+	toolSettings := []settings.ToolSetting{widthSlider}
+	toolSettings = append(toolSettings, strokeSettings...)
+	toolSettings = append(toolSettings, opacitySlider, colorPicker)
 
 	tool.lineThickness = int32(widthSlider.CurrentValue())
 	tool.lineColor = colorPicker.CurrentColor()
 	tool.settings = toolSettings
+	tool.colorPicker = colorPicker
 	return &tool
 }
 
@@ -59,7 +73,9 @@ func (tool *LinesTool) ToolCallbacks(queue *ActionsQueue) *gui.WindowCallbackSet
 		newLine := line{
 			points:    [2]sdl.Point{{X: x, Y: y}, {X: x, Y: y}},
 			thickness: tool.lineThickness,
-			color:     tool.lineColor,
+			pattern:   tool.linePattern,
+			color:     withOpacity(tool.lineColor, tool.lineOpacity),
+			layer:     &annotationLayer{},
 		}
 		tool.lines = append(tool.lines, newLine)
 		return false
@@ -116,12 +132,19 @@ func (tool *LinesTool) ToolCallbacks(queue *ActionsQueue) *gui.WindowCallbackSet
 		}
 		return false
 	})
+
+	callbacks.Quit = append(callbacks.Quit, func() bool {
+		for _, line := range tool.lines {
+			line.destroyLayer()
+		}
+		return false
+	})
 	return callbacks
 }
 
 func (tool LinesTool) RenderCurrentState(ren *sdl.Renderer) {
-	for _, line := range tool.lines {
-		pkg.DrawThickLine(ren, &line.points[0], &line.points[1], line.thickness, line.color)
+	for i, line := range tool.lines {
+		line.draw(ren, !tool.isDragging || i < len(tool.lines)-1)
 	}
 }
 
@@ -170,6 +193,21 @@ func (tool LinesTool) ToolColor() *sdl.Color {
 	return &tool.lineColor
 }
 
+func (tool *LinesTool) SetToolColor(color sdl.Color) {
+	tool.colorPicker.SetColor(color)
+}
+
+func (tool *LinesTool) EraseAnnotations(center sdl.Point, radius int32) ToolAction {
+	return eraseAnnotations(&tool.lines, func(line line) bool {
+		distance := pkg.SegmentDistance(
+			float64(center.X), float64(center.Y),
+			float64(line.points[0].X), float64(line.points[0].Y),
+			float64(line.points[1].X), float64(line.points[1].Y),
+		)
+		return distance <= float64(radius)+float64(line.thickness)/2
+	}, line.destroyLayer)
+}
+
 func (tool *LinesTool) OnToolDeactivated() {
 	tool.isShiftPressed = false
 	tool.isDragging = false
@@ -178,7 +216,36 @@ func (tool *LinesTool) OnToolDeactivated() {
 type line struct {
 	points    [2]sdl.Point
 	thickness int32
+	pattern   pkg.StrokePattern
 	color     sdl.Color
+	layer     *annotationLayer
+}
+
+// Finished lines are blended through a cached layer, the line being drawn is blended every frame
+func (line line) draw(ren *sdl.Renderer, isFinished bool) {
+	draw := func(offset sdl.Point, opaque sdl.Color) {
+		p1 := sdl.Point{X: line.points[0].X + offset.X, Y: line.points[0].Y + offset.Y}
+		p2 := sdl.Point{X: line.points[1].X + offset.X, Y: line.points[1].Y + offset.Y}
+		pkg.DrawStyledLine(ren, &p1, &p2, line.thickness, line.pattern, opaque)
+	}
+	if !isFinished {
+		drawAnnotation(ren, line.bbox(), line.color, draw)
+		return
+	}
+	line.layer.draw(ren, line.bbox(), line.color, draw)
+}
+
+func (line line) destroyLayer() {
+	line.layer.destroy()
+}
+
+func (line line) bbox() *sdl.Rect {
+	minX, minY := pkg.Min(line.points[0].X, line.points[1].X), pkg.Min(line.points[0].Y, line.points[1].Y)
+	maxX, maxY := pkg.Max(line.points[0].X, line.points[1].X), pkg.Max(line.points[0].Y, line.points[1].Y)
+	return &sdl.Rect{
+		X: minX - line.thickness, Y: minY - line.thickness,
+		W: maxX - minX + line.thickness*2 + 1, H: maxY - minY + line.thickness*2 + 1,
+	}
 }
 
 type LineAction struct {
@@ -188,6 +255,7 @@ type LineAction struct {
 
 func (action LineAction) Undo() {
 	action.tool.lines = action.tool.lines[:len(action.tool.lines)-1]
+	action.lastLine.destroyLayer()
 }
 
 func (action LineAction) Redo() {
@@ -0,0 +1,67 @@
+package editTools
+
+import (
+	"math"
+
+	"github.com/Wine1y/trigat/internal/gui/sc_window/settings"
+	"github.com/Wine1y/trigat/pkg"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+const minOpacityPercent uint = 5
+const maxOpacityPercent uint = 100
+
+// Opacity slider used by all annotation tools, values are in percent and reported as alpha
+func newOpacitySlider(onOpacityUpdated func(opacity uint8)) *settings.SliderSetting {
+	slider := settings.NewSliderSetting(minOpacityPercent, maxOpacityPercent, func(value uint) {
+		onOpacityUpdated(uint8(math.Round(float64(value) * 255 / float64(maxOpacityPercent))))
+	})
+	slider.SetValue(maxOpacityPercent)
+	return slider
+}
+
+// Combines the color alpha with the tool opacity
+func withOpacity(color sdl.Color, opacity uint8) sdl.Color {
+	color.A = uint8(math.Round(float64(color.A) * float64(opacity) / 255))
+	return color
+}
+
+// Annotation is drawn opaque and blended as a whole, so overlapping parts don't get darker.
+// Live view and RenderScreenshot both go through here, which keeps the blending identical
+func drawAnnotation(ren *sdl.Renderer, bbox *sdl.Rect, color sdl.Color, draw func(offset sdl.Point, color sdl.Color)) {
+	opaque := sdl.Color{R: color.R, G: color.G, B: color.B, A: 255}
+	pkg.DrawTranslucent(ren, bbox, color.A, func(offset sdl.Point) {
+		draw(offset, opaque)
+	})
+}
+
+// Blended layer of a finished annotation, it's rendered once like the cached brush strokes
+// and has to be destroyed when the annotation leaves the screen
+type annotationLayer struct {
+	texture *sdl.Texture
+	bbox    sdl.Rect
+}
+
+// Same as drawAnnotation, but the layer is kept for the next frames. Opaque annotations don't need a layer
+func (layer *annotationLayer) draw(ren *sdl.Renderer, bbox *sdl.Rect, color sdl.Color, draw func(offset sdl.Point, color sdl.Color)) {
+	if color.A == 255 || color.A == 0 || bbox.Empty() {
+		drawAnnotation(ren, bbox, color, draw)
+		return
+	}
+	if layer.texture == nil {
+		opaque := sdl.Color{R: color.R, G: color.G, B: color.B, A: 255}
+		layer.texture = pkg.RenderToTexture(ren, bbox, func(offset sdl.Point) {
+			draw(offset, opaque)
+		})
+		layer.texture.SetAlphaMod(color.A)
+		layer.bbox = *bbox
+	}
+	ren.Copy(layer.texture, nil, &layer.bbox)
+}
+
+func (layer *annotationLayer) destroy() {
+	if layer.texture != nil {
+		layer.texture.Destroy()
+		layer.texture = nil
+	}
+}
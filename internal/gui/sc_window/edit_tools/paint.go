@@ -2,42 +2,78 @@ package editTools
 
 import (
 	_ "embed"
+	"math"
+	"time"
 
 	"github.com/Wine1y/trigat/assets"
 	"github.com/Wine1y/trigat/internal/gui"
 	"github.com/Wine1y/trigat/internal/gui/sc_window/settings"
 	"github.com/Wine1y/trigat/pkg"
+	"github.com/veandco/go-sdl2/gfx"
 	"github.com/veandco/go-sdl2/sdl"
 )
 
+const (
+	brushWidthFixed int = iota
+	brushWidthDynamic
+)
+
+const maxBrushThickness uint = 20
+const defaultBrushThickness uint = 4
+
+// Dynamic brush gets this much thinner on fast strokes or light pen pressure
+const brushMinWidthRatio float64 = 0.35
+
+// Stroke speed (pixels per millisecond) at which dynamic brush is the thinnest
+const brushMaxSpeed float64 = 3
+const brushWidthSmoothing float64 = 0.6
+const brushMinSampleDistance float64 = 1.5
+
+var brushWidthModeNames = []string{"Fixed", "Dynamic"}
+
 type PaintTool struct {
 	isDragging     bool
-	strokes        []paintStroke
+	strokes        []*paintStroke
 	settings       []settings.ToolSetting
+	colorPicker    *settings.ColorPickerSetting
 	paintThickness int32
 	paintColor     sdl.Color
+	paintOpacity   uint8
+	widthMode      int
+	pressure       float32
+	lastSampleAt   time.Time
 	DefaultScreenshotEditTool
 }
 
 func NewPaintTool() *PaintTool {
 	tool := PaintTool{
 		isDragging: false,
-		strokes:    make([]paintStroke, 0, 1),
+		strokes:    make([]*paintStroke, 0, 1),
 	}
 
-	widthSlider := settings.NewSliderSetting(1, 5, func(value uint) {
+	widthSlider := settings.NewSliderSetting(1, maxBrushThickness, func(value uint) {
 		tool.paintThickness = int32(value)
 	})
+	widthSlider.SetValue(defaultBrushThickness)
+
+	widthModeOptions := settings.NewOptionsSetting(brushWidthModeNames, brushWidthFixed, func(option int) {
+		tool.widthMode = option
+	})
 
 	colorPicker := settings.NewColorPickerSetting(func(color sdl.Color) {
 		tool.paintColor = color
 	})
 
-	toolSettings := []settings.ToolSetting{widthSlider, colorPicker}
+	opacitySlider := newOpacitySlider(func(opacity uint8) {
+		tool.paintOpacity = opacity
+	})
+
+	toolSettings := []settings.ToolSetting{widthSlider, widthModeOptions, opacitySlider, colorPicker}
 
-	tool.paintThickness = int32(widthSlider.CurrentValue())
+	tool.widthMode = widthModeOptions.CurrentOption()
 	tool.paintColor = colorPicker.CurrentColor()
 	tool.settings = toolSettings
+	tool.colorPicker = colorPicker
 	return &tool
 }
 
@@ -54,12 +90,12 @@ func (tool *PaintTool) ToolCallbacks(queue *ActionsQueue) *gui.WindowCallbackSet
 		}
 		tool.strokes = append(
 			tool.strokes,
-			paintStroke{
-				points:    []sdl.Point{{X: x, Y: y}},
-				thickness: tool.paintThickness,
-				color:     tool.paintColor,
+			&paintStroke{
+				samples: []brushSample{{x: float64(x), y: float64(y), width: tool.sampleWidth(nil, 0, 0)}},
+				color:   withOpacity(tool.paintColor, tool.paintOpacity),
 			},
 		)
+		tool.lastSampleAt = time.Now()
 		tool.isDragging = true
 		return false
 	})
@@ -68,10 +104,17 @@ func (tool *PaintTool) ToolCallbacks(queue *ActionsQueue) *gui.WindowCallbackSet
 		if !tool.isDragging {
 			return false
 		}
-		tool.strokes[len(tool.strokes)-1].points = append(
-			tool.strokes[len(tool.strokes)-1].points,
-			sdl.Point{X: x, Y: y},
+		stroke := tool.strokes[len(tool.strokes)-1]
+		last := stroke.samples[len(stroke.samples)-1]
+		distance := math.Hypot(float64(x)-last.x, float64(y)-last.y)
+		if distance < brushMinSampleDistance {
+			return false
+		}
+		stroke.samples = append(
+			stroke.samples,
+			brushSample{x: float64(x), y: float64(y), width: tool.sampleWidth(&last, distance, time.Since(tool.lastSampleAt))},
 		)
+		tool.lastSampleAt = time.Now()
 		return false
 	})
 
@@ -80,29 +123,51 @@ func (tool *PaintTool) ToolCallbacks(queue *ActionsQueue) *gui.WindowCallbackSet
 			return false
 		}
 		tool.isDragging = false
-		queue.Push(PaintAction{tool: tool, lastStroke: tool.strokes[len(tool.strokes)-1]})
+		stroke := tool.strokes[len(tool.strokes)-1]
+		stroke.isFinished = true
+		queue.Push(PaintAction{tool: tool, lastStroke: stroke})
+		return false
+	})
+
+	//Touch events come right after the emulated mouse events, so the pressure is applied to the last sample
+	callbacks.TouchPressure = append(callbacks.TouchPressure, func(pressure float32) bool {
+		tool.pressure = pressure
+		if tool.isDragging && pressure > 0 && tool.widthMode == brushWidthDynamic {
+			stroke := tool.strokes[len(tool.strokes)-1]
+			stroke.samples[len(stroke.samples)-1].width = tool.sampleWidth(nil, 0, 0)
+		}
+		return false
+	})
+
+	callbacks.Quit = append(callbacks.Quit, func() bool {
+		for _, stroke := range tool.strokes {
+			stroke.destroyCache()
+		}
 		return false
 	})
 
 	return callbacks
 }
 
+// Pen pressure is preferred, mouse strokes get thinner with speed
+func (tool PaintTool) sampleWidth(last *brushSample, distance float64, elapsed time.Duration) float64 {
+	thickness := float64(tool.paintThickness)
+	switch {
+	case tool.widthMode != brushWidthDynamic:
+		return thickness
+	case tool.pressure > 0:
+		return thickness * (brushMinWidthRatio + (1-brushMinWidthRatio)*float64(tool.pressure))
+	case last == nil:
+		return thickness
+	}
+	speed := distance / math.Max(float64(elapsed.Milliseconds()), 1)
+	target := thickness * (1 - (1-brushMinWidthRatio)*math.Min(speed/brushMaxSpeed, 1))
+	return last.width*brushWidthSmoothing + target*(1-brushWidthSmoothing)
+}
+
 func (tool PaintTool) RenderCurrentState(ren *sdl.Renderer) {
 	for _, stroke := range tool.strokes {
-		if len(stroke.points) == 1 {
-			pkg.DrawFilledRectangle(
-				ren,
-				&sdl.Rect{
-					X: stroke.points[0].X, Y: stroke.points[0].Y,
-					W: stroke.thickness, H: stroke.thickness,
-				},
-				stroke.color,
-			)
-			continue
-		}
-		for i := 0; i < len(stroke.points)-1; i++ {
-			pkg.DrawThickLine(ren, &stroke.points[i], &stroke.points[i+1], stroke.thickness, stroke.color)
-		}
+		stroke.draw(ren)
 	}
 }
 
@@ -118,23 +183,258 @@ func (tool PaintTool) ToolColor() *sdl.Color {
 	return &tool.paintColor
 }
 
+func (tool *PaintTool) SetToolColor(color sdl.Color) {
+	tool.colorPicker.SetColor(color)
+}
+
+func (tool *PaintTool) EraseAnnotations(center sdl.Point, radius int32) ToolAction {
+	return eraseAnnotations(&tool.strokes, func(stroke *paintStroke) bool {
+		return stroke.isFinished && stroke.touches(center, radius)
+	}, (*paintStroke).destroyCache)
+}
+
+// Touched strokes are replaced with the pieces left outside of the circle
+func (tool *PaintTool) CutAnnotations(center sdl.Point, radius int32) ToolAction {
+	action := strokesCutAction{tool: tool}
+	for i := 0; i < len(tool.strokes); i++ {
+		stroke := tool.strokes[i]
+		if !stroke.isFinished || !stroke.touches(center, radius) {
+			continue
+		}
+		pieces := stroke.cut(center, radius)
+		action.cuts = append(action.cuts, strokeCut{index: i, original: stroke, pieces: pieces})
+		tool.strokes = replaceStrokes(tool.strokes, i, 1, pieces)
+		stroke.destroyCache()
+		i += len(pieces) - 1
+	}
+	if len(action.cuts) == 0 {
+		return nil
+	}
+	return action
+}
+
 func (tool *PaintTool) OnToolDeactivated() {
+	if tool.isDragging {
+		tool.strokes[len(tool.strokes)-1].isFinished = true
+	}
 	tool.isDragging = false
 }
 
+type brushSample struct {
+	x, y  float64
+	width float64
+}
+
 type paintStroke struct {
-	points    []sdl.Point
-	thickness int32
-	color     sdl.Color
+	samples    []brushSample
+	color      sdl.Color
+	isFinished bool
+	outline    []brushSample
+	cache      *sdl.Texture
+	cacheBBox  sdl.Rect
+}
+
+// Finished strokes are rendered once into a cached texture, the stroke being drawn is rendered every frame
+func (stroke *paintStroke) draw(ren *sdl.Renderer) {
+	if stroke.isFinished && stroke.cache != nil {
+		ren.Copy(stroke.cache, nil, &stroke.cacheBBox)
+		return
+	}
+	outline := stroke.smoothedOutline()
+	bbox := brushOutlineBBox(outline)
+	if !stroke.isFinished {
+		drawAnnotation(ren, bbox, stroke.color, func(offset sdl.Point, opaque sdl.Color) {
+			renderBrushOutline(ren, outline, offset, opaque)
+		})
+		return
+	}
+	opaque := sdl.Color{R: stroke.color.R, G: stroke.color.G, B: stroke.color.B, A: 255}
+	stroke.cache = pkg.RenderToTexture(ren, bbox, func(offset sdl.Point) {
+		renderBrushOutline(ren, outline, offset, opaque)
+	})
+	stroke.cache.SetAlphaMod(stroke.color.A)
+	stroke.cacheBBox = *bbox
+	ren.Copy(stroke.cache, nil, &stroke.cacheBBox)
+}
+
+// Outline of finished strokes never changes, so it's computed once
+func (stroke *paintStroke) smoothedOutline() []brushSample {
+	if !stroke.isFinished {
+		return smoothBrushSamples(stroke.samples)
+	}
+	if stroke.outline == nil {
+		stroke.outline = smoothBrushSamples(stroke.samples)
+	}
+	return stroke.outline
+}
+
+func (stroke *paintStroke) touches(center sdl.Point, radius int32) bool {
+	for _, sample := range stroke.smoothedOutline() {
+		if sampleUnderCircle(sample, center, radius) {
+			return true
+		}
+	}
+	return false
+}
+
+// Splits the smoothed outline into the runs of samples outside of the circle
+func (stroke *paintStroke) cut(center sdl.Point, radius int32) []*paintStroke {
+	pieces := make([]*paintStroke, 0)
+	var current []brushSample
+	for _, sample := range stroke.smoothedOutline() {
+		if !sampleUnderCircle(sample, center, radius) {
+			current = append(current, sample)
+			continue
+		}
+		if len(current) > 0 {
+			pieces = append(pieces, &paintStroke{samples: current, color: stroke.color, isFinished: true})
+			current = nil
+		}
+	}
+	if len(current) > 0 {
+		pieces = append(pieces, &paintStroke{samples: current, color: stroke.color, isFinished: true})
+	}
+	return pieces
+}
+
+func sampleUnderCircle(sample brushSample, center sdl.Point, radius int32) bool {
+	return math.Hypot(sample.x-float64(center.X), sample.y-float64(center.Y)) <= float64(radius)+sample.width/2
+}
+
+// Strokes taken off the screen by erasing or undo release their cache, it's rendered again if they are restored,
+// so strokes only kept by the actions queue hold no textures
+func (stroke *paintStroke) destroyCache() {
+	if stroke.cache != nil {
+		stroke.cache.Destroy()
+		stroke.cache = nil
+	}
+}
+
+// Every sample is a round dab, consecutive dabs are connected with quads, which gives round joins and caps
+func renderBrushOutline(ren *sdl.Renderer, outline []brushSample, offset sdl.Point, color sdl.Color) {
+	for i, sample := range outline {
+		x, y := sample.x+float64(offset.X), sample.y+float64(offset.Y)
+		radius := int32(math.Round(sample.width / 2))
+		gfx.FilledCircleColor(ren, int32(math.Round(x)), int32(math.Round(y)), radius, color)
+		if i == 0 {
+			continue
+		}
+		previous := outline[i-1]
+		prevX, prevY := previous.x+float64(offset.X), previous.y+float64(offset.Y)
+		length := math.Hypot(x-prevX, y-prevY)
+		if length == 0 {
+			continue
+		}
+		if radius == 0 {
+			gfx.LineColor(ren, int32(math.Round(prevX)), int32(math.Round(prevY)), int32(math.Round(x)), int32(math.Round(y)), color)
+			continue
+		}
+		normalX, normalY := -(y-prevY)/length, (x-prevX)/length
+		prevHalf, half := previous.width/2, sample.width/2
+		gfx.FilledPolygonColor(
+			ren,
+			[]int16{
+				int16(math.Round(prevX + normalX*prevHalf)), int16(math.Round(x + normalX*half)),
+				int16(math.Round(x - normalX*half)), int16(math.Round(prevX - normalX*prevHalf)),
+			},
+			[]int16{
+				int16(math.Round(prevY + normalY*prevHalf)), int16(math.Round(y + normalY*half)),
+				int16(math.Round(y - normalY*half)), int16(math.Round(prevY - normalY*prevHalf)),
+			},
+			color,
+		)
+	}
+}
+
+// Interpolates samples with a Catmull-Rom spline, width changes linearly between samples
+func smoothBrushSamples(samples []brushSample) []brushSample {
+	if len(samples) < 3 {
+		return samples
+	}
+	smoothed := make([]brushSample, 0, len(samples)*2)
+	for i := 0; i < len(samples)-1; i++ {
+		p0, p1 := samples[pkg.Max(i-1, 0)], samples[i]
+		p2, p3 := samples[i+1], samples[pkg.Min(i+2, len(samples)-1)]
+		step := math.Max(1, math.Min(p1.width, p2.width)/3)
+		steps := pkg.Max(1, int(math.Hypot(p2.x-p1.x, p2.y-p1.y)/step))
+		for j := 0; j < steps; j++ {
+			smoothed = append(smoothed, catmullRomSample(p0, p1, p2, p3, float64(j)/float64(steps)))
+		}
+	}
+	return append(smoothed, samples[len(samples)-1])
+}
+
+func catmullRomSample(p0, p1, p2, p3 brushSample, t float64) brushSample {
+	t2, t3 := t*t, t*t*t
+	interpolate := func(a, b, c, d float64) float64 {
+		return 0.5 * (2*b + (c-a)*t + (2*a-5*b+4*c-d)*t2 + (3*b-a-3*c+d)*t3)
+	}
+	return brushSample{
+		x:     interpolate(p0.x, p1.x, p2.x, p3.x),
+		y:     interpolate(p0.y, p1.y, p2.y, p3.y),
+		width: p1.width + (p2.width-p1.width)*t,
+	}
+}
+
+func brushOutlineBBox(outline []brushSample) *sdl.Rect {
+	minX, minY := outline[0].x, outline[0].y
+	maxX, maxY := minX, minY
+	maxWidth := outline[0].width
+	for _, sample := range outline[1:] {
+		minX, minY = math.Min(minX, sample.x), math.Min(minY, sample.y)
+		maxX, maxY = math.Max(maxX, sample.x), math.Max(maxY, sample.y)
+		maxWidth = math.Max(maxWidth, sample.width)
+	}
+	padding := int32(math.Ceil(maxWidth/2)) + 2
+	return &sdl.Rect{
+		X: int32(math.Floor(minX)) - padding, Y: int32(math.Floor(minY)) - padding,
+		W: int32(math.Ceil(maxX-minX)) + padding*2 + 1, H: int32(math.Ceil(maxY-minY)) + padding*2 + 1,
+	}
+}
+
+func replaceStrokes(strokes []*paintStroke, index int, count int, replacement []*paintStroke) []*paintStroke {
+	result := make([]*paintStroke, 0, len(strokes)-count+len(replacement))
+	result = append(result, strokes[:index]...)
+	result = append(result, replacement...)
+	return append(result, strokes[index+count:]...)
+}
+
+type strokeCut struct {
+	index    int
+	original *paintStroke
+	pieces   []*paintStroke
+}
+
+type strokesCutAction struct {
+	tool *PaintTool
+	cuts []strokeCut
+}
+
+func (action strokesCutAction) Undo() {
+	for i := len(action.cuts) - 1; i >= 0; i-- {
+		cut := action.cuts[i]
+		action.tool.strokes = replaceStrokes(action.tool.strokes, cut.index, len(cut.pieces), []*paintStroke{cut.original})
+		for _, piece := range cut.pieces {
+			piece.destroyCache()
+		}
+	}
+}
+
+func (action strokesCutAction) Redo() {
+	for _, cut := range action.cuts {
+		action.tool.strokes = replaceStrokes(action.tool.strokes, cut.index, 1, cut.pieces)
+		cut.original.destroyCache()
+	}
 }
 
 type PaintAction struct {
 	tool       *PaintTool
-	lastStroke paintStroke
+	lastStroke *paintStroke
 }
 
 func (action PaintAction) Undo() {
 	action.tool.strokes = action.tool.strokes[:len(action.tool.strokes)-1]
+	action.lastStroke.destroyCache()
 }
 
 func (action PaintAction) Redo() {
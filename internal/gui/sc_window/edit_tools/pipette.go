@@ -8,6 +8,8 @@ import (
 	"github.com/Wine1y/trigat/assets"
 	"github.com/Wine1y/trigat/config"
 	"github.com/Wine1y/trigat/internal/gui"
+	"github.com/Wine1y/trigat/internal/gui/sc_window/settings"
+	"github.com/Wine1y/trigat/internal/palette"
 	"github.com/Wine1y/trigat/pkg"
 	"github.com/veandco/go-sdl2/sdl"
 	"github.com/veandco/go-sdl2/ttf"
@@ -24,40 +26,76 @@ const colorTipleteSquareMargin int32 = 5
 const colorTripletShadingFactor float64 = 0.5
 const colorTripletLightningFactor float64 = 1.5
 
+const pipetteHistoryRows int = 2
+const pipetteHistorySwatchSide int32 = 18
+const pipetteHistorySwatchMargin int32 = 4
+const pipetteHistorySwatchCornerRadius int32 = 3
+const pipetteExportButtonWidth int32 = 18
+const pipetteExportArrowThickness int32 = 2
+
 const pipetteMagnifierSide int = 11
 const pipetteMagnifierPixelSize int32 = 15
 
 var pipetteWidgetBackground sdl.Color = sdl.Color{R: 255, G: 255, B: 255, A: 255}
 var pipetteWidgetCurrentSquareColor sdl.Color = sdl.Color{R: 0, G: 0, B: 0, A: 255}
 var pipetteWidgetCopiedTextColor sdl.Color = sdl.Color{R: 255, G: 255, B: 255, A: 255}
+var pipetteHistoryEmptySwatchColor sdl.Color = sdl.Color{R: 230, G: 230, B: 230, A: 255}
+var pipetteExportButtonColor sdl.Color = sdl.Color{R: 230, G: 230, B: 230, A: 255}
+var pipetteExportArrowColor sdl.Color = sdl.Color{R: 90, G: 90, B: 90, A: 255}
 
 type PipetteTool struct {
 	ren           *sdl.Renderer
+	canvas        *sdl.Texture
 	isDragging    bool
 	widget        pipetteWidget
 	magnifier     pipetteMagnifier
 	deactivated   bool
 	handCursorSet bool
 	lastCursorPos sdl.Point
+	windowCursor  sdl.Point
+	format        pkg.ColorFormat
+	settings      []settings.ToolSetting
+	foreground    *pipetteProbe
+	background    *pipetteProbe
+	draggingProbe *pipetteProbe
+	contrast      *contrastWidget
 	DefaultScreenshotEditTool
 }
 
+// Colors are picked from the canvas, the renderer should target it while the tool is created
 func NewPipetteTool(renderer *sdl.Renderer) *PipetteTool {
-	vp := renderer.GetViewport()
-	widget := newPipetteWidget()
-	widget.resize(vp.W, vp.H)
-	return &PipetteTool{
+	tool := PipetteTool{
 		isDragging:  false,
 		ren:         renderer,
-		widget:      *widget,
+		canvas:      renderer.GetRenderTarget(),
+		widget:      *newPipetteWidget(),
 		deactivated: true,
+		format:      pkg.ColorFormats[0],
+		contrast:    newContrastWidget(),
+	}
+	currentFormat := 0
+	formatNames := make([]string, len(pkg.ColorFormats))
+	for i, format := range pkg.ColorFormats {
+		formatNames[i] = format.Name
+		if format.ID == config.GetPipetteConfig().Format {
+			tool.format, currentFormat = format, i
+		}
 	}
+	formatSetting := settings.NewListSetting(formatNames, currentFormat, func(option int) {
+		tool.format = pkg.ColorFormats[option]
+	})
+	tool.settings = []settings.ToolSetting{formatSetting}
+	return &tool
 }
 
 func (tool PipetteTool) ToolIcon() *sdl.Surface {
 	return assets.PipetteIcon
 }
 
+func (tool PipetteTool) ToolSettings() []settings.ToolSetting {
+	return tool.settings
+}
+
 func (tool *PipetteTool) OnToolActivated() {
 	tool.deactivated = false
 }
@@ -71,19 +109,19 @@ func (tool *PipetteTool) ToolCallbacks(_ *ActionsQueue) *gui.WindowCallbackSet {
 	callbacks := gui.NewWindowCallbackSet()
 
 	callbacks.MouseDown = append(callbacks.MouseDown, func(button uint8, x, y int32) bool {
-		click := sdl.Point{X: x, Y: y}
-		inWidget := click.InRect(&tool.widget.bbox)
 		switch {
-		case button == sdl.BUTTON_RIGHT && !inWidget:
+		case button == sdl.BUTTON_RIGHT:
 			color := tool.NewProbe(x, y)
-			tool.copyColorToClipboard(color)
-		case button == sdl.BUTTON_LEFT && !inWidget:
+			if err := tool.copyColorToClipboard(color); err != nil {
+				println(fmt.Sprintf("Can't copy color: %v", err))
+			}
+		case button == sdl.BUTTON_LEFT && sdl.GetModState()&sdl.KMOD_CTRL != 0:
+			tool.foreground = tool.pinProbe(tool.foreground, x, y)
+		case button == sdl.BUTTON_LEFT && sdl.GetModState()&sdl.KMOD_SHIFT != 0:
+			tool.background = tool.pinProbe(tool.background, x, y)
+		case button == sdl.BUTTON_LEFT:
 			tool.NewProbe(x, y)
 			tool.isDragging = true
-		case button == sdl.BUTTON_LEFT && inWidget:
-			if color, clickedAtColorBox := tool.widget.getColorBoxAt(x, y); clickedAtColorBox {
-				tool.copyColorToClipboard(*color)
-			}
 		}
 		return false
 	})
@@ -92,12 +130,8 @@ func (tool *PipetteTool) ToolCallbacks(_ *ActionsQueue) *gui.WindowCallbackSet {
 		if tool.isDragging {
 			tool.NewProbe(x, y)
 		}
-		if _, colorHovered := tool.widget.getColorBoxAt(x, y); colorHovered {
-			sdl.SetCursor(gui.HandCursor)
-			tool.handCursorSet = true
-		} else if tool.handCursorSet {
-			sdl.SetCursor(gui.ArrowCursor)
-			tool.handCursorSet = false
+		if tool.draggingProbe != nil {
+			tool.moveProbe(tool.draggingProbe, x, y)
 		}
 		tool.lastCursorPos.X, tool.lastCursorPos.Y = x, y
 		tool.magnifier.newPos(tool.ren, sdl.Point{X: x, Y: y})
@@ -105,22 +139,71 @@ func (tool *PipetteTool) ToolCallbacks(_ *ActionsQueue) *gui.WindowCallbackSet {
 	})
 
 	callbacks.MouseUp = append(callbacks.MouseUp, func(button uint8, x, y int32) bool {
-		if button != sdl.BUTTON_LEFT || !tool.isDragging {
+		if button != sdl.BUTTON_LEFT {
 			return false
 		}
 		tool.isDragging = false
+		tool.draggingProbe = nil
 		return false
 	})
 
-	callbacks.SizeChange = append(callbacks.SizeChange, func(w, h int32) bool {
-		tool.widget.resize(w, h)
-		return false
+	callbacks.KeyDown = append(callbacks.KeyDown, func(keysym sdl.Keysym) bool {
+		if keysym.Sym != sdl.K_DELETE && keysym.Sym != sdl.K_BACKSPACE {
+			return false
+		}
+		if tool.foreground == nil && tool.background == nil {
+			return false
+		}
+		tool.foreground, tool.background, tool.draggingProbe = nil, nil, nil
+		return true
 	})
 
 	callbacks.Quit = append(callbacks.Quit, func() bool {
 		if tool.widget.copiedTexture != nil {
 			tool.widget.copiedTexture.Destroy()
 		}
+		tool.contrast.destroy()
+		return false
+	})
+
+	return callbacks
+}
+
+// Widget is clicked in the window, clicks on it don't pick colors under it
+func (tool *PipetteTool) ControlsCallbacks() *gui.WindowCallbackSet {
+	callbacks := gui.NewWindowCallbackSet()
+
+	callbacks.MouseDown = append(callbacks.MouseDown, func(button uint8, x, y int32) bool {
+		click := sdl.Point{X: x, Y: y}
+		if !click.InRect(&tool.widget.bbox) {
+			return false
+		}
+		if button == sdl.BUTTON_LEFT {
+			if color, clickedAtColorBox := tool.widget.getColorBoxAt(x, y); clickedAtColorBox {
+				if err := tool.copyColorToClipboard(*color); err != nil {
+					println(fmt.Sprintf("Can't copy color: %v", err))
+				}
+			}
+			if click.InRect(&tool.widget.exportBBox) && len(tool.widget.history) > 0 {
+				if err := palette.ExportPalette(tool.widget.history); err != nil {
+					pkg.ShowErrorMessage("Can't export palette: %v", err)
+				}
+			}
+		}
+		return true
+	})
+
+	callbacks.MouseMove = append(callbacks.MouseMove, func(x, y int32) bool {
+		move := sdl.Point{X: x, Y: y}
+		_, colorHovered := tool.widget.getColorBoxAt(x, y)
+		if colorHovered || (move.InRect(&tool.widget.exportBBox) && len(tool.widget.history) > 0) {
+			sdl.SetCursor(gui.HandCursor)
+			tool.handCursorSet = true
+		} else if tool.handCursorSet {
+			sdl.SetCursor(gui.ArrowCursor)
+			tool.handCursorSet = false
+		}
+		tool.windowCursor = move
 		return false
 	})
 
@@ -133,28 +216,75 @@ func (tool *PipetteTool) NewProbe(x, y int32) sdl.Color {
 	return color
 }
 
+// Pinned probes are moved while dragging, so the contrast is updated live
+func (tool *PipetteTool) pinProbe(probe *pipetteProbe, x, y int32) *pipetteProbe {
+	if probe == nil {
+		probe = &pipetteProbe{}
+	}
+	tool.draggingProbe = probe
+	tool.moveProbe(probe, x, y)
+	return probe
+}
+
+func (tool *PipetteTool) moveProbe(probe *pipetteProbe, x, y int32) {
+	probe.pos = sdl.Point{X: x, Y: y}
+	probe.color = tool.getPixelColor(x, y)
+	if tool.foreground != nil && tool.background != nil {
+		tool.contrast.setColors(tool.foreground.color, tool.background.color)
+	}
+}
+
 func (tool PipetteTool) RenderScreenshot(_ *sdl.Renderer) {}
 
-func (tool PipetteTool) RenderCurrentState(ren *sdl.Renderer) {
-	if !tool.deactivated {
-		tool.widget.draw(ren)
-		if !tool.lastCursorPos.InRect(&tool.widget.bbox) {
-			tool.magnifier.draw(ren)
+func (tool PipetteTool) RenderCurrentState(_ *sdl.Renderer) {}
+
+// Widgets stay in the bottom left corner of the window, probes and the magnifier follow the canvas points
+func (tool *PipetteTool) RenderControls(ren *sdl.Renderer, toWindow func(x, y int32) (int32, int32)) {
+	if tool.deactivated {
+		return
+	}
+	if vp := ren.GetViewport(); vp.H != tool.widget.windowH {
+		tool.widget.resize(vp.W, vp.H)
+	}
+	tool.widget.draw(ren)
+	for _, probe := range []*pipetteProbe{tool.foreground, tool.background} {
+		if probe != nil {
+			x, y := toWindow(probe.pos.X, probe.pos.Y)
+			probe.draw(ren, sdl.Point{X: x, Y: y})
 		}
 	}
+	contrastShown := tool.foreground != nil && tool.background != nil
+	if contrastShown {
+		tool.contrast.draw(ren, tool.widget.bbox)
+	}
+	if !tool.windowCursor.InRect(&tool.widget.bbox) && !(contrastShown && tool.windowCursor.InRect(&tool.contrast.bbox)) {
+		tool.magnifier.draw(ren, tool.canvas, tool.windowCursor)
+	}
 }
 
+// Points outside of the canvas take the color of its nearest edge
 func (tool PipetteTool) getPixelColor(x, y int32) sdl.Color {
-	pixel := pkg.ReadRGBA32(tool.ren, &sdl.Rect{X: x, Y: y, W: 1, H: 1})
+	_, _, w, h, err := tool.canvas.Query()
+	if err != nil {
+		panic(err)
+	}
+	pixel := pkg.ReadTextureRGBA32(tool.ren, tool.canvas, sdl.Rect{X: pkg.Clamp(0, x, w-1), Y: pkg.Clamp(0, y, h-1), W: 1, H: 1})
 	return sdl.Color{R: pixel[0], G: pixel[1], B: pixel[2], A: pixel[3]}
 }
 
+// Color stays copied when the history can't be saved, the failure is only logged
 func (tool *PipetteTool) copyColorToClipboard(color sdl.Color) error {
-	copiedStr := fmt.Sprintf("#%02x%02x%02x", color.R, color.G, color.B)
+	copiedStr := tool.format.Format(color)
 	if err := sdl.SetClipboardText(copiedStr); err != nil {
 		return err
 	}
 	tool.widget.newCopiedColor(tool.ren, copiedStr)
+	history, err := palette.AddColorToHistory(color)
+	if err != nil {
+		println(fmt.Sprintf("Can't save color history: %v", err))
+		return nil
+	}
+	tool.widget.history = history
 	return nil
 }
 
@@ -164,8 +294,12 @@ func (tool PipetteTool) RequiresScreenDim() bool {
 
 type pipetteWidget struct {
 	bbox             sdl.Rect
+	windowH          int32
 	colorSquaresBBox [3]sdl.Rect
 	colors           [3]sdl.Color
+	history          []sdl.Color
+	historyBBoxes    []sdl.Rect
+	exportBBox       sdl.Rect
 	initialized      bool
 	lastCopiedString string
 	copiedAnimation  *pkg.Animation
@@ -180,15 +314,27 @@ func newPipetteWidget() *pipetteWidget {
 		1, true,
 	)
 	copiedAnimation.End()
+	history, err := palette.LoadColorHistory()
+	if err != nil {
+		println(fmt.Sprintf("Can't load color history: %v", err))
+	}
 	return &pipetteWidget{
+		history:         history,
+		historyBBoxes:   make([]sdl.Rect, palette.ColorHistorySize()),
 		copiedAnimation: copiedAnimation,
 		copiedFont:      assets.GetAppFont(pipetteWidgetCopiedFontSize),
 	}
 }
 
 func (widget *pipetteWidget) resize(w, h int32) {
+	widget.windowH = h
 	widgetW := colorTipleteSquareSide*int32(cap(widget.colors)) + colorTipleteSquareMargin*int32(cap(widget.colors)+1)
 	widgetH := colorTipleteSquareSide + colorTipleteSquareMargin*2
+	historyColumns := int32((len(widget.historyBBoxes) + pipetteHistoryRows - 1) / pipetteHistoryRows)
+	historyX := widgetW
+	if historyColumns > 0 {
+		widgetW += historyColumns*(pipetteHistorySwatchSide+pipetteHistorySwatchMargin) + pipetteExportButtonWidth + colorTipleteSquareMargin
+	}
 	widget.bbox = sdl.Rect{
 		X: pipetteWidgetMargin, Y: h - pipetteWidgetMargin - widgetH,
 		W: widgetW, H: widgetH,
@@ -200,6 +346,23 @@ func (widget *pipetteWidget) resize(w, h int32) {
 			W: colorTipleteSquareSide, H: colorTipleteSquareSide,
 		}
 	}
+	//History swatches fill the columns top to bottom, to the right of the triplet
+	historyTop := widget.bbox.Y + (widgetH-int32(pipetteHistoryRows)*pipetteHistorySwatchSide-pipetteHistorySwatchMargin)/2
+	for i := range widget.historyBBoxes {
+		widget.historyBBoxes[i] = sdl.Rect{
+			X: widget.bbox.X + historyX + int32(i/pipetteHistoryRows)*(pipetteHistorySwatchSide+pipetteHistorySwatchMargin),
+			Y: historyTop + int32(i%pipetteHistoryRows)*(pipetteHistorySwatchSide+pipetteHistorySwatchMargin),
+			W: pipetteHistorySwatchSide, H: pipetteHistorySwatchSide,
+		}
+	}
+	widget.exportBBox = sdl.Rect{}
+	if historyColumns > 0 {
+		widget.exportBBox = sdl.Rect{
+			X: widget.bbox.X + widget.bbox.W - colorTipleteSquareMargin - pipetteExportButtonWidth,
+			Y: widget.bbox.Y + colorTipleteSquareMargin,
+			W: pipetteExportButtonWidth, H: colorTipleteSquareSide,
+		}
+	}
 }
 
 func (widget *pipetteWidget) newColor(color sdl.Color) {
@@ -238,25 +401,34 @@ func (widget *pipetteWidget) newCopiedColor(ren *sdl.Renderer, copiedFormatStr s
 	widget.copiedAnimation.ReStart()
 }
 
+func (widget pipetteWidget) isVisible() bool {
+	return widget.initialized || len(widget.history) > 0
+}
+
 func (widget pipetteWidget) getColorBoxAt(x, y int32) (*sdl.Color, bool) {
-	if !widget.initialized {
+	if !widget.isVisible() {
 		return nil, false
 	}
 	point := sdl.Point{X: x, Y: y}
-	for i := 0; i < len(widget.colors); i++ {
+	for i := 0; i < len(widget.colors) && widget.initialized; i++ {
 		if point.InRect(&widget.colorSquaresBBox[i]) {
 			return &widget.colors[i], true
 		}
 	}
+	for i := 0; i < len(widget.history) && i < len(widget.historyBBoxes); i++ {
+		if point.InRect(&widget.historyBBoxes[i]) {
+			return &widget.history[i], true
+		}
+	}
 	return nil, false
 }
 
 func (widget pipetteWidget) draw(ren *sdl.Renderer) {
-	if !widget.initialized {
+	if !widget.isVisible() {
 		return
 	}
 	pkg.DrawRoundedFilledRectangle(ren, &widget.bbox, pipetteWidgetCornerRadius, pipetteWidgetBackground)
-	for i := 0; i < len(widget.colors); i++ {
+	for i := 0; i < len(widget.colors) && widget.initialized; i++ {
 		colorSquareBBox := widget.colorSquaresBBox[i]
 		pkg.DrawRoundedFilledRectangle(
 			ren,
@@ -265,6 +437,16 @@ func (widget pipetteWidget) draw(ren *sdl.Renderer) {
 			widget.colors[i],
 		)
 	}
+	for i := range widget.historyBBoxes {
+		swatchColor := pipetteHistoryEmptySwatchColor
+		if i < len(widget.history) {
+			swatchColor = widget.history[i]
+		}
+		pkg.DrawRoundedFilledRectangle(ren, &widget.historyBBoxes[i], pipetteHistorySwatchCornerRadius, swatchColor)
+	}
+	if widget.exportBBox.W > 0 {
+		widget.drawExportButton(ren)
+	}
 	if !widget.copiedAnimation.IsEnded() {
 		widget.copiedTexture.Texture.SetAlphaMod(uint8(widget.copiedAnimation.CurrentValue()))
 		textureLT := sdl.Point{
@@ -275,6 +457,15 @@ func (widget pipetteWidget) draw(ren *sdl.Renderer) {
 	}
 }
 
+func (widget pipetteWidget) drawExportButton(ren *sdl.Renderer) {
+	bbox := widget.exportBBox
+	pkg.DrawRoundedFilledRectangle(ren, &bbox, pipetteHistorySwatchCornerRadius, pipetteExportButtonColor)
+	centerX, arrowTop, arrowBottom := bbox.X+bbox.W/2, bbox.Y+bbox.H/4, bbox.Y+bbox.H*3/4
+	pkg.DrawThickLine(ren, &sdl.Point{X: centerX, Y: arrowTop}, &sdl.Point{X: centerX, Y: arrowBottom}, pipetteExportArrowThickness, pipetteExportArrowColor)
+	pkg.DrawThickLine(ren, &sdl.Point{X: centerX - bbox.W/4, Y: arrowBottom - bbox.W/4}, &sdl.Point{X: centerX, Y: arrowBottom}, pipetteExportArrowThickness, pipetteExportArrowColor)
+	pkg.DrawThickLine(ren, &sdl.Point{X: centerX + bbox.W/4, Y: arrowBottom - bbox.W/4}, &sdl.Point{X: centerX, Y: arrowBottom}, pipetteExportArrowThickness, pipetteExportArrowColor)
+}
+
 type pipetteMagnifier struct {
 	currentPos         sdl.Point
 	shouldUpdateColors bool
@@ -288,14 +479,14 @@ func (magnifier *pipetteMagnifier) newPos(ren *sdl.Renderer, pos sdl.Point) {
 	magnifier.initialized = true
 }
 
-func (magnifier *pipetteMagnifier) updateColors(ren *sdl.Renderer) {
+func (magnifier *pipetteMagnifier) updateColors(ren *sdl.Renderer, canvas *sdl.Texture) {
 	pitch := pipetteMagnifierSide * 4
 	colorsRect := sdl.Rect{
 		X: magnifier.currentPos.X - int32(pipetteMagnifierSide/2),
 		Y: magnifier.currentPos.Y - int32(pipetteMagnifierSide/2),
 		W: int32(pipetteMagnifierSide), H: int32(pipetteMagnifierSide),
 	}
-	pixels := pkg.ReadRGBA32(ren, &colorsRect)
+	pixels := pkg.ReadTextureRGBA32(ren, canvas, colorsRect)
 	for row := 0; row < len(magnifier.currentColors); row++ {
 		for column := 0; column < len(magnifier.currentColors[row]); column++ {
 			pos := row*pitch + column*4
@@ -309,16 +500,17 @@ func (magnifier *pipetteMagnifier) updateColors(ren *sdl.Renderer) {
 	}
 }
 
-func (magnifier *pipetteMagnifier) draw(ren *sdl.Renderer) {
+// Magnified canvas pixels are drawn around the window point
+func (magnifier *pipetteMagnifier) draw(ren *sdl.Renderer, canvas *sdl.Texture, center sdl.Point) {
 	if !magnifier.initialized {
 		return
 	}
 	if magnifier.shouldUpdateColors {
-		magnifier.updateColors(ren)
+		magnifier.updateColors(ren, canvas)
 		magnifier.shouldUpdateColors = false
 	}
-	x := magnifier.currentPos.X - (int32(pipetteMagnifierSide) / 2 * pipetteMagnifierPixelSize) - pipetteMagnifierPixelSize/2
-	y := magnifier.currentPos.Y - (int32(pipetteMagnifierSide) / 2 * pipetteMagnifierPixelSize) - pipetteMagnifierPixelSize/2
+	x := center.X - (int32(pipetteMagnifierSide) / 2 * pipetteMagnifierPixelSize) - pipetteMagnifierPixelSize/2
+	y := center.Y - (int32(pipetteMagnifierSide) / 2 * pipetteMagnifierPixelSize) - pipetteMagnifierPixelSize/2
 	for row := 0; row < pipetteMagnifierSide; row++ {
 		for column := 0; column < pipetteMagnifierSide; column++ {
 			if color := magnifier.currentColors[row][column]; color != nil {
@@ -0,0 +1,166 @@
+package editTools
+
+import (
+	"fmt"
+
+	"github.com/Wine1y/trigat/assets"
+	"github.com/Wine1y/trigat/pkg"
+	"github.com/veandco/go-sdl2/sdl"
+	"github.com/veandco/go-sdl2/ttf"
+)
+
+const contrastWidgetPadding int32 = 5
+const contrastWidgetPreviewSide int32 = 40
+const contrastWidgetFontSize int = 12
+const contrastWidgetPreviewFontSize int = 18
+const contrastBadgePadding int32 = 4
+const contrastBadgeMargin int32 = 4
+const contrastBadgeCornerRadius int32 = 3
+
+const probeMarkerRadius int32 = 7
+const probeMarkerThickness int32 = 2
+
+var contrastWidgetTextColor = sdl.Color{R: 0, G: 0, B: 0, A: 255}
+var contrastBadgeTextColor = sdl.Color{R: 255, G: 255, B: 255, A: 255}
+var contrastBadgePassColor = sdl.Color{R: 40, G: 150, B: 70, A: 255}
+var contrastBadgeFailColor = sdl.Color{R: 190, G: 50, B: 50, A: 255}
+var probeMarkerInnerColor = sdl.Color{R: 255, G: 255, B: 255, A: 255}
+var probeMarkerOuterColor = sdl.Color{R: 0, G: 0, B: 0, A: 255}
+
+var contrastBadgeLabels = []string{"AA", "AAA", "AA large", "AAA large"}
+
+type pipetteProbe struct {
+	pos   sdl.Point
+	color sdl.Color
+}
+
+// Probe position is on the canvas, its marker is drawn at the window point
+func (probe pipetteProbe) draw(ren *sdl.Renderer, center sdl.Point) {
+	pkg.DrawThickCircle(ren, &center, probeMarkerRadius+probeMarkerThickness, 1, probeMarkerOuterColor)
+	pkg.DrawThickCircle(ren, &center, probeMarkerRadius, probeMarkerThickness, probeMarkerInnerColor)
+}
+
+type contrastWidget struct {
+	bbox            sdl.Rect
+	foreground      sdl.Color
+	background      sdl.Color
+	contrast        pkg.WCAGContrast
+	apca            float64
+	font            *ttf.Font
+	previewFont     *ttf.Font
+	summaryTexture  *pkg.StringTexture
+	previewTexture  *pkg.StringTexture
+	badgeTextures   []*pkg.StringTexture
+	shouldUpdate    bool
+	lastRenderer    *sdl.Renderer
+	lastSummary     string
+	lastPreviewText sdl.Color
+}
+
+func newContrastWidget() *contrastWidget {
+	return &contrastWidget{
+		font:        assets.GetAppFont(contrastWidgetFontSize),
+		previewFont: assets.GetAppFont(contrastWidgetPreviewFontSize),
+	}
+}
+
+func (widget *contrastWidget) setColors(foreground, background sdl.Color) {
+	widget.foreground, widget.background = foreground, background
+	widget.contrast = pkg.CheckWCAGContrast(foreground, background)
+	widget.apca = pkg.APCAContrast(foreground, background)
+	widget.shouldUpdate = true
+}
+
+// Widget is placed to the right of the pipette widget and has the same height
+func (widget *contrastWidget) draw(ren *sdl.Renderer, pipetteBBox sdl.Rect) {
+	if ren != widget.lastRenderer {
+		widget.destroy()
+		widget.lastRenderer = ren
+		widget.badgeTextures = make([]*pkg.StringTexture, len(contrastBadgeLabels))
+		for i, label := range contrastBadgeLabels {
+			widget.badgeTextures[i] = pkg.NewStringTexture(ren, widget.font, label, contrastBadgeTextColor)
+		}
+		widget.shouldUpdate = true
+	}
+	if widget.shouldUpdate {
+		widget.updateTextures(ren)
+		widget.shouldUpdate = false
+	}
+
+	badgesW := int32(0)
+	for _, texture := range widget.badgeTextures {
+		badgesW += texture.TextWidth + contrastBadgePadding*2 + contrastBadgeMargin
+	}
+	widget.bbox = sdl.Rect{
+		X: pipetteBBox.X + pipetteBBox.W + pipetteWidgetMargin, Y: pipetteBBox.Y,
+		W: contrastWidgetPadding*3 + contrastWidgetPreviewSide + pkg.Max(badgesW-contrastBadgeMargin, widget.summaryTexture.TextWidth),
+		H: pipetteBBox.H,
+	}
+	pkg.DrawRoundedFilledRectangle(ren, &widget.bbox, pipetteWidgetCornerRadius, pipetteWidgetBackground)
+
+	preview := sdl.Rect{
+		X: widget.bbox.X + contrastWidgetPadding, Y: widget.bbox.Y + (widget.bbox.H-contrastWidgetPreviewSide)/2,
+		W: contrastWidgetPreviewSide, H: contrastWidgetPreviewSide,
+	}
+	pkg.DrawRoundedFilledRectangle(ren, &preview, colorTipleteCornerRadius, widget.background)
+	widget.previewTexture.Draw(ren, &sdl.Point{
+		X: preview.X + (preview.W-widget.previewTexture.TextWidth)/2,
+		Y: preview.Y + (preview.H-widget.previewTexture.TextHeight)/2,
+	})
+
+	textX := preview.X + preview.W + contrastWidgetPadding
+	badgeH := widget.badgeTextures[0].TextHeight + contrastBadgePadding/2
+	contentH := widget.summaryTexture.TextHeight + contrastBadgeMargin + badgeH
+	summaryY := widget.bbox.Y + (widget.bbox.H-contentH)/2
+	widget.summaryTexture.Draw(ren, &sdl.Point{X: textX, Y: summaryY})
+
+	passed := []bool{widget.contrast.AANormal, widget.contrast.AAANormal, widget.contrast.AALarge, widget.contrast.AAALarge}
+	badgeX := textX
+	for i, texture := range widget.badgeTextures {
+		badge := sdl.Rect{
+			X: badgeX, Y: summaryY + widget.summaryTexture.TextHeight + contrastBadgeMargin,
+			W: texture.TextWidth + contrastBadgePadding*2, H: badgeH,
+		}
+		badgeColor := contrastBadgeFailColor
+		if passed[i] {
+			badgeColor = contrastBadgePassColor
+		}
+		pkg.DrawRoundedFilledRectangle(ren, &badge, contrastBadgeCornerRadius, badgeColor)
+		texture.Draw(ren, &sdl.Point{X: badge.X + contrastBadgePadding, Y: badge.Y + contrastBadgePadding/4})
+		badgeX += badge.W + contrastBadgeMargin
+	}
+}
+
+func (widget *contrastWidget) updateTextures(ren *sdl.Renderer) {
+	summary := fmt.Sprintf("%.2f:1   APCA Lc %.1f", widget.contrast.Ratio, widget.apca)
+	if summary != widget.lastSummary || widget.summaryTexture == nil {
+		if widget.summaryTexture != nil {
+			widget.summaryTexture.Destroy()
+		}
+		widget.summaryTexture = pkg.NewStringTexture(ren, widget.font, summary, contrastWidgetTextColor)
+		widget.lastSummary = summary
+	}
+	if widget.foreground != widget.lastPreviewText || widget.previewTexture == nil {
+		if widget.previewTexture != nil {
+			widget.previewTexture.Destroy()
+		}
+		widget.previewTexture = pkg.NewStringTexture(ren, widget.previewFont, "Aa", widget.foreground)
+		widget.lastPreviewText = widget.foreground
+	}
+}
+
+func (widget *contrastWidget) destroy() {
+	if widget.summaryTexture != nil {
+		widget.summaryTexture.Destroy()
+		widget.summaryTexture = nil
+	}
+	if widget.previewTexture != nil {
+		widget.previewTexture.Destroy()
+		widget.previewTexture = nil
+	}
+	for _, texture := range widget.badgeTextures {
+		texture.Destroy()
+	}
+	widget.badgeTextures = nil
+	widget.lastRenderer = nil
+}
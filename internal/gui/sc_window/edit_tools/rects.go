@@ -10,14 +10,40 @@ import (
 	"github.com/veandco/go-sdl2/sdl"
 )
 
+const (
+	RectFillOutline  string = "outline"
+	RectFillSolid    string = "solid"
+	RectFillBordered string = "bordered"
+	RectFillTinted   string = "tinted"
+)
+
+const (
+	rectColorTargetBorder int = iota
+	rectColorTargetFill
+)
+
+const maxRectCornerRadius uint = 30
+const rectTintedFillOpacity uint8 = 64
+
+var rectFillModes = []string{RectFillOutline, RectFillSolid, RectFillBordered, RectFillTinted}
+var rectFillModeNames = []string{"Outline", "Filled", "Two-color", "Tinted"}
+var rectColorTargetNames = []string{"Border", "Fill"}
+
 type RectsTool struct {
 	isDragging          bool
 	isShiftPressed      bool
 	rects               []rect
 	lastCursorPos       sdl.Point
 	rectBorderThickness int32
+	rectCornerRadius    int32
 	rectColor           sdl.Color
+	rectFillColor       sdl.Color
+	rectFillMode        string
+	rectOpacity         uint8
+	rectPattern         pkg.StrokePattern
+	colorTarget         int
 	settings            []settings.ToolSetting
+	colorPicker         *settings.ColorPickerSetting
 	DefaultScreenshotEditTool
 }
 
@@ -32,15 +58,51 @@ func NewRectsTool() *RectsTool {
 		tool.rectBorderThickness = int32(value)
 	})
 
+	//Leftmost value means square corners
+	radiusSlider := settings.NewSliderSetting(0, maxRectCornerRadius, func(value uint) {
+		tool.rectCornerRadius = int32(value)
+	})
+	radiusSlider.SetValue(0)
+
+	fillModeList := settings.NewListSetting(rectFillModeNames, 0, func(option int) {
+		tool.rectFillMode = rectFillModes[option]
+	})
+
+	opacitySlider := newOpacitySlider(func(opacity uint8) {
+		tool.rectOpacity = opacity
+	})
+
 	colorPicker := settings.NewColorPickerSetting(func(color sdl.Color) {
-		tool.rectColor = color
+		if tool.colorTarget == rectColorTargetFill {
+			tool.rectFillColor = color
+		} else {
+			tool.rectColor = color
+		}
 	})
 
-	toolSettings := []settings.ToolSetting{widthSlider, colorPicker}
+	colorTargetOptions := settings.NewOptionsSetting(rectColorTargetNames, rectColorTargetBorder, func(option int) {
+		tool.colorTarget = option
+		if option == rectColorTargetFill {
+			colorPicker.SetColor(tool.rectFillColor)
+		} else {
+			colorPicker.SetColor(tool.rectColor)
+		}
+	})
+
+	strokeSettings := newStrokeStyleSettings(func(pattern pkg.StrokePattern) {
+		tool.rectPattern = pattern
+	})
+
+	toolSettings := []settings.ToolSetting{widthSlider, radiusSlider}
+	toolSettings = append(toolSettings, strokeSettings...)
+	toolSettings = append(toolSettings, fillModeList, opacitySlider, colorTargetOptions, colorPicker)
 
 	tool.rectBorderThickness = int32(widthSlider.CurrentValue())
+	tool.rectFillMode = rectFillModes[fillModeList.CurrentOption()]
 	tool.rectColor = colorPicker.CurrentColor()
+	tool.rectFillColor = colorPicker.CurrentColor()
 	tool.settings = toolSettings
+	tool.colorPicker = colorPicker
 	return &tool
 }
 
@@ -60,7 +122,13 @@ func (tool *RectsTool) ToolCallbacks(queue *ActionsQueue) *gui.WindowCallbackSet
 			rect{
 				sdlRect:         &sdl.Rect{X: x, Y: y, W: 1, H: 1},
 				borderThickness: tool.rectBorderThickness,
-				color:           tool.rectColor,
+				cornerRadius:    tool.rectCornerRadius,
+				pattern:         tool.rectPattern,
+				fillMode:        tool.rectFillMode,
+				color:           withOpacity(tool.rectColor, tool.rectOpacity),
+				fillColor:       withOpacity(tool.currentFillColor(), tool.rectOpacity),
+				borderLayer:     &annotationLayer{},
+				fillLayer:       &annotationLayer{},
 			},
 		)
 		tool.isDragging = true
@@ -113,12 +181,19 @@ func (tool *RectsTool) ToolCallbacks(queue *ActionsQueue) *gui.WindowCallbackSet
 		return false
 	})
 
+	callbacks.Quit = append(callbacks.Quit, func() bool {
+		for _, rect := range tool.rects {
+			rect.destroyLayers()
+		}
+		return false
+	})
+
 	return callbacks
 }
 
 func (tool RectsTool) RenderCurrentState(ren *sdl.Renderer) {
-	for _, rect := range tool.rects {
-		pkg.DrawThickRectangle(ren, rect.sdlRect, rect.borderThickness, rect.color)
+	for i, rect := range tool.rects {
+		rect.draw(ren, !tool.isDragging || i < len(tool.rects)-1)
 	}
 }
 
@@ -134,6 +209,27 @@ func (tool RectsTool) ToolColor() *sdl.Color {
 	return &tool.rectColor
 }
 
+func (tool *RectsTool) SetToolColor(color sdl.Color) {
+	tool.colorPicker.SetColor(color)
+}
+
+func (tool *RectsTool) EraseAnnotations(center sdl.Point, radius int32) ToolAction {
+	return eraseAnnotations(&tool.rects, func(rect rect) bool {
+		return rect.touches(center, radius)
+	}, rect.destroyLayers)
+}
+
+func (tool RectsTool) currentFillColor() sdl.Color {
+	switch tool.rectFillMode {
+	case RectFillSolid:
+		return tool.rectColor
+	case RectFillTinted:
+		return withOpacity(tool.rectColor, rectTintedFillOpacity)
+	default:
+		return tool.rectFillColor
+	}
+}
+
 func (tool *RectsTool) OnToolDeactivated() {
 	tool.isShiftPressed = false
 	tool.isDragging = false
@@ -142,7 +238,81 @@ func (tool *RectsTool) OnToolDeactivated() {
 type rect struct {
 	sdlRect         *sdl.Rect
 	borderThickness int32
+	cornerRadius    int32
+	pattern         pkg.StrokePattern
+	fillMode        string
 	color           sdl.Color
+	fillColor       sdl.Color
+	borderLayer     *annotationLayer
+	fillLayer       *annotationLayer
+}
+
+// Fill and border are blended separately, fill only covers the inside of the border so they never overlap.
+// Finished rects are blended through cached layers, the rect being drawn is blended every frame
+func (rect rect) draw(ren *sdl.Renderer, isFinished bool) {
+	bbox := rect.bbox()
+	drawLayer := func(layer *annotationLayer, color sdl.Color, draw func(offset sdl.Point, color sdl.Color)) {
+		if !isFinished {
+			drawAnnotation(ren, bbox, color, draw)
+			return
+		}
+		layer.draw(ren, bbox, color, draw)
+	}
+	switch rect.fillMode {
+	case RectFillSolid:
+		drawLayer(rect.fillLayer, rect.fillColor, func(offset sdl.Point, opaque sdl.Color) {
+			outer := rect.outerRect(offset)
+			pkg.DrawRoundedFilledRectangle(ren, &outer, rect.cornerRadius, opaque)
+		})
+		return
+	case RectFillBordered, RectFillTinted:
+		drawLayer(rect.fillLayer, rect.fillColor, func(offset sdl.Point, opaque sdl.Color) {
+			shifted := sdl.Rect{X: rect.sdlRect.X + offset.X, Y: rect.sdlRect.Y + offset.Y, W: rect.sdlRect.W, H: rect.sdlRect.H}
+			pkg.DrawThickRoundedRectangleInside(ren, &shifted, rect.borderThickness, rect.cornerRadius, opaque)
+		})
+	}
+	drawLayer(rect.borderLayer, rect.color, func(offset sdl.Point, opaque sdl.Color) {
+		shifted := sdl.Rect{X: rect.sdlRect.X + offset.X, Y: rect.sdlRect.Y + offset.Y, W: rect.sdlRect.W, H: rect.sdlRect.H}
+		pkg.DrawStyledRectangle(ren, &shifted, rect.borderThickness, rect.cornerRadius, rect.pattern, opaque)
+	})
+}
+
+func (rect rect) destroyLayers() {
+	rect.borderLayer.destroy()
+	rect.fillLayer.destroy()
+}
+
+// Filled rects are touched anywhere, outlined ones only on the border
+func (rect rect) touches(center sdl.Point, radius int32) bool {
+	outer := rect.outerRect(sdl.Point{})
+	if !pkg.CircleIntersectsRect(center, radius, &outer) {
+		return false
+	}
+	if rect.fillMode != RectFillOutline {
+		return true
+	}
+	inner := sdl.Rect{
+		X: outer.X + rect.borderThickness + radius, Y: outer.Y + rect.borderThickness + radius,
+		W: outer.W - (rect.borderThickness+radius)*2, H: outer.H - (rect.borderThickness+radius)*2,
+	}
+	return inner.W < 0 || inner.H < 0 || !center.InRect(&inner)
+}
+
+// Returns the rect covered by the border, width and height are inclusive like in DrawRoundedFilledRectangle
+func (rect rect) outerRect(offset sdl.Point) sdl.Rect {
+	normalized := pkg.NormalizedRect(rect.sdlRect)
+	return sdl.Rect{
+		X: normalized.X - (rect.borderThickness-1)/2 + offset.X, Y: normalized.Y - (rect.borderThickness-1)/2 + offset.Y,
+		W: normalized.W + rect.borderThickness - 1, H: normalized.H + rect.borderThickness - 1,
+	}
+}
+
+func (rect rect) bbox() *sdl.Rect {
+	normalized := pkg.NormalizedRect(rect.sdlRect)
+	return &sdl.Rect{
+		X: normalized.X - rect.borderThickness, Y: normalized.Y - rect.borderThickness,
+		W: normalized.W + rect.borderThickness*2 + 1, H: normalized.H + rect.borderThickness*2 + 1,
+	}
 }
 
 type RectAction struct {
@@ -152,6 +322,7 @@ type RectAction struct {
 
 func (action RectAction) Undo() {
 	action.tool.rects = action.tool.rects[:len(action.tool.rects)-1]
+	action.lastRect.destroyLayers()
 }
 
 func (action RectAction) Redo() {
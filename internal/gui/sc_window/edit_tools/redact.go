@@ -0,0 +1,352 @@
+package editTools
+
+import (
+	"image"
+
+	"github.com/Wine1y/trigat/assets"
+	"github.com/Wine1y/trigat/config"
+	"github.com/Wine1y/trigat/internal/gui"
+	"github.com/Wine1y/trigat/internal/gui/sc_window/settings"
+	"github.com/Wine1y/trigat/pkg"
+	"github.com/veandco/go-sdl2/sdl"
+	"github.com/veandco/go-sdl2/ttf"
+)
+
+const (
+	RedactionStylePixelate string = "pixelate"
+	RedactionStyleBlackout string = "blackout"
+)
+
+const redactionBlockSize int = 12
+const redactionSuggestionPadding int32 = 3
+const redactionSuggestionThickness int32 = 2
+const redactionButtonSize int32 = 18
+const redactionButtonMargin int32 = 3
+const redactionButtonCornerRadius int32 = 4
+const redactionLabelPadding int32 = 4
+const redactionFontSize int = 12
+
+var redactionStyles = []string{RedactionStyleBlackout, RedactionStylePixelate}
+var redactionStyleNames = []string{"Black", "Pixelate"}
+var redactionBlackoutColor = sdl.Color{R: 0, G: 0, B: 0, A: 255}
+var redactionSuggestionColor = sdl.Color{R: 255, G: 170, B: 0, A: 255}
+var redactionSuggestionFillColor = sdl.Color{R: 255, G: 170, B: 0, A: 50}
+var redactionAcceptColor = sdl.Color{R: 40, G: 170, B: 70, A: 230}
+var redactionDismissColor = sdl.Color{R: 200, G: 50, B: 50, A: 230}
+var redactionButtonIconColor = sdl.Color{R: 255, G: 255, B: 255, A: 255}
+var redactionLabelBackgroundColor = sdl.Color{R: 0, G: 0, B: 0, A: 180}
+var redactionLabelColor = sdl.Color{R: 255, G: 255, B: 255, A: 255}
+
+type RedactTool struct {
+	ren         *sdl.Renderer
+	screenshot  *image.RGBA
+	font        *ttf.Font
+	regions     []*redactionRegion
+	suggestions []*redactionSuggestion
+	style       string
+	isDragging  bool
+	dragStart   sdl.Point
+	dragRect    *sdl.Rect
+	isActive    bool
+	settings    []settings.ToolSetting
+	DefaultScreenshotEditTool
+}
+
+type RedactionSuggestion struct {
+	Kind string
+	Rect sdl.Rect
+}
+
+func NewRedactTool(renderer *sdl.Renderer, screenshot *image.RGBA) *RedactTool {
+	tool := RedactTool{
+		ren:         renderer,
+		screenshot:  screenshot,
+		font:        assets.GetCanvasAppFont(redactionFontSize),
+		regions:     make([]*redactionRegion, 0),
+		suggestions: make([]*redactionSuggestion, 0),
+		style:       RedactionStyleBlackout,
+	}
+	currentStyle := 0
+	for i, style := range redactionStyles {
+		if style == config.GetRedactionConfig().Style {
+			tool.style, currentStyle = style, i
+		}
+	}
+	styleSetting := settings.NewOptionsSetting(redactionStyleNames, currentStyle, func(option int) {
+		tool.style = redactionStyles[option]
+	})
+	tool.settings = []settings.ToolSetting{styleSetting}
+	return &tool
+}
+
+func (tool RedactTool) ToolIcon() *sdl.Surface {
+	return assets.RedactIcon
+}
+
+func (tool RedactTool) ToolSettings() []settings.ToolSetting {
+	return tool.settings
+}
+
+func (tool *RedactTool) OnToolActivated() {
+	tool.isActive = true
+}
+
+func (tool *RedactTool) OnToolDeactivated() {
+	tool.isActive = false
+	tool.isDragging = false
+	tool.dragRect = nil
+}
+
+func (tool *RedactTool) SetSuggestions(suggestions []RedactionSuggestion) {
+	tool.destroySuggestions()
+	for _, suggestion := range suggestions {
+		tool.suggestions = append(tool.suggestions, tool.newSuggestion(suggestion))
+	}
+}
+
+func (tool *RedactTool) ToolCallbacks(queue *ActionsQueue) *gui.WindowCallbackSet {
+	callbacks := gui.NewWindowCallbackSet()
+
+	callbacks.MouseDown = append(callbacks.MouseDown, func(button uint8, x, y int32) bool {
+		if button != sdl.BUTTON_LEFT {
+			return false
+		}
+		click := sdl.Point{X: x, Y: y}
+		for i, suggestion := range tool.suggestions {
+			if click.InRect(&suggestion.acceptBBox) {
+				tool.acceptSuggestion(i, queue)
+				return true
+			}
+			if click.InRect(&suggestion.dismissBBox) {
+				tool.dismissSuggestion(i)
+				return true
+			}
+		}
+		tool.isDragging = true
+		tool.dragStart = click
+		tool.dragRect = &sdl.Rect{X: x, Y: y, W: 0, H: 0}
+		return false
+	})
+
+	callbacks.MouseMove = append(callbacks.MouseMove, func(x, y int32) bool {
+		if tool.isDragging {
+			tool.dragRect = selectionToBlitRect(&sdl.Rect{
+				X: tool.dragStart.X, Y: tool.dragStart.Y,
+				W: x - tool.dragStart.X, H: y - tool.dragStart.Y,
+			})
+			return false
+		}
+		move := sdl.Point{X: x, Y: y}
+		for _, suggestion := range tool.suggestions {
+			if move.InRect(&suggestion.acceptBBox) || move.InRect(&suggestion.dismissBBox) {
+				sdl.SetCursor(gui.HandCursor)
+				return false
+			}
+		}
+		sdl.SetCursor(gui.ArrowCursor)
+		return false
+	})
+
+	callbacks.MouseUp = append(callbacks.MouseUp, func(button uint8, x, y int32) bool {
+		if button != sdl.BUTTON_LEFT || !tool.isDragging {
+			return false
+		}
+		tool.isDragging = false
+		if tool.dragRect != nil && tool.dragRect.W > 1 && tool.dragRect.H > 1 {
+			tool.addRegion(*tool.dragRect, queue)
+		}
+		tool.dragRect = nil
+		return false
+	})
+
+	callbacks.KeyDown = append(callbacks.KeyDown, func(keysym sdl.Keysym) bool {
+		if len(tool.suggestions) == 0 {
+			return false
+		}
+		switch keysym.Sym {
+		case sdl.K_RETURN, sdl.K_KP_ENTER:
+			for len(tool.suggestions) > 0 {
+				tool.acceptSuggestion(0, queue)
+			}
+			return true
+		case sdl.K_DELETE:
+			tool.destroySuggestions()
+			return true
+		}
+		return false
+	})
+
+	callbacks.Quit = append(callbacks.Quit, func() bool {
+		for _, region := range tool.regions {
+			region.destroy()
+		}
+		tool.destroySuggestions()
+		tool.font.Close()
+		return false
+	})
+
+	return callbacks
+}
+
+func (tool RedactTool) RenderCurrentState(ren *sdl.Renderer) {
+	tool.RenderScreenshot(ren)
+	if tool.dragRect != nil {
+		tool.renderRegion(ren, tool.dragRect, tool.style, nil)
+	}
+	if !tool.isActive {
+		return
+	}
+	for _, suggestion := range tool.suggestions {
+		suggestion.draw(ren)
+	}
+}
+
+func (tool RedactTool) RenderScreenshot(ren *sdl.Renderer) {
+	for _, region := range tool.regions {
+		tool.renderRegion(ren, &region.rect, region.style, region.texture)
+	}
+}
+
+func (tool RedactTool) renderRegion(ren *sdl.Renderer, rect *sdl.Rect, style string, texture *sdl.Texture) {
+	if style == RedactionStylePixelate && texture != nil {
+		pkg.CopyTexture(ren, texture, rect, nil)
+		return
+	}
+	pkg.DrawFilledRectangle(ren, rect, redactionBlackoutColor)
+}
+
+func (tool *RedactTool) addRegion(rect sdl.Rect, queue *ActionsQueue) {
+	region := &redactionRegion{rect: rect, style: tool.style}
+	if tool.style == RedactionStylePixelate {
+		region.texture = tool.pixelatedTexture(rect)
+	}
+	tool.regions = append(tool.regions, region)
+	queue.Push(redactionAddedAction{tool: tool, region: region})
+}
+
+func (tool *RedactTool) pixelatedTexture(rect sdl.Rect) *sdl.Texture {
+	pixelated := pkg.PixelateImage(
+		tool.screenshot,
+		image.Rect(int(rect.X), int(rect.Y), int(rect.X+rect.W), int(rect.Y+rect.H)),
+		redactionBlockSize,
+	)
+	bounds := pixelated.Bounds()
+	surface, err := sdl.CreateRGBSurfaceWithFormat(0, int32(bounds.Dx()), int32(bounds.Dy()), 32, uint32(sdl.PIXELFORMAT_RGBA32))
+	if err != nil {
+		panic(err)
+	}
+	defer surface.Free()
+	surfacePixels := surface.Pixels()
+	rowSize := bounds.Dx() * 4
+	for y := 0; y < bounds.Dy(); y++ {
+		copy(surfacePixels[y*int(surface.Pitch):y*int(surface.Pitch)+rowSize], pixelated.Pix[y*pixelated.Stride:y*pixelated.Stride+rowSize])
+	}
+	return pkg.CreateTextureFromSurface(tool.ren, surface)
+}
+
+func (tool *RedactTool) acceptSuggestion(index int, queue *ActionsQueue) {
+	suggestion := tool.suggestions[index]
+	tool.addRegion(suggestion.rect, queue)
+	tool.dismissSuggestion(index)
+}
+
+func (tool *RedactTool) dismissSuggestion(index int) {
+	tool.suggestions[index].destroy()
+	tool.suggestions = append(tool.suggestions[:index], tool.suggestions[index+1:]...)
+}
+
+func (tool *RedactTool) destroySuggestions() {
+	for _, suggestion := range tool.suggestions {
+		suggestion.destroy()
+	}
+	tool.suggestions = make([]*redactionSuggestion, 0)
+}
+
+type redactionRegion struct {
+	rect    sdl.Rect
+	style   string
+	texture *sdl.Texture
+}
+
+func (region *redactionRegion) destroy() {
+	if region.texture != nil {
+		region.texture.Destroy()
+		region.texture = nil
+	}
+}
+
+type redactionSuggestion struct {
+	rect         sdl.Rect
+	labelTexture *pkg.StringTexture
+	labelBBox    sdl.Rect
+	acceptBBox   sdl.Rect
+	dismissBBox  sdl.Rect
+}
+
+func (tool *RedactTool) newSuggestion(suggestion RedactionSuggestion) *redactionSuggestion {
+	rect := suggestion.Rect
+	newSuggestion := redactionSuggestion{
+		rect: sdl.Rect{
+			X: rect.X - redactionSuggestionPadding, Y: rect.Y - redactionSuggestionPadding,
+			W: rect.W + redactionSuggestionPadding*2, H: rect.H + redactionSuggestionPadding*2,
+		},
+		labelTexture: pkg.NewStringTexture(tool.ren, tool.font, suggestion.Kind, redactionLabelColor),
+	}
+	rect = newSuggestion.rect
+
+	//Controls are placed above the suggestion, or under it at the top of the screen
+	controlsY := rect.Y - redactionButtonSize - redactionButtonMargin
+	if controlsY < 0 {
+		controlsY = rect.Y + rect.H + redactionButtonMargin
+	}
+	newSuggestion.labelBBox = sdl.Rect{
+		X: rect.X, Y: controlsY,
+		W: newSuggestion.labelTexture.TextWidth + redactionLabelPadding*2, H: redactionButtonSize,
+	}
+	newSuggestion.acceptBBox = sdl.Rect{
+		X: newSuggestion.labelBBox.X + newSuggestion.labelBBox.W + redactionButtonMargin, Y: controlsY,
+		W: redactionButtonSize, H: redactionButtonSize,
+	}
+	newSuggestion.dismissBBox = sdl.Rect{
+		X: newSuggestion.acceptBBox.X + redactionButtonSize + redactionButtonMargin, Y: controlsY,
+		W: redactionButtonSize, H: redactionButtonSize,
+	}
+	return &newSuggestion
+}
+
+func (suggestion redactionSuggestion) draw(ren *sdl.Renderer) {
+	pkg.DrawFilledRectangle(ren, &suggestion.rect, redactionSuggestionFillColor)
+	pkg.DrawThickRectangle(ren, &suggestion.rect, redactionSuggestionThickness, redactionSuggestionColor)
+
+	pkg.DrawRoundedFilledRectangle(ren, &suggestion.labelBBox, redactionButtonCornerRadius, redactionLabelBackgroundColor)
+	suggestion.labelTexture.Draw(ren, &sdl.Point{
+		X: suggestion.labelBBox.X + redactionLabelPadding,
+		Y: suggestion.labelBBox.Y + (suggestion.labelBBox.H-suggestion.labelTexture.TextHeight)/2,
+	})
+
+	accept, dismiss := suggestion.acceptBBox, suggestion.dismissBBox
+	pkg.DrawRoundedFilledRectangle(ren, &accept, redactionButtonCornerRadius, redactionAcceptColor)
+	pkg.DrawThickLine(ren, &sdl.Point{X: accept.X + 4, Y: accept.Y + accept.H/2}, &sdl.Point{X: accept.X + accept.W/2 - 1, Y: accept.Y + accept.H - 5}, 2, redactionButtonIconColor)
+	pkg.DrawThickLine(ren, &sdl.Point{X: accept.X + accept.W/2 - 1, Y: accept.Y + accept.H - 5}, &sdl.Point{X: accept.X + accept.W - 4, Y: accept.Y + 4}, 2, redactionButtonIconColor)
+
+	pkg.DrawRoundedFilledRectangle(ren, &dismiss, redactionButtonCornerRadius, redactionDismissColor)
+	pkg.DrawThickLine(ren, &sdl.Point{X: dismiss.X + 5, Y: dismiss.Y + 5}, &sdl.Point{X: dismiss.X + dismiss.W - 5, Y: dismiss.Y + dismiss.H - 5}, 2, redactionButtonIconColor)
+	pkg.DrawThickLine(ren, &sdl.Point{X: dismiss.X + dismiss.W - 5, Y: dismiss.Y + 5}, &sdl.Point{X: dismiss.X + 5, Y: dismiss.Y + dismiss.H - 5}, 2, redactionButtonIconColor)
+}
+
+func (suggestion *redactionSuggestion) destroy() {
+	suggestion.labelTexture.Destroy()
+}
+
+type redactionAddedAction struct {
+	tool   *RedactTool
+	region *redactionRegion
+}
+
+func (action redactionAddedAction) Undo() {
+	action.tool.regions = action.tool.regions[:len(action.tool.regions)-1]
+}
+
+func (action redactionAddedAction) Redo() {
+	action.tool.regions = append(action.tool.regions, action.region)
+}
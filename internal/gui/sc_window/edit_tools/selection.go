@@ -25,6 +25,7 @@ var selectionFillColor = sdl.Color{R: 255, G: 255, B: 255, A: 50}
 
 type SelectionTool struct {
 	ren            *sdl.Renderer
+	bounds         sdl.Rect
 	isDragging     bool
 	isShiftPressed bool
 	selection      *sdl.Rect
@@ -35,13 +36,14 @@ type SelectionTool struct {
 	DefaultScreenshotEditTool
 }
 
-func NewSelectionTool(renderer *sdl.Renderer, saveCallback, copyCallback, searchCallback func()) *SelectionTool {
+func NewSelectionTool(renderer *sdl.Renderer, saveCallback, copyCallback, searchCallback, uploadCallback, extractTextCallback, decodeCodesCallback func()) *SelectionTool {
 	return &SelectionTool{
 		isDragging:     false,
 		isShiftPressed: false,
 		sizeTooltip:    &selectionSizeTooltip{font: assets.GetAppFont(14)},
-		actionsTooltip: NewSelectionActionsTooltip(renderer, saveCallback, copyCallback, searchCallback),
+		actionsTooltip: NewSelectionActionsTooltip(renderer, saveCallback, copyCallback, searchCallback, uploadCallback, extractTextCallback, decodeCodesCallback),
 		ren:            renderer,
+		bounds:         renderer.GetViewport(),
 	}
 }
 
@@ -61,10 +63,6 @@ func (tool *SelectionTool) ToolCallbacks(_ *ActionsQueue) *gui.WindowCallbackSet
 		if button != sdl.BUTTON_LEFT {
 			return false
 		}
-		if action, actionHovered := tool.actionsTooltip.getActionAt(x, y); actionHovered {
-			action.callback()
-			return false
-		}
 		tool.selection = &sdl.Rect{X: x, Y: y, W: 1, H: 1}
 		tool.updateTooltips()
 		tool.isDragging = true
@@ -81,14 +79,6 @@ func (tool *SelectionTool) ToolCallbacks(_ *ActionsQueue) *gui.WindowCallbackSet
 				pkg.RectIntoSquare(sel)
 			}
 			tool.updateTooltips()
-			return false
-		}
-		if _, actionHovered := tool.actionsTooltip.getActionAt(x, y); actionHovered {
-			sdl.SetCursor(gui.HandCursor)
-			tool.handCursorSet = true
-		} else if tool.handCursorSet {
-			sdl.SetCursor(gui.ArrowCursor)
-			tool.handCursorSet = false
 		}
 		return false
 	})
@@ -110,8 +100,8 @@ func (tool *SelectionTool) ToolCallbacks(_ *ActionsQueue) *gui.WindowCallbackSet
 			tool.isShiftPressed = true
 		}
 		if keysym.Sym == sdl.K_a && (keysym.Mod&sdl.KMOD_CTRL != 0) {
-			vp := tool.ren.GetViewport()
-			tool.selection = &vp
+			bounds := tool.bounds
+			tool.selection = &bounds
 			tool.updateTooltips()
 		}
 
@@ -140,6 +130,38 @@ func (tool *SelectionTool) ToolCallbacks(_ *ActionsQueue) *gui.WindowCallbackSet
 	return callbacks
 }
 
+// Actions are clicked in the window, where the tooltip is drawn
+func (tool *SelectionTool) ControlsCallbacks() *gui.WindowCallbackSet {
+	callbacks := gui.NewWindowCallbackSet()
+
+	callbacks.MouseDown = append(callbacks.MouseDown, func(button uint8, x, y int32) bool {
+		if button != sdl.BUTTON_LEFT {
+			return false
+		}
+		if action, actionHovered := tool.actionsTooltip.getActionAt(x, y); actionHovered {
+			action.callback()
+			return true
+		}
+		return false
+	})
+
+	callbacks.MouseMove = append(callbacks.MouseMove, func(x, y int32) bool {
+		if tool.isDragging {
+			return false
+		}
+		if _, actionHovered := tool.actionsTooltip.getActionAt(x, y); actionHovered {
+			sdl.SetCursor(gui.HandCursor)
+			tool.handCursorSet = true
+		} else if tool.handCursorSet {
+			sdl.SetCursor(gui.ArrowCursor)
+			tool.handCursorSet = false
+		}
+		return false
+	})
+
+	return callbacks
+}
+
 func (tool *SelectionTool) updateTooltips() {
 	tool.sizeTooltip.updateTooltip(tool.ren, tool.selection)
 	tool.actionsTooltip.updateTooltip(tool.selection)
@@ -150,13 +172,24 @@ func (tool *SelectionTool) destroyTooltips() {
 	tool.actionsTooltip.destroy()
 }
 
-func (tool SelectionTool) RenderCurrentState(ren *sdl.Renderer) {
-	if tool.selection != nil {
-		sel := tool.selection
-		pkg.DrawFilledRectangle(ren, sel, selectionFillColor)
-		pkg.DrawThickRectangle(ren, sel, selectionThickness, selectionBorderColor)
-		tool.sizeTooltip.draw(ren)
+func (tool SelectionTool) RenderCurrentState(_ *sdl.Renderer) {}
+
+// Selection border stays thin at any zoom, so the selected pixels aren't covered by it
+func (tool SelectionTool) RenderControls(ren *sdl.Renderer, toWindow func(x, y int32) (int32, int32)) {
+	vp := ren.GetViewport()
+	if tool.selection == nil {
+		tool.actionsTooltip.placeAtCorner(vp.W)
+		tool.actionsTooltip.draw(ren)
+		return
 	}
+	x1, y1 := toWindow(tool.selection.X, tool.selection.Y)
+	x2, y2 := toWindow(tool.selection.X+tool.selection.W, tool.selection.Y+tool.selection.H)
+	sel := sdl.Rect{X: x1, Y: y1, W: x2 - x1, H: y2 - y1}
+	pkg.DrawFilledRectangle(ren, &sel, selectionFillColor)
+	pkg.DrawThickRectangle(ren, &sel, selectionThickness, selectionBorderColor)
+	tool.sizeTooltip.place(&sel, vp.H)
+	tool.sizeTooltip.draw(ren)
+	tool.actionsTooltip.place(&sel)
 	tool.actionsTooltip.draw(ren)
 }
 
@@ -182,6 +215,14 @@ func (tool SelectionTool) CropScreenshot(surface *sdl.Surface) *sdl.Surface {
 	return croppedSurface
 }
 
+func (tool SelectionTool) CropRect() *sdl.Rect {
+	if tool.selection == nil {
+		bounds := tool.bounds
+		return &bounds
+	}
+	return selectionToBlitRect(tool.selection)
+}
+
 type selectionSizeTooltip struct {
 	texture *pkg.StringTexture
 	bbox    sdl.Rect
@@ -190,11 +231,16 @@ type selectionSizeTooltip struct {
 
 func (tooltip *selectionSizeTooltip) updateTooltip(ren *sdl.Renderer, selection *sdl.Rect) {
 	text := fmt.Sprintf("%v x %v", pkg.Abs(selection.W), pkg.Abs(selection.H))
-	textW, textH := pkg.SizeString(tooltip.font, text)
-
-	tooltip.bbox.W = int32(textW) + selectionTooltipPadding*2
-	tooltip.bbox.H = int32(textH) + selectionTooltipPadding*2
+	if tooltip.texture != nil {
+		tooltip.texture.Destroy()
+	}
+	tooltip.texture = pkg.NewStringTexture(ren, tooltip.font, text, selectionTooltipForegroundColor)
+	tooltip.bbox.W = tooltip.texture.TextWidth + selectionTooltipPadding*2
+	tooltip.bbox.H = tooltip.texture.TextHeight + selectionTooltipPadding*2
+}
 
+// Tooltip is placed under the selection in the window, or inside of it at the bottom of the window
+func (tooltip *selectionSizeTooltip) place(selection *sdl.Rect, windowH int32) {
 	tooltip.bbox.X = selection.X
 	if selection.W < 0 {
 		tooltip.bbox.X = selection.X + selection.W
@@ -205,12 +251,10 @@ func (tooltip *selectionSizeTooltip) updateTooltip(ren *sdl.Renderer, selection
 		tooltip.bbox.Y = selection.Y + selectionTooltipMargin
 	}
 
-	vp := ren.GetViewport()
-	if tooltip.bbox.Y+tooltip.bbox.H > vp.H {
+	if tooltip.bbox.Y+tooltip.bbox.H > windowH {
 		tooltip.bbox.Y -= (tooltip.bbox.H + selectionTooltipMargin*2 + selectionThickness)
 		tooltip.bbox.X += (selectionThickness + selectionTooltipMargin)
 	}
-	tooltip.texture = pkg.NewStringTexture(ren, tooltip.font, text, selectionTooltipForegroundColor)
 }
 
 func (tooltip *selectionSizeTooltip) draw(ren *sdl.Renderer) {
@@ -235,7 +279,7 @@ func (tooltip *selectionSizeTooltip) destroy() {
 	if tooltip.texture != nil {
 		tooltip.texture.Destroy()
 	}
-	tooltip.font.Close()
+	pkg.CloseFont(tooltip.font)
 }
 
 type selectionActionsTooltip struct {
@@ -245,30 +289,37 @@ type selectionActionsTooltip struct {
 	inSelection      bool
 }
 
-func NewSelectionActionsTooltip(ren *sdl.Renderer, saveCallback, copyCallback, searchCallback func()) *selectionActionsTooltip {
+func NewSelectionActionsTooltip(ren *sdl.Renderer, saveCallback, copyCallback, searchCallback, uploadCallback, extractTextCallback, decodeCodesCallback func()) *selectionActionsTooltip {
 	tooltip := selectionActionsTooltip{
 		actions: []*tooltipAction{
-			{texture: pkg.CreateTextureFromSurface(ren, assets.SearchIcon), callback: searchCallback},
-			{texture: pkg.CreateTextureFromSurface(ren, assets.CopyIcon), callback: copyCallback},
-			{texture: pkg.CreateTextureFromSurface(ren, assets.SaveIcon), callback: saveCallback},
+			newTooltipAction(ren, assets.SearchIcon, searchCallback),
+			newTooltipAction(ren, assets.UploadIcon, uploadCallback),
+			newTooltipAction(ren, assets.ExtractTextIcon, extractTextCallback),
+			newTooltipAction(ren, assets.DecodeCodesIcon, decodeCodesCallback),
+			newTooltipAction(ren, assets.CopyIcon, copyCallback),
+			newTooltipAction(ren, assets.SaveIcon, saveCallback),
 		},
 		actionsAvailable: true,
 	}
 
-	vp := ren.GetViewport()
 	tooltip.bbox.W = int32(len(tooltip.actions))*actionIconSize + int32(len(tooltip.actions)-1)*actionMargin + selectionTooltipPadding*2
 	tooltip.bbox.H = actionIconSize + selectionTooltipPadding*2
-	tooltip.bbox.X = vp.W - tooltip.bbox.W - selectionTooltipMargin
-	tooltip.bbox.Y = selectionTooltipMargin
-	tooltip.updateActionsPositions()
 	return &tooltip
 }
 
 func (tooltip *selectionActionsTooltip) updateTooltip(selection *sdl.Rect) {
-	tooltip.actionsAvailable = true
-	if selection.W == 0 || selection.H == 0 {
-		tooltip.actionsAvailable = false
-	}
+	tooltip.actionsAvailable = selection.W != 0 && selection.H != 0
+}
+
+// Without a selection the whole screenshot is used, so the tooltip waits in the top right corner of the window
+func (tooltip *selectionActionsTooltip) placeAtCorner(windowW int32) {
+	tooltip.bbox.X = windowW - tooltip.bbox.W - selectionTooltipMargin
+	tooltip.bbox.Y = selectionTooltipMargin
+	tooltip.updateActionsPositions()
+}
+
+// Tooltip is placed above the selection in the window, or inside of it at the top of the window
+func (tooltip *selectionActionsTooltip) place(selection *sdl.Rect) {
 	tooltip.bbox.X = selection.X + selection.W - tooltip.bbox.W
 	if selection.W < 0 {
 		tooltip.bbox.X = selection.X - tooltip.bbox.W
@@ -345,6 +396,12 @@ type tooltipAction struct {
 	callback func()
 }
 
+func newTooltipAction(ren *sdl.Renderer, icon *sdl.Surface, callback func()) *tooltipAction {
+	scaledIcon := assets.GetScaledIcon(icon)
+	defer scaledIcon.Free()
+	return &tooltipAction{texture: pkg.CreateTextureFromSurface(ren, scaledIcon), callback: callback}
+}
+
 func selectionToBlitRect(selection *sdl.Rect) *sdl.Rect {
 	x, y := selection.X, selection.Y
 	if selection.W < 0 {
@@ -0,0 +1,28 @@
+package editTools
+
+import (
+	"github.com/Wine1y/trigat/internal/gui/sc_window/settings"
+	"github.com/Wine1y/trigat/pkg"
+)
+
+const minDashLength uint = 2
+const maxDashLength uint = 40
+const defaultDashLength uint = 10
+
+var strokeStyles = []pkg.StrokeStyle{pkg.StrokeSolid, pkg.StrokeDashed, pkg.StrokeDotted, pkg.StrokeDashDot}
+var strokeStyleNames = []string{"Solid", "Dashed", "Dotted", "Dash-dot"}
+
+// Stroke style list and dash length slider used by the shape tools
+func newStrokeStyleSettings(onPatternUpdated func(pattern pkg.StrokePattern)) []settings.ToolSetting {
+	pattern := pkg.StrokePattern{Style: strokeStyles[0]}
+	styleList := settings.NewListSetting(strokeStyleNames, 0, func(option int) {
+		pattern.Style = strokeStyles[option]
+		onPatternUpdated(pattern)
+	})
+	dashSlider := settings.NewSliderSetting(minDashLength, maxDashLength, func(value uint) {
+		pattern.DashLength = int32(value)
+		onPatternUpdated(pattern)
+	})
+	dashSlider.SetValue(defaultDashLength)
+	return []settings.ToolSetting{styleList, dashSlider}
+}
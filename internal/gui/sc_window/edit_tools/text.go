@@ -2,8 +2,8 @@ package editTools
 
 import (
 	_ "embed"
+	"math"
 	"time"
-	"unicode"
 
 	"github.com/Wine1y/trigat/assets"
 	"github.com/Wine1y/trigat/config"
@@ -17,47 +17,222 @@ import (
 var cursorColor = sdl.Color{R: 255, G: 255, B: 255, A: 255}
 var selectionColor = sdl.Color{R: 0, G: 0, B: 0, A: 100}
 var paragraphBoundariesColor = sdl.Color{R: 255, G: 255, B: 255, A: 255}
+var calloutHandleColor = sdl.Color{R: 255, G: 255, B: 255, A: 255}
+var calloutHandleOutlineColor = sdl.Color{R: 0, G: 0, B: 0, A: 160}
+var defaultTextBackgroundColor = sdl.Color{R: 0, G: 0, B: 0, A: 160}
+var defaultTextEffectColor = sdl.Color{R: 0, G: 0, B: 0, A: 255}
+var compositionBackgroundColor = sdl.Color{R: 40, G: 40, B: 40, A: 230}
+var compositionTextColor = sdl.Color{R: 255, G: 255, B: 255, A: 255}
+var compositionSelectionColor = sdl.Color{R: 255, G: 255, B: 255, A: 80}
+
+const (
+	textStyleBold int = iota
+	textStyleItalic
+	textStyleUnderline
+)
+
+const (
+	textColorTargetText int = iota
+	textColorTargetBackground
+	textColorTargetEffect
+)
 
 const paragraphPadding int32 = 5
 const paragraphDraggingPadding int32 = 5
 const cursorAnimationDuration time.Duration = time.Millisecond * 1250
+const minTextFontSize uint = 8
+const maxTextFontSize uint = 72
+const defaultTextFontSize uint = 14
+const maxTextBackgroundPadding uint = 20
+const defaultTextBackgroundPadding uint = 6
+const maxTextCornerRadius uint = 20
+const defaultTextCornerRadius uint = 4
+const calloutHandleRadius int32 = 5
+const calloutDefaultTailLength int32 = 30
+const compositionPadding int32 = 2
+const minParagraphWrapWidth int32 = 20
+const minLineSpacingPercent uint = 100
+const maxLineSpacingPercent uint = 300
+
+var textStyleNames = []string{"B", "I", "U"}
+var textBackgrounds = []string{pkg.TextBackgroundNone, pkg.TextBackgroundBox, pkg.TextBackgroundCallout}
+var textBackgroundNames = []string{"None", "Box", "Callout"}
+var textEffects = []string{pkg.TextEffectNone, pkg.TextEffectOutline, pkg.TextEffectShadow}
+var textEffectNames = []string{"Plain", "Outline", "Shadow"}
+var textColorTargetNames = []string{"Text", "Back", "Effect"}
+var textAlignments = []string{pkg.TextAlignLeft, pkg.TextAlignCenter, pkg.TextAlignRight}
+var textAlignmentNames = []string{"Left", "Center", "Right"}
 
 type TextTool struct {
 	paragraphs       []*pkg.TextParagraph
+	paragraphColors  map[*pkg.TextParagraph]paragraphColor
 	activeParagraph  *pkg.TextParagraph
 	ren              *sdl.Renderer
 	textFont         *ttf.Font
+	textStyle        pkg.TextStyle
+	textDecoration   pkg.TextDecoration
+	textLayout       pkg.TextLayout
+	colorTarget      int
+	fontCache        *pkg.FontCache
+	fontFamilies     []pkg.FontFamily
+	familiesLoaded   chan []pkg.FontFamily
 	textColor        sdl.Color
+	textOpacity      uint8
 	settings         []settings.ToolSetting
+	colorPicker      *settings.ColorPickerSetting
+	opacitySlider    *settings.SliderSetting
+	familyDropdown   *settings.DropdownSetting
+	sizeSlider       *settings.SliderSetting
+	styleToggles     *settings.TogglesSetting
+	alignmentOption  *settings.OptionsSetting
+	spacingSlider    *settings.SliderSetting
+	backgroundOption *settings.OptionsSetting
+	paddingSlider    *settings.SliderSetting
+	radiusSlider     *settings.SliderSetting
+	effectOption     *settings.OptionsSetting
 	cursorPos        int
 	cursorAnimation  *pkg.Animation
 	isShiftSelecting bool
 	isMouseSelecting bool
 	selection        textSelection
 	draggingHandle   textDraggingHandle
+	draggingTail     *pkg.TextParagraph
+	resizingWrap     *pkg.TextParagraph
+	composition      textComposition
 	iBeamCursorSet   bool
 	sizeAllCursorSet bool
+	sizeWECursorSet  bool
 	DefaultScreenshotEditTool
 }
 
 func NewTextTool(renderer *sdl.Renderer) *TextTool {
 	tool := TextTool{
-		paragraphs:     make([]*pkg.TextParagraph, 0),
-		ren:            renderer,
-		textFont:       assets.GetAppFont(14),
+		paragraphs:      make([]*pkg.TextParagraph, 0),
+		paragraphColors: make(map[*pkg.TextParagraph]paragraphColor),
+		ren:             renderer,
+		textStyle:       pkg.TextStyle{Family: assets.GetAppFontFamily(), Size: int(defaultTextFontSize)},
+		fontCache:       pkg.NewFontCache(),
+		fontFamilies:    []pkg.FontFamily{assets.GetAppFontFamily()},
+		familiesLoaded:  make(chan []pkg.FontFamily, 1),
+		textLayout:      pkg.TextLayout{Alignment: pkg.TextAlignLeft, LineSpacing: 1},
+		textDecoration: pkg.TextDecoration{
+			Background:      pkg.TextBackgroundNone,
+			BackgroundColor: defaultTextBackgroundColor,
+			Effect:          pkg.TextEffectNone,
+			EffectColor:     defaultTextEffectColor,
+		},
 		selection:      textSelection{start: 0, length: 0, selected: false},
 		draggingHandle: textDraggingHandle{draggingParagraph: nil, xHandleOffset: 0, yHandleOffset: 0},
 	}
 
 	colorPicker := settings.NewColorPickerSetting(func(color sdl.Color) {
-		tool.textColor = color
+		switch tool.colorTarget {
+		case textColorTargetBackground:
+			decoration := tool.textDecoration
+			decoration.BackgroundColor = color
+			tool.setTextDecoration(decoration)
+		case textColorTargetEffect:
+			decoration := tool.textDecoration
+			decoration.EffectColor = color
+			tool.setTextDecoration(decoration)
+		default:
+			tool.textColor = color
+			if tool.activeParagraph != nil {
+				tool.setParagraphColor(tool.activeParagraph)
+			}
+		}
+	})
+	colorTargetOptions := settings.NewOptionsSetting(textColorTargetNames, textColorTargetText, func(option int) {
+		tool.colorTarget = option
+		colorPicker.SetColor(tool.targetColor())
+	})
+	opacitySlider := newOpacitySlider(func(opacity uint8) {
+		tool.textOpacity = opacity
 		if tool.activeParagraph != nil {
-			tool.activeParagraph.SetColor(color)
+			tool.setParagraphColor(tool.activeParagraph)
 		}
 	})
-	toolSettings := []settings.ToolSetting{colorPicker}
+	familyDropdown := settings.NewDropdownSetting(tool.familyNames(), 0, func(option int) {
+		style := tool.textStyle
+		style.Family = tool.fontFamilies[option]
+		tool.setTextStyle(style)
+	})
+	sizeSlider := settings.NewSliderSetting(minTextFontSize, maxTextFontSize, func(value uint) {
+		style := tool.textStyle
+		style.Size = int(value)
+		tool.setTextStyle(style)
+	})
+	styleToggles := settings.NewTogglesSetting(textStyleNames, func(option int, toggled bool) {
+		style := tool.textStyle
+		switch option {
+		case textStyleBold:
+			style.Bold = toggled
+		case textStyleItalic:
+			style.Italic = toggled
+		case textStyleUnderline:
+			style.Underline = toggled
+		}
+		tool.setTextStyle(style)
+	})
+	sizeSlider.SetValue(defaultTextFontSize)
+	alignmentOption := settings.NewOptionsSetting(textAlignmentNames, 0, func(option int) {
+		layout := tool.textLayout
+		layout.Alignment = textAlignments[option]
+		tool.setTextLayout(layout)
+	})
+	spacingSlider := settings.NewSliderSetting(minLineSpacingPercent, maxLineSpacingPercent, func(value uint) {
+		layout := tool.textLayout
+		layout.LineSpacing = float64(value) / 100
+		tool.setTextLayout(layout)
+	})
+	spacingSlider.SetValue(minLineSpacingPercent)
+	backgroundOption := settings.NewOptionsSetting(textBackgroundNames, 0, func(option int) {
+		decoration := tool.textDecoration
+		decoration.Background = textBackgrounds[option]
+		tool.setTextDecoration(decoration)
+	})
+	//Leftmost values mean no padding and square corners
+	paddingSlider := settings.NewSliderSetting(0, maxTextBackgroundPadding, func(value uint) {
+		decoration := tool.textDecoration
+		decoration.BackgroundPadding = int32(value)
+		tool.setTextDecoration(decoration)
+	})
+	paddingSlider.SetValue(defaultTextBackgroundPadding)
+	radiusSlider := settings.NewSliderSetting(0, maxTextCornerRadius, func(value uint) {
+		decoration := tool.textDecoration
+		decoration.CornerRadius = int32(value)
+		tool.setTextDecoration(decoration)
+	})
+	radiusSlider.SetValue(defaultTextCornerRadius)
+	effectOption := settings.NewOptionsSetting(textEffectNames, 0, func(option int) {
+		decoration := tool.textDecoration
+		decoration.Effect = textEffects[option]
+		tool.setTextDecoration(decoration)
+	})
+	toolSettings := []settings.ToolSetting{
+		familyDropdown, sizeSlider, styleToggles, alignmentOption, spacingSlider,
+		backgroundOption, paddingSlider, radiusSlider, effectOption,
+		opacitySlider, colorTargetOptions, colorPicker,
+	}
 	tool.textColor = colorPicker.CurrentColor()
 	tool.settings = toolSettings
+	tool.colorPicker = colorPicker
+	tool.opacitySlider = opacitySlider
+	tool.familyDropdown = familyDropdown
+	tool.sizeSlider = sizeSlider
+	tool.styleToggles = styleToggles
+	tool.alignmentOption = alignmentOption
+	tool.spacingSlider = spacingSlider
+	tool.backgroundOption = backgroundOption
+	tool.paddingSlider = paddingSlider
+	tool.radiusSlider = radiusSlider
+	tool.effectOption = effectOption
+
+	//Scanning the system fonts takes a while, so the embedded font is the only choice until it's done
+	go func() {
+		tool.familiesLoaded <- pkg.DiscoverFontFamilies()
+		gui.RequestRedraw()
+	}()
 	return &tool
 }
 
@@ -69,15 +244,30 @@ func (tool *TextTool) ToolCallbacks(queue *ActionsQueue) *gui.WindowCallbackSet
 	callbacks := gui.NewWindowCallbackSet()
 
 	callbacks.MouseDown = append(callbacks.MouseDown, func(button uint8, x, y int32) bool {
+		click := sdl.Point{X: x, Y: y}
+		//Right edge sets the wrap width, right click on it brings the unwrapped lines back
+		if par := tool.wrapEdgeAt(click); par != nil && button == sdl.BUTTON_RIGHT {
+			layout := par.Layout
+			layout.WrapWidth = 0
+			par.SetLayout(tool.ren, layout)
+			return false
+		}
 		if button != sdl.BUTTON_LEFT {
 			return false
 		}
-		click := sdl.Point{X: x, Y: y}
+		if par := tool.wrapEdgeAt(click); par != nil {
+			tool.resizingWrap = par
+			return false
+		}
+		if par := tool.tailHandleAt(click); par != nil {
+			tool.draggingTail = par
+			return false
+		}
 		for _, par := range tool.paragraphs {
 			bbox := par.GetBBox()
 			if click.InRect(bbox) {
 				tool.deselectText()
-				tool.activeParagraph = par
+				tool.activateParagraph(par)
 				tool.moveCursor(par.GetPositionByOffset(x-par.TextStart.X, y-par.TextStart.Y))
 				tool.isMouseSelecting = true
 				return false
@@ -92,11 +282,16 @@ func (tool *TextTool) ToolCallbacks(queue *ActionsQueue) *gui.WindowCallbackSet
 		}
 		newParagraph := pkg.NewTextParagraph(
 			sdl.Point{X: x, Y: y},
-			tool.textColor,
+			withOpacity(tool.textColor, tool.textOpacity),
 			tool.textFont,
 			paragraphPadding,
 		)
+		tool.paragraphColors[newParagraph] = paragraphColor{color: tool.textColor, opacity: tool.textOpacity}
+		newParagraph.Style = tool.textStyle
+		newParagraph.SetLayout(tool.ren, tool.textLayout)
+		tool.applyTextDecoration(newParagraph, tool.textDecoration)
 		tool.paragraphs = append(tool.paragraphs, newParagraph)
+		tool.setComposition("", 0, 0)
 		tool.activeParagraph = newParagraph
 		tool.cursorAnimation = pkg.NewLinearAnimation(255, 0, int(config.GetAppFPS()), cursorAnimationDuration, 0, true)
 		tool.deselectText()
@@ -121,6 +316,22 @@ func (tool *TextTool) ToolCallbacks(queue *ActionsQueue) *gui.WindowCallbackSet
 			tool.iBeamCursorSet = true
 			return false
 		}
+		if tool.resizingWrap != nil {
+			layout := tool.resizingWrap.Layout
+			layout.WrapWidth = pkg.Max(minParagraphWrapWidth, x-tool.resizingWrap.TextStart.X)
+			tool.resizingWrap.SetLayout(tool.ren, layout)
+			sdl.SetCursor(gui.SizeWECursor)
+			tool.sizeWECursorSet = true
+			return false
+		}
+		if tool.draggingTail != nil {
+			decoration := tool.draggingTail.Decoration
+			decoration.TailTarget = move
+			tool.draggingTail.SetDecoration(tool.ren, decoration)
+			sdl.SetCursor(gui.SizeAllCursor)
+			tool.sizeAllCursorSet = true
+			return false
+		}
 		if tool.draggingHandle.draggingParagraph != nil {
 			tool.draggingHandle.draggingParagraph.TextStart = sdl.Point{
 				X: move.X - tool.draggingHandle.xHandleOffset,
@@ -130,6 +341,16 @@ func (tool *TextTool) ToolCallbacks(queue *ActionsQueue) *gui.WindowCallbackSet
 			tool.sizeAllCursorSet = true
 			return false
 		}
+		if tool.tailHandleAt(move) != nil {
+			sdl.SetCursor(gui.SizeAllCursor)
+			tool.sizeAllCursorSet = true
+			return false
+		}
+		if tool.wrapEdgeAt(move) != nil {
+			sdl.SetCursor(gui.SizeWECursor)
+			tool.sizeWECursorSet = true
+			return false
+		}
 		for _, par := range tool.paragraphs {
 			if move.InRect(par.GetBBox()) {
 				sdl.SetCursor(gui.IBeamCursor)
@@ -143,13 +364,16 @@ func (tool *TextTool) ToolCallbacks(queue *ActionsQueue) *gui.WindowCallbackSet
 			}
 		}
 
-		if tool.iBeamCursorSet || tool.sizeAllCursorSet {
+		if tool.iBeamCursorSet || tool.sizeAllCursorSet || tool.sizeWECursorSet {
 			if tool.iBeamCursorSet {
 				tool.iBeamCursorSet = false
 			}
 			if tool.sizeAllCursorSet {
 				tool.sizeAllCursorSet = false
 			}
+			if tool.sizeWECursorSet {
+				tool.sizeWECursorSet = false
+			}
 			sdl.SetCursor(gui.ArrowCursor)
 		}
 		return false
@@ -163,31 +387,44 @@ func (tool *TextTool) ToolCallbacks(queue *ActionsQueue) *gui.WindowCallbackSet
 			if tool.draggingHandle.draggingParagraph != nil {
 				tool.draggingHandle.draggingParagraph = nil
 			}
+			tool.draggingTail = nil
+			tool.resizingWrap = nil
 		}
 		return false
 	})
 
-	callbacks.TextInput = append(callbacks.TextInput, func(rn rune) bool {
-		if tool.activeParagraph == nil || !unicode.IsGraphic(rn) {
+	callbacks.TextInput = append(callbacks.TextInput, func(text string) bool {
+		tool.setComposition("", 0, 0)
+		runes := pkg.InputRunes(text)
+		if tool.activeParagraph == nil || len(runes) == 0 {
 			return false
 		}
 		var newCursorPos int
 		if tool.selection.selected {
 			selStart, selEnd := tool.selection.selectionBounds()
-			tool.replaceInParagraph(tool.activeParagraph, selStart, selEnd, queue, rn)
-			newCursorPos = selStart + 1
+			tool.replaceInParagraph(tool.activeParagraph, selStart, selEnd, queue, runes...)
+			newCursorPos = selStart + len(runes)
 		} else {
-			tool.insertIntoParagraph(tool.activeParagraph, tool.cursorPos, queue, rn)
-			newCursorPos = tool.cursorPos + 1
+			tool.insertIntoParagraph(tool.activeParagraph, tool.cursorPos, queue, runes...)
+			newCursorPos = tool.cursorPos + len(runes)
 		}
 		tool.moveCursorIgnoreSelection(newCursorPos)
 		return false
 	})
 
-	callbacks.KeyDown = append(callbacks.KeyDown, func(keysym sdl.Keysym) bool {
+	callbacks.TextEditing = append(callbacks.TextEditing, func(text string, cursor, selectionLength int32) bool {
 		if tool.activeParagraph == nil {
 			return false
 		}
+		tool.setComposition(text, int(cursor), int(selectionLength))
+		return false
+	})
+
+	callbacks.KeyDown = append(callbacks.KeyDown, func(keysym sdl.Keysym) bool {
+		//Keys typed during a composition belong to the input method
+		if tool.activeParagraph == nil || len(tool.composition.text) > 0 {
+			return false
+		}
 		activePar := tool.activeParagraph
 
 		switch {
@@ -268,11 +505,10 @@ func (tool *TextTool) ToolCallbacks(queue *ActionsQueue) *gui.WindowCallbackSet
 
 	callbacks.Quit = append(callbacks.Quit, func() bool {
 		for _, par := range tool.paragraphs {
-			if par.StringTexture != nil {
-				par.StringTexture.Destroy()
-			}
+			par.DestroyTextures()
 		}
-		tool.textFont.Close()
+		tool.setComposition("", 0, 0)
+		tool.fontCache.Close()
 		return false
 	})
 
@@ -287,14 +523,207 @@ func (tool TextTool) ToolColor() *sdl.Color {
 	return &tool.textColor
 }
 
+func (tool *TextTool) SetToolColor(color sdl.Color) {
+	tool.colorPicker.SetColor(color)
+}
+
+func (tool *TextTool) EraseAnnotations(center sdl.Point, radius int32) ToolAction {
+	return eraseAnnotations(&tool.paragraphs, func(par *pkg.TextParagraph) bool {
+		outerBBox := par.GetOuterBBox()
+		return pkg.CircleIntersectsRect(center, radius, &outerBBox)
+	}, tool.onParagraphRemoved)
+}
+
+// Erased paragraph can't be edited anymore, so it stops being active and its cursor and selection are gone
+func (tool *TextTool) onParagraphRemoved(par *pkg.TextParagraph) {
+	if par != tool.activeParagraph {
+		return
+	}
+	tool.activeParagraph = nil
+	tool.cursorPos = 0
+	tool.setComposition("", 0, 0)
+	tool.selection.selected = false
+	tool.isShiftSelecting = false
+	tool.isMouseSelecting = false
+}
+
+func (tool TextTool) IsTyping() bool {
+	return tool.activeParagraph != nil
+}
+
 func (tool *TextTool) OnToolDeactivated() {
 	tool.activeParagraph = nil
 	tool.draggingHandle.draggingParagraph = nil
+	tool.draggingTail = nil
+	tool.resizingWrap = nil
+	tool.setComposition("", 0, 0)
 	tool.selection.selected = false
 	tool.isShiftSelecting = false
 	tool.isMouseSelecting = false
 }
 
+func (tool *TextTool) setTextStyle(style pkg.TextStyle) {
+	font, err := tool.fontCache.Get(style.Family, style.Size, style.FontStyle())
+	if err != nil {
+		//Settings go back to the style that is still used
+		tool.showTextStyle(tool.textStyle)
+		pkg.ShowErrorMessage("Can't load font %s: %v", style.Family.Name, err)
+		return
+	}
+	tool.textStyle = style
+	tool.textFont = font
+	if tool.activeParagraph != nil {
+		tool.activeParagraph.SetFont(tool.ren, font, style)
+		//Composition texture is rendered again with the new font
+		composition := tool.composition
+		tool.setComposition(string(composition.text), composition.cursor, composition.selectionLength)
+	}
+}
+
+// Activates the paragraph and shows its style in the settings, so the paragraph can be restyled
+func (tool *TextTool) activateParagraph(par *pkg.TextParagraph) {
+	if par != tool.activeParagraph {
+		tool.setComposition("", 0, 0)
+	}
+	tool.activeParagraph = par
+	if par.Font != tool.textFont {
+		tool.textStyle = par.Style
+		tool.textFont = par.Font
+		tool.showTextStyle(par.Style)
+	}
+	tool.textLayout = par.Layout
+	tool.textLayout.WrapWidth = 0
+	tool.alignmentOption.SetOption(optionIndex(textAlignments, par.Layout.Alignment))
+	tool.spacingSlider.SetValue(uint(math.Round(par.Layout.LineSpacing * 100)))
+	tool.textDecoration = par.Decoration
+	tool.backgroundOption.SetOption(optionIndex(textBackgrounds, par.Decoration.Background))
+	tool.paddingSlider.SetValue(uint(par.Decoration.BackgroundPadding))
+	tool.radiusSlider.SetValue(uint(par.Decoration.CornerRadius))
+	tool.effectOption.SetOption(optionIndex(textEffects, par.Decoration.Effect))
+	//Picker color and opacity are restored separately, the paragraph only keeps them combined
+	if color, ok := tool.paragraphColors[par]; ok {
+		tool.textColor, tool.textOpacity = color.color, color.opacity
+		tool.opacitySlider.SetValue(uint(math.Round(float64(color.opacity) * float64(maxOpacityPercent) / 255)))
+	}
+	tool.colorPicker.SetColor(tool.targetColor())
+}
+
+func (tool *TextTool) setParagraphColor(par *pkg.TextParagraph) {
+	par.SetColor(tool.ren, withOpacity(tool.textColor, tool.textOpacity))
+	tool.paragraphColors[par] = paragraphColor{color: tool.textColor, opacity: tool.textOpacity}
+}
+
+func (tool *TextTool) showTextStyle(style pkg.TextStyle) {
+	tool.familyDropdown.SetOption(tool.familyIndex(style.Family))
+	tool.sizeSlider.SetValue(uint(style.Size))
+	tool.styleToggles.SetToggled(textStyleBold, style.Bold)
+	tool.styleToggles.SetToggled(textStyleItalic, style.Italic)
+	tool.styleToggles.SetToggled(textStyleUnderline, style.Underline)
+}
+
+func (tool *TextTool) setTextLayout(layout pkg.TextLayout) {
+	tool.textLayout = layout
+	if tool.activeParagraph != nil {
+		//Wrap width belongs to the paragraph and is only changed by dragging its edge
+		layout.WrapWidth = tool.activeParagraph.Layout.WrapWidth
+		tool.activeParagraph.SetLayout(tool.ren, layout)
+	}
+}
+
+func (tool *TextTool) setTextDecoration(decoration pkg.TextDecoration) {
+	tool.textDecoration = decoration
+	if tool.activeParagraph != nil {
+		tool.applyTextDecoration(tool.activeParagraph, decoration)
+	}
+}
+
+// Tail target belongs to the paragraph, a new callout gets its tail pointing below the text
+func (tool *TextTool) applyTextDecoration(par *pkg.TextParagraph, decoration pkg.TextDecoration) {
+	decoration.TailTarget = par.Decoration.TailTarget
+	if decoration.Background == pkg.TextBackgroundCallout && par.Decoration.Background != pkg.TextBackgroundCallout {
+		background := par.GetBackgroundRect()
+		decoration.TailTarget = sdl.Point{
+			X: background.X + background.W/4,
+			Y: background.Y + background.H + calloutDefaultTailLength,
+		}
+	}
+	par.SetDecoration(tool.ren, decoration)
+}
+
+func (tool TextTool) targetColor() sdl.Color {
+	switch tool.colorTarget {
+	case textColorTargetBackground:
+		return tool.textDecoration.BackgroundColor
+	case textColorTargetEffect:
+		return tool.textDecoration.EffectColor
+	default:
+		return tool.textColor
+	}
+}
+
+// Unknown values fall back to the first option
+func optionIndex(options []string, option string) int {
+	for i, known := range options {
+		if known == option {
+			return i
+		}
+	}
+	return 0
+}
+
+func (tool TextTool) wrapEdgeAt(point sdl.Point) *pkg.TextParagraph {
+	for i := len(tool.paragraphs) - 1; i >= 0; i-- {
+		bbox := tool.paragraphs[i].GetBBox()
+		edgeX := bbox.X + bbox.W
+		//Only the dragging strip right of the paragraph is taken, clicks inside still place the cursor
+		if point.Y >= bbox.Y && point.Y < bbox.Y+bbox.H && point.X >= edgeX && point.X <= edgeX+paragraphDraggingPadding {
+			return tool.paragraphs[i]
+		}
+	}
+	return nil
+}
+
+func (tool TextTool) tailHandleAt(point sdl.Point) *pkg.TextParagraph {
+	for i := len(tool.paragraphs) - 1; i >= 0; i-- {
+		par := tool.paragraphs[i]
+		if par.Decoration.Background != pkg.TextBackgroundCallout {
+			continue
+		}
+		target := par.Decoration.TailTarget
+		dx, dy := point.X-target.X, point.Y-target.Y
+		if dx*dx+dy*dy <= calloutHandleRadius*calloutHandleRadius {
+			return par
+		}
+	}
+	return nil
+}
+
+func (tool TextTool) familyIndex(family pkg.FontFamily) int {
+	for i, knownFamily := range tool.fontFamilies {
+		if knownFamily.Name == family.Name && knownFamily.Path == family.Path {
+			return i
+		}
+	}
+	return 0
+}
+
+func (tool TextTool) familyNames() []string {
+	names := make([]string, len(tool.fontFamilies))
+	for i, family := range tool.fontFamilies {
+		names[i] = family.Name
+	}
+	return names
+}
+
+func (tool *TextTool) updateFontFamilies() {
+	select {
+	case families := <-tool.familiesLoaded:
+		tool.fontFamilies = append(tool.fontFamilies[:1], families...)
+		tool.familyDropdown.SetOptions(tool.familyNames(), tool.familyIndex(tool.textStyle.Family))
+	default:
+	}
+}
+
 func (tool *TextTool) moveCursor(newPos int) {
 	newPos = pkg.Clamp(0, newPos, len(tool.activeParagraph.Text))
 	if tool.isShiftSelecting || tool.isMouseSelecting {
@@ -364,21 +793,22 @@ func (tool *TextTool) popFromParagraph(par *pkg.TextParagraph, popFrom int, popT
 
 func (tool TextTool) RenderScreenshot(ren *sdl.Renderer) {
 	for _, par := range tool.paragraphs {
-		if par.StringTexture != nil {
-			par.StringTexture.Draw(ren, &par.TextStart)
-		}
+		par.Draw(ren)
 	}
 }
 
-func (tool TextTool) RenderCurrentState(ren *sdl.Renderer) {
+func (tool *TextTool) RenderCurrentState(ren *sdl.Renderer) {
+	tool.updateFontFamilies()
 	for _, par := range tool.paragraphs {
+		par.Draw(ren)
 		pkg.DrawRectangle(
 			ren,
 			par.GetBBox(),
 			paragraphBoundariesColor,
 		)
-		if par.StringTexture != nil {
-			par.StringTexture.Draw(ren, &par.TextStart)
+		if par.Decoration.Background == pkg.TextBackgroundCallout {
+			pkg.DrawFilledCircle(ren, &par.Decoration.TailTarget, calloutHandleRadius, calloutHandleColor)
+			pkg.DrawCircle(ren, &par.Decoration.TailTarget, calloutHandleRadius, calloutHandleOutlineColor)
 		}
 		if par == tool.activeParagraph {
 			tool.renderCursor(ren)
@@ -387,6 +817,79 @@ func (tool TextTool) RenderCurrentState(ren *sdl.Renderer) {
 			}
 		}
 	}
+	if tool.activeParagraph != nil && len(tool.composition.text) > 0 {
+		tool.renderComposition(ren)
+	}
+}
+
+// Input method text that isn't committed yet is shown in a box at the cursor
+func (tool *TextTool) renderComposition(ren *sdl.Renderer) {
+	par := tool.activeParagraph
+	composition := &tool.composition
+	if composition.texture == nil {
+		composition.texture = pkg.NewStringTexture(ren, par.Font, string(composition.text), compositionTextColor)
+	}
+	box := tool.compositionRect()
+	pkg.DrawFilledRectangle(ren, &box, compositionBackgroundColor)
+	textStart := sdl.Point{X: box.X + compositionPadding, Y: box.Y + compositionPadding}
+	if composition.selectionLength > 0 {
+		selStart := pkg.Clamp(0, composition.cursor, len(composition.text))
+		selEnd := pkg.Clamp(selStart, composition.cursor+composition.selectionLength, len(composition.text))
+		selOffset, _ := pkg.SizeString(par.Font, string(composition.text[:selStart]))
+		selW, _ := pkg.SizeString(par.Font, string(composition.text[selStart:selEnd]))
+		pkg.DrawFilledRectangle(
+			ren,
+			&sdl.Rect{X: textStart.X + int32(selOffset), Y: textStart.Y, W: int32(selW), H: composition.texture.TextHeight},
+			compositionSelectionColor,
+		)
+	}
+	composition.texture.Draw(ren, &textStart)
+	underlineY := textStart.Y + composition.texture.TextHeight
+	pkg.DrawThickLine(
+		ren,
+		&sdl.Point{X: textStart.X, Y: underlineY},
+		&sdl.Point{X: textStart.X + composition.texture.TextWidth, Y: underlineY},
+		1,
+		compositionTextColor,
+	)
+	cursorOffset, _ := pkg.SizeString(par.Font, string(composition.text[:pkg.Clamp(0, composition.cursor, len(composition.text))]))
+	pkg.DrawThickLine(
+		ren,
+		&sdl.Point{X: textStart.X + int32(cursorOffset), Y: textStart.Y},
+		&sdl.Point{X: textStart.X + int32(cursorOffset), Y: underlineY},
+		1,
+		compositionTextColor,
+	)
+}
+
+// Input method candidate window is placed right under the cursor
+func (tool TextTool) TextInputRect() sdl.Rect {
+	return tool.compositionRect()
+}
+
+func (tool TextTool) compositionRect() sdl.Rect {
+	par := tool.activeParagraph
+	xOffset, yOffset := par.GetOffsetByPosition(tool.cursorPos)
+	rect := sdl.Rect{
+		X: par.TextStart.X + xOffset - compositionPadding, Y: par.TextStart.Y + yOffset - compositionPadding,
+		W: compositionPadding * 2, H: int32(par.Font.Height()) + compositionPadding*2,
+	}
+	if tool.composition.texture != nil {
+		rect.W += tool.composition.texture.TextWidth
+		rect.H = pkg.Max(rect.H, tool.composition.texture.TextHeight+compositionPadding*2+1)
+	}
+	return rect
+}
+
+func (tool *TextTool) setComposition(text string, cursor, selectionLength int) {
+	if tool.composition.texture != nil {
+		tool.composition.texture.Destroy()
+	}
+	tool.composition = textComposition{
+		text:            []rune(text),
+		cursor:          cursor,
+		selectionLength: selectionLength,
+	}
 }
 
 func (tool TextTool) renderCursor(ren *sdl.Renderer) {
@@ -403,40 +906,31 @@ func (tool TextTool) renderCursor(ren *sdl.Renderer) {
 }
 
 func (tool TextTool) renderSelection(ren *sdl.Renderer) {
-	par := tool.activeParagraph
-	selH := par.Font.LineSkip()
 	selStart, selEnd := tool.selection.selectionBounds()
-	lines := par.GetLinesBoundaries()
-	for i, line := range lines {
-		y := int32(i * selH)
-		lineSelStart := pkg.Max(selStart, line[0])
-		lineSelEnd := pkg.Min(selEnd, line[1]+1)
-		if lineSelStart > line[1] || lineSelEnd < line[0] {
-			continue
-		}
-		selOffset := 0
-		if lineSelStart > 0 {
-			selOffset, _ = pkg.SizeString(par.Font, string(par.Text[line[0]:lineSelStart]))
-		}
-		selW, _ := pkg.SizeString(par.Font, string(par.Text[lineSelStart:lineSelEnd]))
-		pkg.DrawFilledRectangle(
-			ren,
-			&sdl.Rect{
-				X: par.TextStart.X + int32(selOffset),
-				Y: par.TextStart.Y + y,
-				W: int32(selW), H: int32(selH),
-			},
-			selectionColor,
-		)
+	for _, rect := range tool.activeParagraph.GetSelectionRects(selStart, selEnd) {
+		pkg.DrawFilledRectangle(ren, &rect, selectionColor)
 	}
 }
 
+// Text color chosen in the picker and the opacity it's drawn with
+type paragraphColor struct {
+	color   sdl.Color
+	opacity uint8
+}
+
 type textDraggingHandle struct {
 	draggingParagraph *pkg.TextParagraph
 	xHandleOffset     int32
 	yHandleOffset     int32
 }
 
+type textComposition struct {
+	text            []rune
+	cursor          int
+	selectionLength int
+	texture         *pkg.StringTexture
+}
+
 type textSelection struct {
 	start    int
 	length   int
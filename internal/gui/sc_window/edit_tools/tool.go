@@ -21,6 +21,41 @@ type ScreenshotEditTool interface {
 type ScreenshotCropTool interface {
 	ScreenshotEditTool
 	CropScreenshot(surface *sdl.Surface) *sdl.Surface
+	CropRect() *sdl.Rect
+}
+
+type ScreenshotColoredTool interface {
+	ScreenshotEditTool
+	SetToolColor(color sdl.Color)
+}
+
+type ScreenshotTypingTool interface {
+	ScreenshotEditTool
+	//Reports whether key presses are currently typed as text
+	IsTyping() bool
+	//Returns the canvas area the text is typed at, input method candidates are shown next to it
+	TextInputRect() sdl.Rect
+}
+
+// Controls keep their size whatever the zoom is, so they are drawn over the zoomed canvas in window coordinates
+type ScreenshotControlsTool interface {
+	ScreenshotEditTool
+	//toWindow converts canvas coordinates into window ones
+	RenderControls(ren *sdl.Renderer, toWindow func(x, y int32) (int32, int32))
+	//Callbacks get window coordinates and come before the tool callbacks
+	ControlsCallbacks() *gui.WindowCallbackSet
+}
+
+type ScreenshotErasableTool interface {
+	ScreenshotEditTool
+	//Removes every annotation touched by the circle, returns nil if nothing was removed
+	EraseAnnotations(center sdl.Point, radius int32) ToolAction
+}
+
+type ScreenshotCuttableTool interface {
+	ScreenshotErasableTool
+	//Cuts the part of annotations under the circle, returns nil if nothing was cut
+	CutAnnotations(center sdl.Point, radius int32) ToolAction
 }
 
 type DefaultScreenshotEditTool struct {
@@ -0,0 +1,97 @@
+package scWindow
+
+import (
+	"time"
+
+	"github.com/Wine1y/trigat/assets"
+	"github.com/Wine1y/trigat/config"
+	"github.com/Wine1y/trigat/internal/gui"
+	"github.com/Wine1y/trigat/pkg"
+	"github.com/veandco/go-sdl2/sdl"
+	"github.com/veandco/go-sdl2/ttf"
+)
+
+const progressFontSize int = 14
+const progressPadding int32 = 8
+const progressMargin int32 = 16
+const progressCornerRadius int32 = 6
+const progressBarHeight int32 = 3
+const progressBarAnimationDuration time.Duration = time.Millisecond * 1200
+const noticeDuration time.Duration = time.Millisecond * 2500
+
+var progressBackgroundColor = sdl.Color{R: 0, G: 0, B: 0, A: 200}
+var progressTextColor = sdl.Color{R: 255, G: 255, B: 255, A: 255}
+var progressBarColor = sdl.Color{R: 0, G: 200, B: 255, A: 255}
+
+// progressBadge is shown at the top of the window while a background task is running,
+// notices are badges without the bar reporting a result, they disappear by themselves
+type progressBadge struct {
+	font          *ttf.Font
+	titleTexture  *pkg.StringTexture
+	barAnimation  *pkg.Animation
+	hideAnimation *pkg.Animation
+}
+
+func newProgressBadge(ren *sdl.Renderer, title string) *progressBadge {
+	font := assets.GetAppFont(progressFontSize)
+	return &progressBadge{
+		font:         font,
+		titleTexture: pkg.NewStringTexture(ren, font, title, progressTextColor),
+		barAnimation: pkg.NewLinearAnimation(
+			0, 100,
+			int(config.GetAppFPS()), progressBarAnimationDuration,
+			0, true,
+		),
+	}
+}
+
+func newNoticeBadge(ren *sdl.Renderer, title string) *progressBadge {
+	badge := newProgressBadge(ren, title)
+	badge.barAnimation = nil
+	badge.hideAnimation = pkg.NewLinearAnimation(0, 100, int(config.GetAppFPS()), noticeDuration, 1, false)
+	return badge
+}
+
+func (badge *progressBadge) isHidden() bool {
+	return badge.hideAnimation != nil && badge.hideAnimation.IsEnded()
+}
+
+func (badge *progressBadge) draw(ren *sdl.Renderer) {
+	vp := ren.GetViewport()
+	bbox := sdl.Rect{
+		W: badge.titleTexture.TextWidth + progressPadding*2,
+		H: badge.titleTexture.TextHeight + progressPadding*2,
+	}
+	if badge.barAnimation != nil {
+		bbox.H += progressPadding/2 + progressBarHeight
+	}
+	bbox.X, bbox.Y = (vp.W-bbox.W)/2, progressMargin
+	pkg.DrawRoundedFilledRectangle(ren, &bbox, progressCornerRadius, progressBackgroundColor)
+	badge.titleTexture.Draw(ren, &sdl.Point{X: bbox.X + progressPadding, Y: bbox.Y + progressPadding})
+	if badge.hideAnimation != nil {
+		badge.hideAnimation.CurrentValue()
+		//Ended animations don't ask for frames, but the next one is needed to take the notice away
+		if badge.hideAnimation.IsEnded() {
+			gui.RequestRedraw()
+		}
+		return
+	}
+
+	//Duration of the task is unknown, so the bar just runs along the badge
+	track := sdl.Rect{
+		X: bbox.X + progressPadding, Y: bbox.Y + bbox.H - progressPadding - progressBarHeight,
+		W: bbox.W - progressPadding*2, H: progressBarHeight,
+	}
+	barW := track.W / 3
+	barX := track.X - barW + (track.W+barW)*int32(badge.barAnimation.CurrentValue())/100
+	bar := sdl.Rect{X: pkg.Max(barX, track.X), Y: track.Y, H: track.H}
+	bar.W = pkg.Min(barX+barW, track.X+track.W) - bar.X
+	if bar.W > 0 {
+		pkg.DrawFilledRectangle(ren, &bar, progressBarColor)
+	}
+}
+
+func (badge *progressBadge) destroy() {
+	badge.titleTexture.Destroy()
+	pkg.CloseFont(badge.font)
+}
@@ -9,9 +9,12 @@ import (
 	"time"
 	"unsafe"
 
+	"github.com/Wine1y/trigat/assets"
 	"github.com/Wine1y/trigat/config"
 	"github.com/Wine1y/trigat/internal/gui"
 	editTools "github.com/Wine1y/trigat/internal/gui/sc_window/edit_tools"
+	"github.com/Wine1y/trigat/internal/recognition"
+	"github.com/Wine1y/trigat/internal/sharing"
 	"github.com/Wine1y/trigat/pkg"
 	"github.com/Wine1y/trigat/pkg/hotkeys"
 	"github.com/kbinani/screenshot"
@@ -21,6 +24,11 @@ import (
 
 const windowFlags uint32 = sdl.WINDOW_SKIP_TASKBAR | sdl.WINDOW_BORDERLESS | sdl.WINDOW_HIDDEN
 
+const screenshotDisplayIndex int = 0
+const searchingSensitiveTextTitle string = "Looking for sensitive text..."
+const noCodesFoundTitle string = "No QR codes or barcodes found"
+const noSensitiveTextFoundTitle string = "No sensitive text found"
+
 var dimColor = sdl.Color{R: 0, G: 0, B: 0}
 var dimAlpha uint8 = 100
 var initAnimationDuration time.Duration = time.Millisecond * 750
@@ -28,15 +36,56 @@ var dimAnimationDuration time.Duration = time.Millisecond * 650
 
 type ScreenshotWindow struct {
 	screenshotTexture *sdl.Texture
+	canvasTexture     *sdl.Texture
+	view              *viewTransform
+	textInputRect     sdl.Rect
+	capturedAt        time.Time
 	toolsPanel        *ToolsPanel
 	initAnimation     *pkg.Animation
 	dimAnimation      *pkg.Animation
 	undimAnimation    *pkg.Animation
 	dimmed            bool
+	searchChooser     *searchEngineChooser
+	textOverlay       *textCorrectionOverlay
+	ocrResults        chan ocrOutcome
+	barcodeOverlay    *barcodeOverlay
+	barcodeResults    chan barcodeOutcome
+	redactionResults  chan redactionOutcome
+	redactionProgress *progressBadge
+	notice            *progressBadge
+	callbacks         *gui.WindowCallbackSet
+	callbacksState    callbacksState
 	*gui.SDLWindow
 }
 
+// Everything the window callbacks are built from, the set is only rebuilt when some of it changes
+type callbacksState struct {
+	tool           editTools.ScreenshotEditTool
+	settingsTool   editTools.ScreenshotEditTool
+	searchChooser  *searchEngineChooser
+	textOverlay    *textCorrectionOverlay
+	barcodeOverlay *barcodeOverlay
+}
+
+type ocrOutcome struct {
+	result *pkg.OCRResult
+	err    error
+}
+
+type barcodeOutcome struct {
+	barcodes []pkg.DecodedBarcode
+	offset   sdl.Point
+	err      error
+}
+
+type redactionOutcome struct {
+	matches []pkg.SensitiveTextMatch
+	offset  sdl.Point
+	err     error
+}
+
 func NewScreenshotWindow() *ScreenshotWindow {
+	capturedAt := time.Now()
 	screenImage, err := takeScreenshot()
 	if err != nil {
 		panic(err)
@@ -47,7 +96,8 @@ func NewScreenshotWindow() *ScreenshotWindow {
 	}
 	defer screenshotSurface.Free()
 	window := ScreenshotWindow{
-		dimmed: true,
+		capturedAt: capturedAt,
+		dimmed:     true,
 		initAnimation: pkg.NewLinearAnimation(
 			0, 100,
 			int(config.GetAppFPS()), initAnimationDuration,
@@ -75,14 +125,31 @@ func NewScreenshotWindow() *ScreenshotWindow {
 		window.callbackSet,
 	)
 	window.SDLWindow = sdlWindow
+	//Renderer scales only the layout, fonts and icons of the UI are rasterized for the display
+	assets.SetUIScale(window.UIScale())
 	window.screenshotTexture = pkg.CreateTextureFromSurface(window.Renderer(), screenshotSurface)
+	//Screenshot is taken in physical pixels, the canvas keeps them whatever the window size and display scale are
+	window.canvasTexture, err = window.Renderer().CreateTexture(
+		uint32(sdl.PIXELFORMAT_RGBA32), sdl.TEXTUREACCESS_TARGET,
+		screenshotSurface.W, screenshotSurface.H,
+	)
+	if err != nil {
+		panic(err)
+	}
+	vp := window.Renderer().GetViewport()
+	window.view = newViewTransform(screenshotSurface.W, screenshotSurface.H, vp.W, vp.H, window.UIScale())
 	window.SDLWin().SetWindowOpacity(0)
 	window.SDLWin().Show()
+	//Tools live on the canvas, so they are created with it as the render target
+	window.setRenderTarget(window.canvasTexture)
 	window.toolsPanel = NewToolsPanel(
 		window.Renderer(),
+		screenImage,
+		window.view,
 		window.onNewToolSelected,
-		window.saveImage, window.copyImage, window.searchImage,
+		window.saveImage, window.copyImage, window.searchImage, window.uploadImage, window.extractText, window.decodeCodes,
 	)
+	window.setRenderTarget(nil)
 	window.render(window.Renderer())
 	window.Renderer().Present()
 	window.SDLWin().Raise()
@@ -93,16 +160,55 @@ func (window *ScreenshotWindow) render(ren *sdl.Renderer) {
 	if !window.initAnimation.IsEnded() {
 		window.SDLWin().SetWindowOpacity(float32(window.initAnimation.CurrentValue()) / 100)
 	}
+	select {
+	case outcome := <-window.ocrResults:
+		window.onTextRecognized(outcome)
+	case outcome := <-window.barcodeResults:
+		window.onCodesDecoded(outcome)
+	case outcome := <-window.redactionResults:
+		window.onSensitiveTextFound(outcome)
+	default:
+	}
+	//Everything placed on the screenshot is drawn into the canvas, which is then zoomed and panned as a whole
+	window.setRenderTarget(window.canvasTexture)
 	window.drawScreenshotBackground(ren)
 	window.toolsPanel.DrawToolsState(ren)
+	if window.barcodeOverlay != nil {
+		window.barcodeOverlay.draw(ren)
+	}
+	window.setRenderTarget(nil)
+	window.view.draw(ren, window.canvasTexture)
+	window.toolsPanel.DrawToolsControls(ren)
+	window.updateTextInputRect()
 	window.toolsPanel.DrawPanel(ren)
+	if window.searchChooser != nil {
+		window.searchChooser.draw(ren)
+	}
+	if window.textOverlay != nil {
+		//Selection moves in the window whenever the view is zoomed or panned
+		window.textOverlay.setAnchor(window.view.toWindowRect(window.toolsPanel.CropRect(ren)))
+		window.textOverlay.draw(ren)
+	}
+	if window.redactionProgress != nil {
+		window.redactionProgress.draw(ren)
+	}
+	if window.notice != nil && window.notice.isHidden() {
+		window.notice.destroy()
+		window.notice = nil
+	}
+	if window.notice != nil {
+		window.notice.draw(ren)
+	}
 }
 
 func (window ScreenshotWindow) renderScreenshot() (*[]byte, *sdl.Surface) {
 	ren := window.Renderer()
+	//The canvas has the size of the screenshot, so the image is exported in physical pixels whatever the zoom is
+	window.setRenderTarget(window.canvasTexture)
 	pkg.CopyTexture(ren, window.screenshotTexture, nil, nil)
 	window.toolsPanel.RenderScreenshot(ren)
-	pixels, surface := readRenderIntoSurface(ren)
+	pixels, surface := readTextureIntoSurface(ren, window.canvasTexture)
+	window.setRenderTarget(nil)
 	croppedSurface := window.toolsPanel.CropScreenshot(surface)
 	if croppedSurface != surface {
 		surface.Free()
@@ -110,11 +216,55 @@ func (window ScreenshotWindow) renderScreenshot() (*[]byte, *sdl.Surface) {
 	return pixels, croppedSurface
 }
 
+func (window ScreenshotWindow) screenshotMetadata() *pkg.ImageMetadata {
+	metadataConfig := config.GetMetadataConfig()
+	if !metadataConfig.Enabled {
+		return nil
+	}
+	return &pkg.ImageMetadata{
+		CaptureTime:  window.capturedAt,
+		DisplayIndex: screenshotDisplayIndex,
+		Selection:    *window.toolsPanel.CropRect(window.Renderer()),
+		AppVersion:   config.GetAppVersion(),
+		Comment:      metadataConfig.Comment,
+	}
+}
+
 func (window *ScreenshotWindow) callbackSet() *gui.WindowCallbackSet {
+	state := callbacksState{
+		tool:           window.toolsPanel.CurrentTool(),
+		settingsTool:   window.toolsPanel.ShownSettingsTool(),
+		searchChooser:  window.searchChooser,
+		textOverlay:    window.textOverlay,
+		barcodeOverlay: window.barcodeOverlay,
+	}
+	if window.callbacks == nil || state != window.callbacksState {
+		window.callbacks = window.buildCallbackSet()
+		window.callbacksState = state
+	}
+	return window.callbacks
+}
+
+func (window *ScreenshotWindow) buildCallbackSet() *gui.WindowCallbackSet {
 	set := gui.NewWindowCallbackSet()
+	set.Append(window.view.callbacks(window.isTyping))
+	if window.searchChooser != nil {
+		set.Append(window.searchChooser.callbacks())
+	}
+	if window.textOverlay != nil {
+		set.Append(window.textOverlay.callbacks())
+	}
+	if window.barcodeOverlay != nil {
+		set.Append(window.barcodeOverlay.callbacks().MapMouse(window.view.toCanvas))
+	}
 	window.toolsPanel.SetToolsCallbacks(set)
+	set.SizeChange = append(set.SizeChange, func(w, h int32) bool {
+		window.view.resize(w, h, window.UIScale())
+		return false
+	})
 	set.Quit = append(set.Quit, func() bool {
 		window.screenshotTexture.Destroy()
+		window.canvasTexture.Destroy()
 		return false
 	})
 	set.KeyDown = append(set.KeyDown, func(keysym sdl.Keysym) bool {
@@ -130,17 +280,61 @@ func (window *ScreenshotWindow) callbackSet() *gui.WindowCallbackSet {
 			window.searchImage()
 			return true
 		}
+		if keysym.Sym == sdl.K_u && (keysym.Mod&sdl.KMOD_CTRL) != 0 {
+			window.uploadImage()
+			return true
+		}
+		if keysym.Sym == sdl.K_e && (keysym.Mod&sdl.KMOD_CTRL) != 0 {
+			window.extractText()
+			return true
+		}
+		if keysym.Sym == sdl.K_d && (keysym.Mod&sdl.KMOD_CTRL) != 0 {
+			window.decodeCodes()
+			return true
+		}
+		if keysym.Sym == sdl.K_r && (keysym.Mod&sdl.KMOD_CTRL) != 0 {
+			window.autoRedact()
+			return true
+		}
+		if keysym.Sym == sdl.K_ESCAPE {
+			window.Close()
+			return true
+		}
 		return false
 	})
 	return set
 }
 
 func (window *ScreenshotWindow) HotKeys() *hotkeys.HotKeySet {
-	exitCb := func() { window.Close() }
+	//Overlays are closed first, the window is closed by the Escape nobody else handled
+	exitCb := func() { gui.PushKeyDown(sdl.K_ESCAPE) }
 	exitHk := hotkeys.NewHotKey(hotkeys.KeyEscape, nil, &exitCb, nil)
 	return hotkeys.NewHotKeySet(exitHk)
 }
 
+func (window ScreenshotWindow) isTyping() bool {
+	if window.textOverlay != nil {
+		return true
+	}
+	typingTool, isTyping := window.toolsPanel.CurrentTool().(editTools.ScreenshotTypingTool)
+	return isTyping && typingTool.IsTyping()
+}
+
+// Input method candidate window is placed next to the typed text, in the system window coordinates
+func (window *ScreenshotWindow) updateTextInputRect() {
+	typingTool, isTypingTool := window.toolsPanel.CurrentTool().(editTools.ScreenshotTypingTool)
+	if !isTypingTool || !typingTool.IsTyping() {
+		return
+	}
+	canvasRect := typingTool.TextInputRect()
+	rect := window.FromUI(window.view.toWindowRect(&canvasRect))
+	if rect == window.textInputRect {
+		return
+	}
+	window.textInputRect = rect
+	sdl.SetTextInputRect(&rect)
+}
+
 func (window *ScreenshotWindow) onNewToolSelected(tool editTools.ScreenshotEditTool) {
 	if tool.RequiresScreenDim() {
 		window.dimBackground()
@@ -165,6 +359,7 @@ func (window *ScreenshotWindow) undimBackground() {
 
 func (window *ScreenshotWindow) saveImage() {
 	pixels, surface := window.renderScreenshot()
+	metadata := window.screenshotMetadata()
 	savingOptions, success := pkg.RequestSavingOptions("Saving screenshot", "screenshot")
 
 	if !success {
@@ -177,7 +372,7 @@ func (window *ScreenshotWindow) saveImage() {
 		if err != nil {
 			panic(err)
 		}
-		savingOptions.Method.WritingFunction(surface, file)
+		savingOptions.Method.WritingFunction(surface, file, metadata)
 		surface.Free()
 		file.Close()
 		runtime.KeepAlive(pixels)
@@ -189,7 +384,7 @@ func (window *ScreenshotWindow) copyImage() {
 	window.Close()
 	go func() {
 		buf := bytes.NewBuffer(make([]byte, 0, surface.W*surface.H))
-		pkg.WriteSurfaceToPNG(surface, buf)
+		pkg.WriteSurfaceToPNG(surface, buf, nil)
 		clipboard.Write(clipboard.FmtImage, buf.Bytes())
 		surface.Free()
 		runtime.KeepAlive(pixels)
@@ -197,21 +392,199 @@ func (window *ScreenshotWindow) copyImage() {
 }
 
 func (window *ScreenshotWindow) searchImage() {
+	if window.searchChooser != nil {
+		return
+	}
+	engine, shouldAsk := sharing.ConfiguredSearchEngine()
+	if !shouldAsk {
+		window.searchImageWith(*engine)
+		return
+	}
+	window.searchChooser = newSearchEngineChooser(
+		window.Renderer(),
+		sharing.SearchEngines(),
+		func(engine pkg.SearchEngine) {
+			window.closeSearchChooser()
+			window.searchImageWith(engine)
+		},
+		window.closeSearchChooser,
+	)
+}
+
+func (window *ScreenshotWindow) closeSearchChooser() {
+	window.searchChooser.destroy()
+	window.searchChooser = nil
+}
+
+func (window *ScreenshotWindow) searchImageWith(engine pkg.SearchEngine) {
 	pixels, surface := window.renderScreenshot()
 	window.Close()
 	go func() {
-		buf := bytes.NewBuffer(make([]byte, 0, surface.W*surface.H))
-		pkg.WriteSurfaceToPNG(surface, buf)
-		imageUrl, err := pkg.UploadImage(buf)
+		searchURL, err := sharing.SearchImage(surface, engine)
+		surface.Free()
+		runtime.KeepAlive(pixels)
 		if err != nil {
-			panic(err)
+			pkg.ShowErrorMessage("Can't search image: %v", err)
+			return
+		}
+		pkg.OpenUrlInBrowser(searchURL)
+	}()
+}
+
+func (window *ScreenshotWindow) uploadImage() {
+	pixels, surface := window.renderScreenshot()
+	window.Close()
+	go func() {
+		result, err := sharing.UploadImage(surface)
+		surface.Free()
+		runtime.KeepAlive(pixels)
+		if err != nil {
+			pkg.ShowErrorMessage("Can't upload image: %v", err)
+			return
 		}
-		pkg.OpenUrlInBrowser(pkg.GetSearchURL(imageUrl))
+		clipboard.Write(clipboard.FmtText, []byte(result.URL))
+		pkg.ShowInfoMessage("Image uploaded, link copied to the clipboard:\n%v", result.URL)
+	}()
+}
+
+func (window *ScreenshotWindow) extractText() {
+	if window.textOverlay != nil {
+		return
+	}
+	pixels, surface := window.renderScreenshot()
+	results := make(chan ocrOutcome, 1)
+	window.ocrResults = results
+	anchor := window.view.toWindowRect(window.toolsPanel.CropRect(window.Renderer()))
+	window.textOverlay = newTextCorrectionOverlay(
+		window.Renderer(),
+		&anchor,
+		func(text string) {
+			clipboard.Write(clipboard.FmtText, []byte(text))
+		},
+		window.closeTextOverlay,
+	)
+	go func() {
+		result, err := recognition.RecognizeText(surface)
+		surface.Free()
+		runtime.KeepAlive(pixels)
+		results <- ocrOutcome{result: result, err: err}
+		gui.RequestRedraw()
+	}()
+}
+
+func (window *ScreenshotWindow) onTextRecognized(outcome ocrOutcome) {
+	//Text recognized after the overlay was closed isn't needed anymore and mustn't replace the clipboard
+	if window.textOverlay == nil {
+		return
+	}
+	if outcome.err != nil {
+		window.closeTextOverlay()
+		pkg.ShowErrorMessage("Can't extract text: %v", outcome.err)
+		return
+	}
+	if outcome.result.Text != "" {
+		clipboard.Write(clipboard.FmtText, []byte(outcome.result.Text))
+	}
+	window.textOverlay.setText(outcome.result.Text)
+}
+
+func (window *ScreenshotWindow) closeTextOverlay() {
+	window.textOverlay.destroy()
+	window.textOverlay = nil
+	window.ocrResults = nil
+}
+
+func (window *ScreenshotWindow) decodeCodes() {
+	if window.barcodeOverlay != nil || window.barcodeResults != nil {
+		return
+	}
+	pixels, surface := window.renderScreenshot()
+	cropRect := window.toolsPanel.CropRect(window.Renderer())
+	results := make(chan barcodeOutcome, 1)
+	window.barcodeResults = results
+	go func() {
+		barcodes, err := pkg.DecodeBarcodes(surface)
+		surface.Free()
+		runtime.KeepAlive(pixels)
+		results <- barcodeOutcome{barcodes: barcodes, offset: sdl.Point{X: cropRect.X, Y: cropRect.Y}, err: err}
+		gui.RequestRedraw()
+	}()
+}
+
+func (window *ScreenshotWindow) onCodesDecoded(outcome barcodeOutcome) {
+	window.barcodeResults = nil
+	if outcome.err != nil {
+		pkg.ShowErrorMessage("Can't decode codes: %v", outcome.err)
+		return
+	}
+	if len(outcome.barcodes) == 0 {
+		window.showNotice(noCodesFoundTitle)
+		return
+	}
+	window.barcodeOverlay = newBarcodeOverlay(
+		window.Renderer(),
+		outcome.barcodes,
+		outcome.offset,
+		&sdl.Rect{W: window.view.canvasW, H: window.view.canvasH},
+		window.closeBarcodeOverlay,
+	)
+}
+
+func (window *ScreenshotWindow) showNotice(title string) {
+	if window.notice != nil {
+		window.notice.destroy()
+	}
+	window.notice = newNoticeBadge(window.Renderer(), title)
+}
+
+func (window *ScreenshotWindow) closeBarcodeOverlay() {
+	window.barcodeOverlay.destroy()
+	window.barcodeOverlay = nil
+}
+
+func (window *ScreenshotWindow) autoRedact() {
+	if window.redactionResults != nil {
+		return
+	}
+	pixels, surface := window.renderScreenshot()
+	cropRect := window.toolsPanel.CropRect(window.Renderer())
+	results := make(chan redactionOutcome, 1)
+	window.redactionResults = results
+	window.redactionProgress = newProgressBadge(window.Renderer(), searchingSensitiveTextTitle)
+	go func() {
+		matches, err := recognition.FindSensitiveText(surface)
 		surface.Free()
 		runtime.KeepAlive(pixels)
+		results <- redactionOutcome{matches: matches, offset: sdl.Point{X: cropRect.X, Y: cropRect.Y}, err: err}
+		gui.RequestRedraw()
 	}()
 }
 
+func (window *ScreenshotWindow) onSensitiveTextFound(outcome redactionOutcome) {
+	window.redactionResults = nil
+	window.redactionProgress.destroy()
+	window.redactionProgress = nil
+	if outcome.err != nil {
+		pkg.ShowErrorMessage("Can't find sensitive text: %v", outcome.err)
+		return
+	}
+	if len(outcome.matches) == 0 {
+		window.showNotice(noSensitiveTextFoundTitle)
+		return
+	}
+	suggestions := make([]editTools.RedactionSuggestion, len(outcome.matches))
+	for i, match := range outcome.matches {
+		suggestions[i] = editTools.RedactionSuggestion{
+			Kind: match.Kind,
+			Rect: sdl.Rect{
+				X: int32(match.BBox.Min.X) + outcome.offset.X, Y: int32(match.BBox.Min.Y) + outcome.offset.Y,
+				W: int32(match.BBox.Dx()), H: int32(match.BBox.Dy()),
+			},
+		}
+	}
+	window.toolsPanel.SuggestRedactions(suggestions)
+}
+
 func (window *ScreenshotWindow) drawScreenshotBackground(ren *sdl.Renderer) {
 	pkg.CopyTexture(ren, window.screenshotTexture, nil, nil)
 
@@ -229,8 +602,14 @@ func (window *ScreenshotWindow) drawScreenshotBackground(ren *sdl.Renderer) {
 	)
 }
 
+func (window ScreenshotWindow) setRenderTarget(texture *sdl.Texture) {
+	if err := window.Renderer().SetRenderTarget(texture); err != nil {
+		panic(err)
+	}
+}
+
 func takeScreenshot() (*image.RGBA, error) {
-	return screenshot.CaptureDisplay(0)
+	return screenshot.CaptureDisplay(screenshotDisplayIndex)
 }
 
 func getScreenshotSurface(screenshot *image.RGBA) (*sdl.Surface, error) {
@@ -252,15 +631,19 @@ func getScreenshotSurface(screenshot *image.RGBA) (*sdl.Surface, error) {
 	return screenshotSurface, nil
 }
 
-func readRenderIntoSurface(ren *sdl.Renderer) (surfaceData *[]byte, surface *sdl.Surface) {
-	vp := ren.GetViewport()
-	pitch := int(vp.W) * 4
-	pixels := pkg.ReadRGBA32(ren, nil)
+// Reads the whole render target texture, it has to be the current target
+func readTextureIntoSurface(ren *sdl.Renderer, texture *sdl.Texture) (surfaceData *[]byte, surface *sdl.Surface) {
+	_, _, w, h, err := texture.Query()
+	if err != nil {
+		panic(err)
+	}
+	pitch := int(w) * 4
+	pixels := pkg.ReadRGBA32(ren, &sdl.Rect{X: 0, Y: 0, W: w, H: h})
 	sh := (*reflect.SliceHeader)(unsafe.Pointer(&pixels))
-	surface, err := sdl.CreateRGBSurfaceFrom(
+	surface, err = sdl.CreateRGBSurfaceFrom(
 		unsafe.Pointer(sh.Data),
-		vp.W,
-		vp.H,
+		w,
+		h,
 		32,
 		pitch,
 		0x000000FF, 0x0000FF00, 0x00FF0000, 0xFF000000,
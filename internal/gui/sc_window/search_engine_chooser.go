@@ -0,0 +1,186 @@
+package scWindow
+
+import (
+	"github.com/Wine1y/trigat/assets"
+	"github.com/Wine1y/trigat/internal/gui"
+	"github.com/Wine1y/trigat/pkg"
+	"github.com/veandco/go-sdl2/sdl"
+	"github.com/veandco/go-sdl2/ttf"
+)
+
+const chooserFontSize int = 16
+const chooserPadding int32 = 6
+const chooserOptionPadding int32 = 8
+const chooserCornerRadius int32 = 8
+const chooserTitle string = "Search with"
+
+var chooserBackgroundColor = sdl.Color{R: 0, G: 0, B: 0, A: 170}
+var chooserHoverColor = sdl.Color{R: 255, G: 255, B: 255, A: 60}
+var chooserTitleColor = sdl.Color{R: 170, G: 170, B: 170, A: 255}
+var chooserTextColor = sdl.Color{R: 255, G: 255, B: 255, A: 255}
+
+type searchEngineChooser struct {
+	engines      []pkg.SearchEngine
+	font         *ttf.Font
+	titleTexture *pkg.StringTexture
+	textures     []*pkg.StringTexture
+	bbox         sdl.Rect
+	optionBBoxes []sdl.Rect
+	hoveredIndex int
+	closed       bool
+	onChosen     func(engine pkg.SearchEngine)
+	onCancelled  func()
+}
+
+func newSearchEngineChooser(
+	ren *sdl.Renderer,
+	engines []pkg.SearchEngine,
+	onChosen func(engine pkg.SearchEngine),
+	onCancelled func(),
+) *searchEngineChooser {
+	chooser := searchEngineChooser{
+		engines:      engines,
+		font:         assets.GetAppFont(chooserFontSize),
+		textures:     make([]*pkg.StringTexture, len(engines)),
+		optionBBoxes: make([]sdl.Rect, len(engines)),
+		hoveredIndex: 0,
+		onChosen:     onChosen,
+		onCancelled:  onCancelled,
+	}
+	chooser.titleTexture = pkg.NewStringTexture(ren, chooser.font, chooserTitle, chooserTitleColor)
+	optionsW := chooser.titleTexture.TextWidth
+	for i, engine := range engines {
+		chooser.textures[i] = pkg.NewStringTexture(ren, chooser.font, engine.Name, chooserTextColor)
+		optionsW = pkg.Max(optionsW, chooser.textures[i].TextWidth)
+	}
+	optionH := pkg.FontHeight(chooser.font) + chooserOptionPadding*2
+	optionW := optionsW + chooserOptionPadding*2
+
+	vp := ren.GetViewport()
+	chooser.bbox.W = optionW + chooserPadding*2
+	chooser.bbox.H = optionH*int32(len(engines)+1) + chooserPadding*2
+	chooser.bbox.X = (vp.W - chooser.bbox.W) / 2
+	chooser.bbox.Y = (vp.H - chooser.bbox.H) / 2
+	for i := range engines {
+		chooser.optionBBoxes[i] = sdl.Rect{
+			X: chooser.bbox.X + chooserPadding,
+			Y: chooser.bbox.Y + chooserPadding + optionH*int32(i+1),
+			W: optionW, H: optionH,
+		}
+	}
+	return &chooser
+}
+
+func (chooser *searchEngineChooser) draw(ren *sdl.Renderer) {
+	if chooser.closed {
+		return
+	}
+	pkg.DrawRoundedFilledRectangle(ren, &chooser.bbox, chooserCornerRadius, chooserBackgroundColor)
+	chooser.titleTexture.Draw(ren, &sdl.Point{
+		X: chooser.bbox.X + chooserPadding + chooserOptionPadding,
+		Y: chooser.bbox.Y + chooserPadding + chooserOptionPadding,
+	})
+	for i, texture := range chooser.textures {
+		bbox := chooser.optionBBoxes[i]
+		if i == chooser.hoveredIndex {
+			pkg.DrawRoundedFilledRectangle(ren, &bbox, chooserCornerRadius, chooserHoverColor)
+		}
+		texture.Draw(ren, &sdl.Point{X: bbox.X + chooserOptionPadding, Y: bbox.Y + chooserOptionPadding})
+	}
+}
+
+func (chooser *searchEngineChooser) callbacks() *gui.WindowCallbackSet {
+	callbacks := gui.NewWindowCallbackSet()
+
+	callbacks.MouseDown = append(callbacks.MouseDown, func(button uint8, x, y int32) bool {
+		if chooser.closed {
+			return false
+		}
+		if index, found := chooser.optionAt(x, y); found && button == sdl.BUTTON_LEFT {
+			chooser.choose(index)
+			return true
+		}
+		click := sdl.Point{X: x, Y: y}
+		if !click.InRect(&chooser.bbox) {
+			chooser.cancel()
+		}
+		return true
+	})
+
+	callbacks.MouseMove = append(callbacks.MouseMove, func(x, y int32) bool {
+		if chooser.closed {
+			return false
+		}
+		if index, found := chooser.optionAt(x, y); found {
+			chooser.hoveredIndex = index
+			sdl.SetCursor(gui.HandCursor)
+		} else {
+			sdl.SetCursor(gui.ArrowCursor)
+		}
+		return true
+	})
+
+	callbacks.MouseUp = append(callbacks.MouseUp, func(button uint8, x, y int32) bool {
+		return !chooser.closed
+	})
+
+	callbacks.KeyDown = append(callbacks.KeyDown, func(keysym sdl.Keysym) bool {
+		if chooser.closed {
+			return false
+		}
+		switch keysym.Sym {
+		case sdl.K_UP:
+			chooser.hoveredIndex = pkg.Max(chooser.hoveredIndex-1, 0)
+		case sdl.K_DOWN:
+			chooser.hoveredIndex = pkg.Min(chooser.hoveredIndex+1, len(chooser.engines)-1)
+		case sdl.K_RETURN, sdl.K_KP_ENTER:
+			chooser.choose(chooser.hoveredIndex)
+		case sdl.K_ESCAPE:
+			chooser.cancel()
+		}
+		return true
+	})
+
+	callbacks.Quit = append(callbacks.Quit, func() bool {
+		chooser.destroy()
+		return false
+	})
+	return callbacks
+}
+
+func (chooser searchEngineChooser) optionAt(x, y int32) (int, bool) {
+	point := sdl.Point{X: x, Y: y}
+	for i := range chooser.optionBBoxes {
+		if point.InRect(&chooser.optionBBoxes[i]) {
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+func (chooser *searchEngineChooser) choose(index int) {
+	chooser.close()
+	chooser.onChosen(chooser.engines[index])
+}
+
+func (chooser *searchEngineChooser) cancel() {
+	chooser.close()
+	chooser.onCancelled()
+}
+
+func (chooser *searchEngineChooser) close() {
+	chooser.closed = true
+	sdl.SetCursor(gui.ArrowCursor)
+}
+
+func (chooser *searchEngineChooser) destroy() {
+	if chooser.titleTexture == nil {
+		return
+	}
+	chooser.titleTexture.Destroy()
+	for _, texture := range chooser.textures {
+		texture.Destroy()
+	}
+	chooser.titleTexture = nil
+	pkg.CloseFont(chooser.font)
+}
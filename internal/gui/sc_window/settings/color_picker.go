@@ -1,43 +1,101 @@
 package settings
 
 import (
-	"math"
+	"fmt"
 
+	"github.com/Wine1y/trigat/assets"
+	"github.com/Wine1y/trigat/config"
 	"github.com/Wine1y/trigat/internal/gui"
 	"github.com/Wine1y/trigat/pkg"
 	"github.com/veandco/go-sdl2/sdl"
+	"github.com/veandco/go-sdl2/ttf"
 )
 
-const colorPickerHeight int32 = 200
 const gradientPadding int32 = 10
 const pickerGradientHeight int32 = 80
 const hueGradientHeight int32 = 20
 const pickerThumbRadius int32 = 8
 const pickerThumbThickness int32 = 3
+const alphaTrackHeight int32 = 12
+const alphaCheckerSize int32 = 4
+const presetsPerRow int = 5
+const presetSide int32 = 14
+const presetMargin int32 = 4
+const presetCornerRadius int32 = 3
+const colorInputHeight int32 = 22
+const colorInputPadding int32 = 5
+const colorInputFontSize int = 12
+const colorInputCornerRadius int32 = 4
 
 var hueThumbColor = sdl.Color{R: 255, G: 255, B: 255, A: 255}
 var pickerThumbColorLight = sdl.Color{R: 255, G: 255, B: 255, A: 255}
 var pickerThumbColorDark = sdl.Color{R: 0, G: 0, B: 0, A: 255}
+var alphaCheckerLightColor = sdl.Color{R: 255, G: 255, B: 255, A: 255}
+var alphaCheckerDarkColor = sdl.Color{R: 190, G: 190, B: 190, A: 255}
+var presetOutlineColor = sdl.Color{R: 0, G: 0, B: 0, A: 60}
+var colorInputBackgroundColor = sdl.Color{R: 255, G: 255, B: 255, A: 40}
+var colorInputFocusedBackgroundColor = sdl.Color{R: 255, G: 255, B: 255, A: 255}
+var colorInputTextColor = sdl.Color{R: 255, G: 255, B: 255, A: 255}
+var colorInputFocusedTextColor = sdl.Color{R: 0, G: 0, B: 0, A: 255}
+var colorInputInvalidColor = sdl.Color{R: 220, G: 40, B: 40, A: 255}
 
 type ColorPickerSetting struct {
 	*DefaultSetting
 	currentColor          hslColor
+	currentAlpha          uint8
 	currentPickerGradient cachedGradient
 	currentHueGradient    cachedGradient
+	alphaTrack            sdl.Rect
+	presets               []sdl.Color
+	presetBBoxes          []sdl.Rect
+	input                 colorInput
 	lastRenderer          *sdl.Renderer
 	draggingHue           bool
 	draggingPicker        bool
+	draggingAlpha         bool
 	onColorUpdated        func(color sdl.Color)
 }
 
+type colorInput struct {
+	bbox            sdl.Rect
+	font            *ttf.Font
+	text            []rune
+	focused         bool
+	invalid         bool
+	texture         *pkg.StringTexture
+	renderedText    string
+	renderedFocused bool
+}
+
 func NewColorPickerSetting(onColorUpdated func(color sdl.Color)) *ColorPickerSetting {
+	presets := colorPresets()
+	presetRows := int32((len(presets) + presetsPerRow - 1) / presetsPerRow)
+	height := pickerGradientHeight + hueGradientHeight + alphaTrackHeight + presetRows*(presetSide+presetMargin) +
+		colorInputHeight + gradientPadding*5
 	return &ColorPickerSetting{
-		DefaultSetting: NewDefaultSetting(colorPickerHeight),
+		DefaultSetting: NewDefaultSetting(height),
 		currentColor:   hslColor{H: 0, S: 1, L: 0.5},
+		currentAlpha:   255,
+		presets:        presets,
+		presetBBoxes:   make([]sdl.Rect, len(presets)),
+		input:          colorInput{font: assets.GetAppFont(colorInputFontSize)},
 		onColorUpdated: onColorUpdated,
 	}
 }
 
+func colorPresets() []sdl.Color {
+	presets := make([]sdl.Color, 0)
+	for _, preset := range config.GetColorPickerConfig().Presets {
+		color, err := pkg.ParseColor(preset)
+		if err != nil {
+			println(fmt.Sprintf("Invalid color preset %q: %v", preset, err))
+			continue
+		}
+		presets = append(presets, color)
+	}
+	return presets
+}
+
 func (setting *ColorPickerSetting) Render(ren *sdl.Renderer) {
 	if ren != setting.lastRenderer {
 		setting.lastRenderer = ren
@@ -74,6 +132,92 @@ func (setting *ColorPickerSetting) Render(ren *sdl.Renderer) {
 		pickerThumbThickness,
 		pickerThumbColor,
 	)
+
+	setting.renderAlphaTrack(ren)
+	for i, preset := range setting.presets {
+		pkg.DrawRoundedFilledRectangle(ren, &setting.presetBBoxes[i], presetCornerRadius, preset)
+		pkg.DrawRectangle(ren, &setting.presetBBoxes[i], presetOutlineColor)
+	}
+	setting.renderInput(ren)
+}
+
+func (setting *ColorPickerSetting) renderAlphaTrack(ren *sdl.Renderer) {
+	track := setting.alphaTrack
+	for y := int32(0); y < track.H; y += alphaCheckerSize {
+		for x := int32(0); x < track.W; x += alphaCheckerSize {
+			checkerColor := alphaCheckerLightColor
+			if (x/alphaCheckerSize+y/alphaCheckerSize)%2 == 1 {
+				checkerColor = alphaCheckerDarkColor
+			}
+			pkg.DrawFilledRectangle(ren, &sdl.Rect{
+				X: track.X + x, Y: track.Y + y,
+				W: pkg.Min(alphaCheckerSize, track.W-x) - 1, H: pkg.Min(alphaCheckerSize, track.H-y) - 1,
+			}, checkerColor)
+		}
+	}
+	color := setting.CurrentColor()
+	for x := int32(0); x < track.W; x++ {
+		color.A = uint8(255 * x / pkg.Max(track.W-1, 1))
+		pkg.DrawFilledRectangle(ren, &sdl.Rect{X: track.X + x, Y: track.Y, W: 0, H: track.H - 1}, color)
+	}
+	thumbX := track.X + int32(setting.currentAlpha)*(track.W-1)/255
+	pkg.DrawThickLine(
+		ren,
+		&sdl.Point{X: thumbX, Y: track.Y - gradientPadding/2},
+		&sdl.Point{X: thumbX, Y: track.Y + track.H - 1 + gradientPadding/2},
+		1, hueThumbColor,
+	)
+}
+
+func (setting *ColorPickerSetting) renderInput(ren *sdl.Renderer) {
+	input := &setting.input
+	text := string(input.text)
+	if !input.focused {
+		text = setting.currentColorString()
+	}
+	if input.texture == nil || input.renderedText != text || input.renderedFocused != input.focused {
+		if input.texture != nil {
+			input.texture.Destroy()
+			input.texture = nil
+		}
+		if text != "" {
+			textColor := colorInputTextColor
+			if input.focused {
+				textColor = colorInputFocusedTextColor
+			}
+			input.texture = pkg.NewStringTexture(ren, input.font, text, textColor)
+		}
+		input.renderedText, input.renderedFocused = text, input.focused
+	}
+
+	backgroundColor := colorInputBackgroundColor
+	if input.focused {
+		backgroundColor = colorInputFocusedBackgroundColor
+	}
+	pkg.DrawRoundedFilledRectangle(ren, &input.bbox, colorInputCornerRadius, backgroundColor)
+	if input.invalid {
+		pkg.DrawRectangle(ren, &input.bbox, colorInputInvalidColor)
+	}
+	textX := input.bbox.X + colorInputPadding
+	if input.texture != nil {
+		//Long input is scrolled so the end of the text stays visible
+		textX = pkg.Min(textX, input.bbox.X+input.bbox.W-colorInputPadding-input.texture.TextWidth)
+		ren.SetClipRect(&sdl.Rect{
+			X: input.bbox.X + colorInputPadding, Y: input.bbox.Y,
+			W: input.bbox.W - colorInputPadding*2, H: input.bbox.H,
+		})
+		input.texture.Draw(ren, &sdl.Point{X: textX, Y: input.bbox.Y + (input.bbox.H-input.texture.TextHeight)/2})
+		ren.SetClipRect(nil)
+		textX += input.texture.TextWidth
+	}
+	if input.focused {
+		pkg.DrawThickLine(
+			ren,
+			&sdl.Point{X: textX + 1, Y: input.bbox.Y + colorInputPadding},
+			&sdl.Point{X: textX + 1, Y: input.bbox.Y + input.bbox.H - colorInputPadding},
+			1, colorInputFocusedTextColor,
+		)
+	}
 }
 
 func (setting *ColorPickerSetting) SettingCallbacks() *gui.WindowCallbackSet {
@@ -95,6 +239,20 @@ func (setting *ColorPickerSetting) SettingCallbacks() *gui.WindowCallbackSet {
 			)
 			setting.draggingPicker = true
 		}
+		if click.InRect(&setting.alphaTrack) {
+			setting.newAlphaValue(x - setting.alphaTrack.X)
+			setting.draggingAlpha = true
+		}
+		for i := range setting.presetBBoxes {
+			if click.InRect(&setting.presetBBoxes[i]) {
+				setting.SetColor(setting.presets[i])
+			}
+		}
+		if click.InRect(&setting.input.bbox) {
+			setting.focusInput()
+		} else {
+			setting.input.focused = false
+		}
 		if click.InRect(&setting.bbox) {
 			return true
 		}
@@ -106,6 +264,10 @@ func (setting *ColorPickerSetting) SettingCallbacks() *gui.WindowCallbackSet {
 		if !move.InRect(&setting.bbox) {
 			setting.draggingHue = false
 			setting.draggingPicker = false
+			setting.draggingAlpha = false
+		}
+		if setting.draggingAlpha {
+			setting.newAlphaValue(x - setting.alphaTrack.X)
 		}
 		if setting.draggingHue {
 			setting.newHueValue(x - setting.currentHueGradient.bbox.X)
@@ -123,10 +285,38 @@ func (setting *ColorPickerSetting) SettingCallbacks() *gui.WindowCallbackSet {
 		if button == sdl.BUTTON_LEFT {
 			setting.draggingHue = false
 			setting.draggingPicker = false
+			setting.draggingAlpha = false
 		}
 		return false
 	})
 
+	callbacks.KeyDown = append(callbacks.KeyDown, func(keysym sdl.Keysym) bool {
+		if !setting.input.focused {
+			return false
+		}
+		switch keysym.Sym {
+		case sdl.K_RETURN, sdl.K_KP_ENTER:
+			setting.applyInput()
+		case sdl.K_ESCAPE:
+			setting.input.focused = false
+		case sdl.K_BACKSPACE:
+			if len(setting.input.text) > 0 {
+				setting.input.text = setting.input.text[:len(setting.input.text)-1]
+			}
+			setting.input.invalid = false
+		}
+		return true
+	})
+
+	callbacks.TextInput = append(callbacks.TextInput, func(text string) bool {
+		if !setting.input.focused {
+			return false
+		}
+		setting.input.text = append(setting.input.text, pkg.InputRunes(text)...)
+		setting.input.invalid = false
+		return true
+	})
+
 	callbacks.SizeChange = append(callbacks.SizeChange, func(w, h int32) bool {
 		setting.lastRenderer = nil
 		return false
@@ -139,6 +329,10 @@ func (setting *ColorPickerSetting) SettingCallbacks() *gui.WindowCallbackSet {
 		if setting.currentPickerGradient.texture != nil {
 			setting.currentPickerGradient.texture.Destroy()
 		}
+		if setting.input.texture != nil {
+			setting.input.texture.Destroy()
+			setting.input.texture = nil
+		}
 		return false
 	})
 	return callbacks
@@ -149,6 +343,59 @@ func (setting *ColorPickerSetting) SetWidth(width int32) {
 	setting.lastRenderer = nil
 }
 
+// Alpha track, presets and the input are placed under the hue gradient
+func (setting *ColorPickerSetting) updateLayout() {
+	contentW := setting.bbox.W - gradientPadding*2
+	y := setting.bbox.Y + pickerGradientHeight + hueGradientHeight + gradientPadding*3
+	setting.alphaTrack = sdl.Rect{X: setting.bbox.X + gradientPadding, Y: y, W: contentW, H: alphaTrackHeight}
+	y += alphaTrackHeight + gradientPadding
+
+	side := pkg.Min(presetSide, (contentW-presetMargin*int32(presetsPerRow-1))/int32(presetsPerRow))
+	for i := range setting.presetBBoxes {
+		setting.presetBBoxes[i] = sdl.Rect{
+			X: setting.bbox.X + gradientPadding + int32(i%presetsPerRow)*(side+presetMargin),
+			Y: y + int32(i/presetsPerRow)*(presetSide+presetMargin),
+			W: side, H: side,
+		}
+	}
+	y += int32((len(setting.presets)+presetsPerRow-1)/presetsPerRow) * (presetSide + presetMargin)
+	setting.input.bbox = sdl.Rect{X: setting.bbox.X + gradientPadding, Y: y, W: contentW, H: colorInputHeight}
+}
+
+func (setting *ColorPickerSetting) newAlphaValue(alphaOffset int32) {
+	alphaOffset = pkg.Clamp(0, alphaOffset, setting.alphaTrack.W-1)
+	setting.currentAlpha = uint8(255 * alphaOffset / pkg.Max(setting.alphaTrack.W-1, 1))
+	setting.colorUpdated()
+}
+
+func (setting ColorPickerSetting) IsFocused() bool {
+	return setting.input.focused
+}
+
+func (setting *ColorPickerSetting) focusInput() {
+	setting.input.focused = true
+	setting.input.invalid = false
+	setting.input.text = []rune(setting.currentColorString())
+}
+
+func (setting *ColorPickerSetting) applyInput() {
+	color, err := pkg.ParseColor(string(setting.input.text))
+	if err != nil {
+		setting.input.invalid = true
+		return
+	}
+	setting.input.focused = false
+	setting.SetColor(color)
+}
+
+func (setting ColorPickerSetting) currentColorString() string {
+	color := setting.CurrentColor()
+	if color.A != 255 {
+		return pkg.FormatHexAlphaColor(color)
+	}
+	return pkg.FormatHexColor(color)
+}
+
 func (setting *ColorPickerSetting) newHueValue(hueOffset int32) {
 	hueOffset = pkg.Clamp(0, hueOffset, setting.currentHueGradient.bbox.W-1)
 	h := 360 / (float64(setting.currentHueGradient.bbox.W) - 1)
@@ -169,6 +416,7 @@ func (setting *ColorPickerSetting) newPickerValue(xOffset, yOffset int32) {
 }
 
 func (setting *ColorPickerSetting) updateGradients() {
+	setting.updateLayout()
 	if err := setting.updatePickerGradient(); err != nil {
 		panic(err)
 	}
@@ -197,7 +445,7 @@ func (setting *ColorPickerSetting) updatePickerGradient() error {
 	lightnessLinspace := pkg.Linspace(1, 0, int(pickerGradientHeight))
 	for y := int32(0); y < pickerGradientHeight; y++ {
 		for x := int32(0); x < pickerW; x++ {
-			color := hslToRGB(setting.currentColor.H, saturationLinspace[x], lightnessLinspace[y])
+			color := pkg.HSLToRGB(setting.currentColor.H, saturationLinspace[x], lightnessLinspace[y])
 			pkg.DrawPoint(setting.lastRenderer, &sdl.Point{X: x, Y: y}, color)
 		}
 	}
@@ -233,7 +481,7 @@ func (setting *ColorPickerSetting) updateHueGradient() error {
 	hueLinspace := pkg.Linspace(0, 360, int(hueW))
 	for y := int32(0); y < hueGradientHeight; y++ {
 		for x := int32(0); x < hueW; x++ {
-			color := hslToRGB(hueLinspace[x], 1, 0.5)
+			color := pkg.HSLToRGB(hueLinspace[x], 1, 0.5)
 			pkg.DrawPoint(setting.lastRenderer, &sdl.Point{X: x, Y: y}, color)
 		}
 	}
@@ -251,13 +499,25 @@ func (setting *ColorPickerSetting) updateHueGradient() error {
 }
 
 func (setting ColorPickerSetting) colorUpdated() {
-	setting.onColorUpdated(
-		hslToRGB(setting.currentColor.H, setting.currentColor.S, setting.currentColor.L),
-	)
+	setting.onColorUpdated(setting.CurrentColor())
+}
+
+func (setting *ColorPickerSetting) SetColor(color sdl.Color) {
+	h, s, l := pkg.RGBToHSL(color)
+	setting.currentColor = hslColor{H: h, S: s, L: l}
+	setting.currentAlpha = color.A
+	if setting.lastRenderer != nil {
+		if err := setting.updatePickerGradient(); err != nil {
+			panic(err)
+		}
+	}
+	setting.colorUpdated()
 }
 
 func (setting ColorPickerSetting) CurrentColor() sdl.Color {
-	return hslToRGB(setting.currentColor.H, setting.currentColor.S, setting.currentColor.L)
+	color := pkg.HSLToRGB(setting.currentColor.H, setting.currentColor.S, setting.currentColor.L)
+	color.A = setting.currentAlpha
+	return color
 }
 
 type cachedGradient struct {
@@ -285,26 +545,3 @@ func (color hslColor) hueOffset(hueWidth int32) int32 {
 	h := 360 / (float64(hueWidth) - 1)
 	return int32(color.H / h)
 }
-
-func hslToRGB(h, s, l float64) sdl.Color {
-	C := (1 - pkg.Abs(l*2-1)) * s
-	H := h / 60
-	X := C * (1 - pkg.Abs(math.Mod(H, 2)-1))
-	var r, g, b float64
-	switch {
-	case H >= 0 && H <= 1:
-		r, g, b = C, X, 0
-	case H >= 1 && H <= 2:
-		r, g, b = X, C, 0
-	case H >= 2 && H <= 3:
-		r, g, b = 0, C, X
-	case H >= 3 && H <= 4:
-		r, g, b = 0, X, C
-	case h >= 4 && H <= 5:
-		r, g, b = X, 0, C
-	case h >= 5 && H <= 6:
-		r, g, b = C, 0, X
-	}
-	m := l - C/2
-	return sdl.Color{R: uint8((r + m) * 255), G: uint8((g + m) * 255), B: uint8((b + m) * 255), A: 255}
-}
@@ -0,0 +1,278 @@
+package settings
+
+import (
+	"github.com/Wine1y/trigat/assets"
+	"github.com/Wine1y/trigat/internal/gui"
+	"github.com/Wine1y/trigat/pkg"
+	"github.com/veandco/go-sdl2/sdl"
+	"github.com/veandco/go-sdl2/ttf"
+)
+
+const dropdownHeight int32 = 30
+const dropdownBoxHeight int32 = 22
+const dropdownRadius int32 = 6
+const dropdownTextPadding int32 = 6
+const dropdownArrowSize int32 = 4
+const dropdownFontSize int = 12
+const dropdownRowHeight int32 = 20
+const dropdownVisibleRows int = 8
+const dropdownListPadding int32 = 3
+const dropdownScrollbarWidth int32 = 3
+
+var dropdownBoxColor = sdl.Color{R: 255, G: 255, B: 255, A: 90}
+var dropdownListColor = sdl.Color{R: 70, G: 70, B: 70, A: 240}
+var dropdownHoverColor = sdl.Color{R: 255, G: 255, B: 255, A: 60}
+var dropdownActiveColor = sdl.Color{R: 255, G: 255, B: 255, A: 110}
+var dropdownTextColor = sdl.Color{R: 255, G: 255, B: 255, A: 255}
+var dropdownScrollbarColor = sdl.Color{R: 255, G: 255, B: 255, A: 150}
+
+// DropdownSetting shows the current option and unfolds a scrollable list of all options over the settings below it
+type DropdownSetting struct {
+	*DefaultSetting
+	options          []string
+	currentOption    int
+	hoveredOption    int
+	scrollOffset     int
+	isExpanded       bool
+	lastMousePos     sdl.Point
+	font             *ttf.Font
+	textures         []*pkg.StringTexture
+	lastRenderer     *sdl.Renderer
+	box              sdl.Rect
+	onOptionSelected func(option int)
+}
+
+func NewDropdownSetting(options []string, currentOption int, onOptionSelected func(option int)) *DropdownSetting {
+	if len(options) == 0 {
+		panic("Dropdown setting requires at least one option")
+	}
+	return &DropdownSetting{
+		DefaultSetting:   NewDefaultSetting(dropdownHeight),
+		options:          options,
+		currentOption:    pkg.Clamp(0, currentOption, len(options)-1),
+		hoveredOption:    -1,
+		font:             assets.GetAppFont(dropdownFontSize),
+		textures:         make([]*pkg.StringTexture, len(options)),
+		onOptionSelected: onOptionSelected,
+	}
+}
+
+func (setting *DropdownSetting) Render(ren *sdl.Renderer) {
+	if ren != setting.lastRenderer {
+		setting.destroyTextures()
+		setting.lastRenderer = ren
+	}
+	pkg.DrawRoundedFilledRectangle(ren, &setting.box, dropdownRadius, dropdownBoxColor)
+	arrowCenter := sdl.Point{
+		X: setting.box.X + setting.box.W - dropdownTextPadding - dropdownArrowSize,
+		Y: setting.box.Y + setting.box.H/2,
+	}
+	arrowDirection := int32(1)
+	if setting.isExpanded {
+		arrowDirection = -1
+	}
+	pkg.DrawFilledTriangle(
+		ren,
+		&sdl.Point{X: arrowCenter.X - dropdownArrowSize, Y: arrowCenter.Y - dropdownArrowSize/2*arrowDirection},
+		&sdl.Point{X: arrowCenter.X + dropdownArrowSize, Y: arrowCenter.Y - dropdownArrowSize/2*arrowDirection},
+		&sdl.Point{X: arrowCenter.X, Y: arrowCenter.Y + dropdownArrowSize/2*arrowDirection},
+		dropdownTextColor,
+	)
+	setting.drawOption(
+		ren,
+		setting.currentOption,
+		&sdl.Rect{
+			X: setting.box.X, Y: setting.box.Y,
+			W: setting.box.W - dropdownTextPadding - dropdownArrowSize*2, H: setting.box.H,
+		},
+	)
+}
+
+func (setting *DropdownSetting) RenderOverlay(ren *sdl.Renderer) {
+	if !setting.isExpanded {
+		return
+	}
+	list := setting.listRect()
+	pkg.DrawRoundedFilledRectangle(ren, &list, dropdownRadius, dropdownListColor)
+	for i := setting.scrollOffset; i < setting.scrollOffset+setting.visibleRows(); i++ {
+		bbox := setting.optionBBox(i)
+		switch i {
+		case setting.currentOption:
+			pkg.DrawRoundedFilledRectangle(ren, &bbox, listRadius, dropdownActiveColor)
+		case setting.hoveredOption:
+			pkg.DrawRoundedFilledRectangle(ren, &bbox, listRadius, dropdownHoverColor)
+		}
+		setting.drawOption(ren, i, &bbox)
+	}
+	if len(setting.options) > dropdownVisibleRows {
+		trackH := list.H - dropdownListPadding*2
+		thumbH := pkg.Max(dropdownRowHeight/2, trackH*int32(dropdownVisibleRows)/int32(len(setting.options)))
+		maxOffset := len(setting.options) - dropdownVisibleRows
+		thumbY := list.Y + dropdownListPadding + (trackH-thumbH)*int32(setting.scrollOffset)/int32(maxOffset)
+		pkg.DrawFilledRectangle(
+			ren,
+			&sdl.Rect{
+				X: list.X + list.W - dropdownListPadding - dropdownScrollbarWidth, Y: thumbY,
+				W: dropdownScrollbarWidth, H: thumbH,
+			},
+			dropdownScrollbarColor,
+		)
+	}
+}
+
+func (setting *DropdownSetting) SettingCallbacks() *gui.WindowCallbackSet {
+	callbacks := gui.NewWindowCallbackSet()
+	callbacks.MouseDown = append(callbacks.MouseDown, func(button uint8, x, y int32) bool {
+		click := sdl.Point{X: x, Y: y}
+		setting.lastMousePos = click
+		if setting.isExpanded {
+			list := setting.listRect()
+			if !click.InRect(&list) {
+				setting.isExpanded = false
+				return click.InRect(&setting.bbox)
+			}
+			if option := setting.optionAt(click); button == sdl.BUTTON_LEFT && option != -1 {
+				setting.isExpanded = false
+				setting.SetOption(option)
+			}
+			return true
+		}
+		if button == sdl.BUTTON_LEFT && click.InRect(&setting.box) {
+			setting.isExpanded = true
+			setting.scrollTo(setting.currentOption - dropdownVisibleRows/2)
+		}
+		return click.InRect(&setting.bbox)
+	})
+	callbacks.MouseMove = append(callbacks.MouseMove, func(x, y int32) bool {
+		setting.lastMousePos = sdl.Point{X: x, Y: y}
+		if !setting.isExpanded {
+			return false
+		}
+		//The list is folded once the pointer leaves the settings column
+		if x < setting.bbox.X || x >= setting.bbox.X+setting.bbox.W || y < setting.bbox.Y {
+			setting.isExpanded = false
+			return false
+		}
+		move := sdl.Point{X: x, Y: y}
+		setting.hoveredOption = setting.optionAt(move)
+		list := setting.listRect()
+		return move.InRect(&list)
+	})
+	callbacks.MouseWheel = append(callbacks.MouseWheel, func(x, y int32) bool {
+		if !setting.isExpanded {
+			return false
+		}
+		//Wheel events don't carry the pointer position
+		list := setting.listRect()
+		if !setting.lastMousePos.InRect(&list) {
+			return false
+		}
+		setting.scrollTo(setting.scrollOffset - int(y))
+		setting.hoveredOption = setting.optionAt(setting.lastMousePos)
+		return true
+	})
+	callbacks.Quit = append(callbacks.Quit, func() bool {
+		setting.destroyTextures()
+		return false
+	})
+	return callbacks
+}
+
+func (setting *DropdownSetting) SetLeftTop(lt *sdl.Point) {
+	setting.DefaultSetting.SetLeftTop(lt)
+	setting.resize()
+}
+
+func (setting *DropdownSetting) SetWidth(width int32) {
+	setting.DefaultSetting.SetWidth(width)
+	setting.resize()
+}
+
+func (setting *DropdownSetting) resize() {
+	setting.box = sdl.Rect{
+		X: setting.bbox.X, Y: setting.bbox.Y + (setting.bbox.H-dropdownBoxHeight)/2,
+		W: setting.bbox.W, H: dropdownBoxHeight,
+	}
+}
+
+func (setting DropdownSetting) visibleRows() int {
+	return pkg.Min(len(setting.options), dropdownVisibleRows)
+}
+
+func (setting DropdownSetting) listRect() sdl.Rect {
+	return sdl.Rect{
+		X: setting.box.X, Y: setting.box.Y + setting.box.H,
+		W: setting.box.W, H: dropdownRowHeight*int32(setting.visibleRows()) + dropdownListPadding*2,
+	}
+}
+
+func (setting DropdownSetting) optionBBox(option int) sdl.Rect {
+	list := setting.listRect()
+	return sdl.Rect{
+		X: list.X + dropdownListPadding,
+		Y: list.Y + dropdownListPadding + dropdownRowHeight*int32(option-setting.scrollOffset),
+		W: list.W - dropdownListPadding*3 - dropdownScrollbarWidth, H: dropdownRowHeight,
+	}
+}
+
+func (setting DropdownSetting) optionAt(point sdl.Point) int {
+	for i := setting.scrollOffset; i < setting.scrollOffset+setting.visibleRows(); i++ {
+		bbox := setting.optionBBox(i)
+		if point.InRect(&bbox) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (setting *DropdownSetting) scrollTo(offset int) {
+	setting.scrollOffset = pkg.Clamp(0, offset, len(setting.options)-setting.visibleRows())
+}
+
+// Draws the option name inside of the bbox, names that don't fit are cut
+func (setting *DropdownSetting) drawOption(ren *sdl.Renderer, option int, bbox *sdl.Rect) {
+	if setting.textures[option] == nil {
+		setting.textures[option] = pkg.NewStringTexture(ren, setting.font, setting.options[option], dropdownTextColor)
+	}
+	texture := setting.textures[option]
+	texture.DrawCropped(
+		ren,
+		&sdl.Point{X: bbox.X + dropdownTextPadding, Y: bbox.Y + (bbox.H-texture.TextHeight)/2},
+		bbox.W-dropdownTextPadding*2,
+	)
+}
+
+func (setting *DropdownSetting) destroyTextures() {
+	for i, texture := range setting.textures {
+		if texture != nil {
+			texture.Destroy()
+			setting.textures[i] = nil
+		}
+	}
+}
+
+func (setting DropdownSetting) CurrentOption() int {
+	return setting.currentOption
+}
+
+func (setting *DropdownSetting) SetOption(option int) {
+	option = pkg.Clamp(0, option, len(setting.options)-1)
+	if option == setting.currentOption {
+		return
+	}
+	setting.currentOption = option
+	setting.onOptionSelected(option)
+}
+
+// Replaces the options without calling the callback
+func (setting *DropdownSetting) SetOptions(options []string, currentOption int) {
+	if len(options) == 0 {
+		panic("Dropdown setting requires at least one option")
+	}
+	setting.destroyTextures()
+	setting.options = options
+	setting.textures = make([]*pkg.StringTexture, len(options))
+	setting.currentOption = pkg.Clamp(0, currentOption, len(options)-1)
+	setting.hoveredOption = -1
+	setting.scrollTo(setting.scrollOffset)
+}
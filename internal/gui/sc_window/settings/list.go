@@ -0,0 +1,130 @@
+package settings
+
+import (
+	"github.com/Wine1y/trigat/assets"
+	"github.com/Wine1y/trigat/internal/gui"
+	"github.com/Wine1y/trigat/pkg"
+	"github.com/veandco/go-sdl2/sdl"
+	"github.com/veandco/go-sdl2/ttf"
+)
+
+const listRowHeight int32 = 20
+const listPadding int32 = 4
+const listTextPadding int32 = 6
+const listFontSize int = 12
+const listRadius int32 = 4
+
+var listHoverColor = sdl.Color{R: 255, G: 255, B: 255, A: 60}
+var listActiveColor = sdl.Color{R: 255, G: 255, B: 255, A: 255}
+var listTextColor = sdl.Color{R: 255, G: 255, B: 255, A: 255}
+var listActiveTextColor = sdl.Color{R: 0, G: 0, B: 0, A: 255}
+
+type ListSetting struct {
+	*DefaultSetting
+	options          []string
+	currentOption    int
+	hoveredOption    int
+	font             *ttf.Font
+	textures         []*pkg.StringTexture
+	activeTextures   []*pkg.StringTexture
+	lastRenderer     *sdl.Renderer
+	onOptionSelected func(option int)
+}
+
+func NewListSetting(options []string, currentOption int, onOptionSelected func(option int)) *ListSetting {
+	if len(options) == 0 {
+		panic("List setting requires at least one option")
+	}
+	return &ListSetting{
+		DefaultSetting:   NewDefaultSetting(listRowHeight*int32(len(options)) + listPadding*2),
+		options:          options,
+		currentOption:    pkg.Clamp(0, currentOption, len(options)-1),
+		hoveredOption:    -1,
+		font:             assets.GetAppFont(listFontSize),
+		onOptionSelected: onOptionSelected,
+	}
+}
+
+func (setting *ListSetting) Render(ren *sdl.Renderer) {
+	if ren != setting.lastRenderer {
+		setting.lastRenderer = ren
+		setting.updateTextures()
+	}
+	for i := range setting.options {
+		bbox := setting.optionBBox(i)
+		texture := setting.textures[i]
+		switch i {
+		case setting.currentOption:
+			pkg.DrawRoundedFilledRectangle(ren, &bbox, listRadius, listActiveColor)
+			texture = setting.activeTextures[i]
+		case setting.hoveredOption:
+			pkg.DrawRoundedFilledRectangle(ren, &bbox, listRadius, listHoverColor)
+		}
+		texture.Draw(ren, &sdl.Point{
+			X: bbox.X + listTextPadding,
+			Y: bbox.Y + (bbox.H-texture.TextHeight)/2,
+		})
+	}
+}
+
+func (setting *ListSetting) SettingCallbacks() *gui.WindowCallbackSet {
+	callbacks := gui.NewWindowCallbackSet()
+	callbacks.MouseDown = append(callbacks.MouseDown, func(button uint8, x, y int32) bool {
+		click := sdl.Point{X: x, Y: y}
+		if button == sdl.BUTTON_LEFT {
+			if option := setting.optionAt(click); option != -1 && option != setting.currentOption {
+				setting.currentOption = option
+				setting.onOptionSelected(option)
+			}
+		}
+		return click.InRect(&setting.bbox)
+	})
+	callbacks.MouseMove = append(callbacks.MouseMove, func(x, y int32) bool {
+		setting.hoveredOption = setting.optionAt(sdl.Point{X: x, Y: y})
+		return false
+	})
+	callbacks.Quit = append(callbacks.Quit, func() bool {
+		setting.destroyTextures()
+		return false
+	})
+	return callbacks
+}
+
+func (setting ListSetting) optionBBox(option int) sdl.Rect {
+	return sdl.Rect{
+		X: setting.bbox.X + listPadding, Y: setting.bbox.Y + listPadding + listRowHeight*int32(option),
+		W: setting.bbox.W - listPadding*2, H: listRowHeight,
+	}
+}
+
+func (setting ListSetting) optionAt(point sdl.Point) int {
+	for i := range setting.options {
+		bbox := setting.optionBBox(i)
+		if point.InRect(&bbox) {
+			return i
+		}
+	}
+	return -1
+}
+
+func (setting *ListSetting) updateTextures() {
+	setting.destroyTextures()
+	setting.textures = make([]*pkg.StringTexture, len(setting.options))
+	setting.activeTextures = make([]*pkg.StringTexture, len(setting.options))
+	for i, option := range setting.options {
+		setting.textures[i] = pkg.NewStringTexture(setting.lastRenderer, setting.font, option, listTextColor)
+		setting.activeTextures[i] = pkg.NewStringTexture(setting.lastRenderer, setting.font, option, listActiveTextColor)
+	}
+}
+
+func (setting *ListSetting) destroyTextures() {
+	for i := range setting.textures {
+		setting.textures[i].Destroy()
+		setting.activeTextures[i].Destroy()
+	}
+	setting.textures, setting.activeTextures = nil, nil
+}
+
+func (setting ListSetting) CurrentOption() int {
+	return setting.currentOption
+}
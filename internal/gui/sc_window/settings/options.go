@@ -0,0 +1,150 @@
+package settings
+
+import (
+	"github.com/Wine1y/trigat/assets"
+	"github.com/Wine1y/trigat/internal/gui"
+	"github.com/Wine1y/trigat/pkg"
+	"github.com/veandco/go-sdl2/sdl"
+	"github.com/veandco/go-sdl2/ttf"
+)
+
+const optionsHeight int32 = 30
+const optionsFontSize int = 12
+const optionsTrackHeight int32 = 22
+const optionsRadius int32 = 6
+const optionsActivePadding int32 = 2
+
+var optionsTrackColor = sdl.Color{R: 255, G: 255, B: 255, A: 90}
+var optionsActiveColor = sdl.Color{R: 255, G: 255, B: 255, A: 255}
+var optionsTextColor = sdl.Color{R: 255, G: 255, B: 255, A: 255}
+var optionsActiveTextColor = sdl.Color{R: 0, G: 0, B: 0, A: 255}
+
+type OptionsSetting struct {
+	*DefaultSetting
+	options          []string
+	currentOption    int
+	font             *ttf.Font
+	textures         []*pkg.StringTexture
+	activeTextures   []*pkg.StringTexture
+	lastRenderer     *sdl.Renderer
+	track            sdl.Rect
+	optionBBoxes     []sdl.Rect
+	onOptionSelected func(option int)
+}
+
+func NewOptionsSetting(options []string, currentOption int, onOptionSelected func(option int)) *OptionsSetting {
+	if len(options) == 0 {
+		panic("Options setting requires at least one option")
+	}
+	return &OptionsSetting{
+		DefaultSetting:   NewDefaultSetting(optionsHeight),
+		options:          options,
+		currentOption:    pkg.Clamp(0, currentOption, len(options)-1),
+		font:             assets.GetAppFont(optionsFontSize),
+		optionBBoxes:     make([]sdl.Rect, len(options)),
+		onOptionSelected: onOptionSelected,
+	}
+}
+
+func (setting *OptionsSetting) Render(ren *sdl.Renderer) {
+	if ren != setting.lastRenderer {
+		setting.lastRenderer = ren
+		setting.updateTextures()
+	}
+	pkg.DrawRoundedFilledRectangle(ren, &setting.track, optionsRadius, optionsTrackColor)
+	for i, bbox := range setting.optionBBoxes {
+		texture := setting.textures[i]
+		if i == setting.currentOption {
+			pkg.DrawRoundedFilledRectangle(
+				ren,
+				&sdl.Rect{
+					X: bbox.X + optionsActivePadding, Y: bbox.Y + optionsActivePadding,
+					W: bbox.W - optionsActivePadding*2, H: bbox.H - optionsActivePadding*2,
+				},
+				optionsRadius-optionsActivePadding,
+				optionsActiveColor,
+			)
+			texture = setting.activeTextures[i]
+		}
+		texture.Draw(ren, &sdl.Point{
+			X: bbox.X + (bbox.W-texture.TextWidth)/2,
+			Y: bbox.Y + (bbox.H-texture.TextHeight)/2,
+		})
+	}
+}
+
+func (setting *OptionsSetting) SettingCallbacks() *gui.WindowCallbackSet {
+	callbacks := gui.NewWindowCallbackSet()
+	callbacks.MouseDown = append(callbacks.MouseDown, func(button uint8, x, y int32) bool {
+		click := sdl.Point{X: x, Y: y}
+		if button == sdl.BUTTON_LEFT {
+			for i := range setting.optionBBoxes {
+				if click.InRect(&setting.optionBBoxes[i]) && i != setting.currentOption {
+					setting.currentOption = i
+					setting.onOptionSelected(i)
+				}
+			}
+		}
+		return click.InRect(&setting.bbox)
+	})
+	callbacks.Quit = append(callbacks.Quit, func() bool {
+		setting.destroyTextures()
+		return false
+	})
+	return callbacks
+}
+
+func (setting *OptionsSetting) SetLeftTop(lt *sdl.Point) {
+	setting.DefaultSetting.SetLeftTop(lt)
+	setting.resize()
+}
+
+func (setting *OptionsSetting) SetWidth(width int32) {
+	setting.DefaultSetting.SetWidth(width)
+	setting.resize()
+}
+
+func (setting *OptionsSetting) resize() {
+	setting.track = sdl.Rect{
+		X: setting.bbox.X, Y: setting.bbox.Y + (setting.bbox.H-optionsTrackHeight)/2,
+		W: setting.bbox.W, H: optionsTrackHeight,
+	}
+	optionW := setting.track.W / int32(len(setting.options))
+	for i := range setting.optionBBoxes {
+		setting.optionBBoxes[i] = sdl.Rect{
+			X: setting.track.X + optionW*int32(i), Y: setting.track.Y,
+			W: optionW, H: setting.track.H,
+		}
+	}
+}
+
+func (setting *OptionsSetting) updateTextures() {
+	setting.destroyTextures()
+	setting.textures = make([]*pkg.StringTexture, len(setting.options))
+	setting.activeTextures = make([]*pkg.StringTexture, len(setting.options))
+	for i, option := range setting.options {
+		setting.textures[i] = pkg.NewStringTexture(setting.lastRenderer, setting.font, option, optionsTextColor)
+		setting.activeTextures[i] = pkg.NewStringTexture(setting.lastRenderer, setting.font, option, optionsActiveTextColor)
+	}
+}
+
+func (setting *OptionsSetting) destroyTextures() {
+	for i := range setting.textures {
+		setting.textures[i].Destroy()
+		setting.activeTextures[i].Destroy()
+	}
+	setting.textures, setting.activeTextures = nil, nil
+}
+
+func (setting OptionsSetting) CurrentOption() int {
+	return setting.currentOption
+}
+
+func (setting *OptionsSetting) SetOption(option int) {
+	option = pkg.Clamp(0, option, len(setting.options)-1)
+	if option == setting.currentOption {
+		return
+	}
+	setting.currentOption = option
+	setting.onOptionSelected(option)
+}
@@ -14,6 +14,16 @@ type ToolSetting interface {
 	SettingCallbacks() *gui.WindowCallbackSet
 }
 
+// Settings that draw over the settings placed below them, like an unfolded dropdown
+type OverlaySetting interface {
+	RenderOverlay(ren *sdl.Renderer)
+}
+
+// Settings with a text input, they stay on the screen while the input is focused
+type FocusableSetting interface {
+	IsFocused() bool
+}
+
 type DefaultSetting struct {
 	bbox sdl.Rect
 }
@@ -29,8 +29,8 @@ type SliderSetting struct {
 }
 
 func NewSliderSetting(minValue, maxValue uint, onValueUpdated func(value uint)) *SliderSetting {
-	if minValue == 0 {
-		panic("Slider value can't be 0 or lower")
+	if maxValue <= minValue {
+		panic("Slider maximum value must be greater than the minimum one")
 	}
 	return &SliderSetting{
 		DefaultSetting: NewDefaultSetting(sliderHeight),
@@ -111,7 +111,7 @@ func (setting *SliderSetting) resize() {
 	trackY := setting.bbox.Y + (setting.bbox.H-trackHeight)/2
 	trackW := setting.bbox.W - trackRadius*2
 	pixelsPerValue := float64(trackW) / float64(setting.maxValue-setting.minValue)
-	thumbOffset := float64(setting.currentValue-1) * pixelsPerValue
+	thumbOffset := float64(setting.currentValue-setting.minValue) * pixelsPerValue
 	thumbCenter := sdl.Point{X: int32(float64(trackX) + thumbOffset), Y: trackY + trackHeight/2}
 
 	setting.track = sdl.Rect{
@@ -134,7 +134,7 @@ func (setting *SliderSetting) updateValue(x int32) {
 		x = trackX + trackW
 	}
 	valuePerPixel := float64(setting.maxValue-setting.minValue) / float64(trackW)
-	setting.currentValue = uint(math.Round(float64(x-trackX)*valuePerPixel)) + 1
+	setting.currentValue = uint(math.Round(float64(x-trackX)*valuePerPixel)) + setting.minValue
 	setting.onValueUpdated(setting.currentValue)
 	setting.resize()
 }
@@ -142,3 +142,9 @@ func (setting *SliderSetting) updateValue(x int32) {
 func (slider SliderSetting) CurrentValue() uint {
 	return slider.currentValue
 }
+
+func (slider *SliderSetting) SetValue(value uint) {
+	slider.currentValue = pkg.Clamp(slider.minValue, value, slider.maxValue)
+	slider.onValueUpdated(slider.currentValue)
+	slider.resize()
+}
@@ -0,0 +1,138 @@
+package settings
+
+import (
+	"github.com/Wine1y/trigat/assets"
+	"github.com/Wine1y/trigat/internal/gui"
+	"github.com/Wine1y/trigat/pkg"
+	"github.com/veandco/go-sdl2/sdl"
+	"github.com/veandco/go-sdl2/ttf"
+)
+
+// TogglesSetting looks like OptionsSetting, but every option is switched on and off independently
+type TogglesSetting struct {
+	*DefaultSetting
+	options        []string
+	toggled        []bool
+	font           *ttf.Font
+	textures       []*pkg.StringTexture
+	activeTextures []*pkg.StringTexture
+	lastRenderer   *sdl.Renderer
+	track          sdl.Rect
+	optionBBoxes   []sdl.Rect
+	onToggled      func(option int, toggled bool)
+}
+
+func NewTogglesSetting(options []string, onToggled func(option int, toggled bool)) *TogglesSetting {
+	if len(options) == 0 {
+		panic("Toggles setting requires at least one option")
+	}
+	return &TogglesSetting{
+		DefaultSetting: NewDefaultSetting(optionsHeight),
+		options:        options,
+		toggled:        make([]bool, len(options)),
+		font:           assets.GetAppFont(optionsFontSize),
+		optionBBoxes:   make([]sdl.Rect, len(options)),
+		onToggled:      onToggled,
+	}
+}
+
+func (setting *TogglesSetting) Render(ren *sdl.Renderer) {
+	if ren != setting.lastRenderer {
+		setting.lastRenderer = ren
+		setting.updateTextures()
+	}
+	pkg.DrawRoundedFilledRectangle(ren, &setting.track, optionsRadius, optionsTrackColor)
+	for i, bbox := range setting.optionBBoxes {
+		texture := setting.textures[i]
+		if setting.toggled[i] {
+			pkg.DrawRoundedFilledRectangle(
+				ren,
+				&sdl.Rect{
+					X: bbox.X + optionsActivePadding, Y: bbox.Y + optionsActivePadding,
+					W: bbox.W - optionsActivePadding*2, H: bbox.H - optionsActivePadding*2,
+				},
+				optionsRadius-optionsActivePadding,
+				optionsActiveColor,
+			)
+			texture = setting.activeTextures[i]
+		}
+		texture.Draw(ren, &sdl.Point{
+			X: bbox.X + (bbox.W-texture.TextWidth)/2,
+			Y: bbox.Y + (bbox.H-texture.TextHeight)/2,
+		})
+	}
+}
+
+func (setting *TogglesSetting) SettingCallbacks() *gui.WindowCallbackSet {
+	callbacks := gui.NewWindowCallbackSet()
+	callbacks.MouseDown = append(callbacks.MouseDown, func(button uint8, x, y int32) bool {
+		click := sdl.Point{X: x, Y: y}
+		if button == sdl.BUTTON_LEFT {
+			for i := range setting.optionBBoxes {
+				if click.InRect(&setting.optionBBoxes[i]) {
+					setting.SetToggled(i, !setting.toggled[i])
+				}
+			}
+		}
+		return click.InRect(&setting.bbox)
+	})
+	callbacks.Quit = append(callbacks.Quit, func() bool {
+		setting.destroyTextures()
+		return false
+	})
+	return callbacks
+}
+
+func (setting *TogglesSetting) SetLeftTop(lt *sdl.Point) {
+	setting.DefaultSetting.SetLeftTop(lt)
+	setting.resize()
+}
+
+func (setting *TogglesSetting) SetWidth(width int32) {
+	setting.DefaultSetting.SetWidth(width)
+	setting.resize()
+}
+
+func (setting *TogglesSetting) resize() {
+	setting.track = sdl.Rect{
+		X: setting.bbox.X, Y: setting.bbox.Y + (setting.bbox.H-optionsTrackHeight)/2,
+		W: setting.bbox.W, H: optionsTrackHeight,
+	}
+	optionW := setting.track.W / int32(len(setting.options))
+	for i := range setting.optionBBoxes {
+		setting.optionBBoxes[i] = sdl.Rect{
+			X: setting.track.X + optionW*int32(i), Y: setting.track.Y,
+			W: optionW, H: setting.track.H,
+		}
+	}
+}
+
+func (setting *TogglesSetting) updateTextures() {
+	setting.destroyTextures()
+	setting.textures = make([]*pkg.StringTexture, len(setting.options))
+	setting.activeTextures = make([]*pkg.StringTexture, len(setting.options))
+	for i, option := range setting.options {
+		setting.textures[i] = pkg.NewStringTexture(setting.lastRenderer, setting.font, option, optionsTextColor)
+		setting.activeTextures[i] = pkg.NewStringTexture(setting.lastRenderer, setting.font, option, optionsActiveTextColor)
+	}
+}
+
+func (setting *TogglesSetting) destroyTextures() {
+	for i := range setting.textures {
+		setting.textures[i].Destroy()
+		setting.activeTextures[i].Destroy()
+	}
+	setting.textures, setting.activeTextures = nil, nil
+}
+
+func (setting TogglesSetting) IsToggled(option int) bool {
+	return setting.toggled[option]
+}
+
+func (setting *TogglesSetting) SetToggled(option int, toggled bool) {
+	if setting.toggled[option] == toggled {
+		return
+	}
+	setting.toggled[option] = toggled
+	setting.onToggled(option, toggled)
+}
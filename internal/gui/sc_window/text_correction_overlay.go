@@ -0,0 +1,305 @@
+package scWindow
+
+import (
+	"time"
+	"unicode"
+
+	"github.com/Wine1y/trigat/assets"
+	"github.com/Wine1y/trigat/config"
+	"github.com/Wine1y/trigat/internal/gui"
+	"github.com/Wine1y/trigat/pkg"
+	"github.com/veandco/go-sdl2/sdl"
+	"github.com/veandco/go-sdl2/ttf"
+)
+
+const correctionFontSize int = 14
+const correctionPadding int32 = 8
+const correctionMargin int32 = 8
+const correctionMinWidth int32 = 320
+const correctionCornerRadius int32 = 6
+const correctionCursorAnimationDuration time.Duration = time.Millisecond * 1250
+
+const recognizingTextTitle string = "Recognizing text..."
+const recognizedTextTitle string = "Copied to the clipboard, edit the text and press Ctrl+Enter to copy it again"
+const noTextTitle string = "No text found"
+
+var correctionBackgroundColor = sdl.Color{R: 0, G: 0, B: 0, A: 200}
+var correctionTitleColor = sdl.Color{R: 170, G: 170, B: 170, A: 255}
+var correctionTextColor = sdl.Color{R: 255, G: 255, B: 255, A: 255}
+var correctionCursorColor = sdl.Color{R: 255, G: 255, B: 255, A: 255}
+
+type textCorrectionOverlay struct {
+	ren             *sdl.Renderer
+	font            *ttf.Font
+	titleTexture    *pkg.StringTexture
+	paragraph       *pkg.TextParagraph
+	anchor          sdl.Rect
+	bbox            sdl.Rect
+	cursorPos       int
+	cursorAnimation *pkg.Animation
+	recognizing     bool
+	closed          bool
+	onCopy          func(text string)
+	onClosed        func()
+}
+
+func newTextCorrectionOverlay(
+	ren *sdl.Renderer,
+	anchor *sdl.Rect,
+	onCopy func(text string),
+	onClosed func(),
+) *textCorrectionOverlay {
+	font := assets.GetAppFont(correctionFontSize)
+	overlay := textCorrectionOverlay{
+		ren:         ren,
+		font:        font,
+		paragraph:   pkg.NewTextParagraph(sdl.Point{}, correctionTextColor, font, 0),
+		anchor:      *anchor,
+		recognizing: true,
+		cursorAnimation: pkg.NewLinearAnimation(
+			255, 0,
+			int(config.GetAppFPS()), correctionCursorAnimationDuration,
+			0, true,
+		),
+		onCopy:   onCopy,
+		onClosed: onClosed,
+	}
+	overlay.setTitle(recognizingTextTitle)
+	return &overlay
+}
+
+func (overlay *textCorrectionOverlay) setText(text string) {
+	overlay.recognizing = false
+	runes := make([]rune, 0, len(text))
+	for _, rn := range text {
+		if rn == '\n' || unicode.IsGraphic(rn) {
+			runes = append(runes, rn)
+		}
+	}
+	if len(runes) == 0 {
+		overlay.setTitle(noTextTitle)
+		return
+	}
+	overlay.paragraph.InsertRunes(overlay.ren, 0, runes...)
+	overlay.cursorPos = len(runes)
+	overlay.setTitle(recognizedTextTitle)
+}
+
+func (overlay *textCorrectionOverlay) setTitle(title string) {
+	if overlay.titleTexture != nil {
+		overlay.titleTexture.Destroy()
+	}
+	overlay.titleTexture = pkg.NewStringTexture(overlay.ren, overlay.font, title, correctionTitleColor)
+	overlay.updateLayout()
+}
+
+func (overlay *textCorrectionOverlay) setAnchor(anchor sdl.Rect) {
+	if anchor == overlay.anchor {
+		return
+	}
+	overlay.anchor = anchor
+	overlay.updateLayout()
+}
+
+func (overlay *textCorrectionOverlay) updateLayout() {
+	vp := overlay.ren.GetViewport()
+	textBBox := overlay.paragraph.GetBBox()
+	contentW := pkg.Max(correctionMinWidth, pkg.Max(overlay.titleTexture.TextWidth, textBBox.W))
+	overlay.bbox.W = pkg.Min(contentW+correctionPadding*2, vp.W-correctionMargin*2)
+	overlay.bbox.H = overlay.titleTexture.TextHeight + textBBox.H + correctionMargin + correctionPadding*2
+	overlay.bbox.H = pkg.Min(overlay.bbox.H, vp.H-correctionMargin*2)
+
+	//Overlay is placed under the selection, above it if there is no space or at the bottom of the screen
+	overlay.bbox.X = pkg.Clamp(correctionMargin, overlay.anchor.X, vp.W-overlay.bbox.W-correctionMargin)
+	switch {
+	case overlay.anchor.Y+overlay.anchor.H+correctionMargin+overlay.bbox.H <= vp.H-correctionMargin:
+		overlay.bbox.Y = overlay.anchor.Y + overlay.anchor.H + correctionMargin
+	case overlay.anchor.Y-correctionMargin-overlay.bbox.H >= correctionMargin:
+		overlay.bbox.Y = overlay.anchor.Y - correctionMargin - overlay.bbox.H
+	default:
+		overlay.bbox.Y = vp.H - overlay.bbox.H - correctionMargin
+	}
+
+	overlay.paragraph.TextStart = sdl.Point{
+		X: overlay.bbox.X + correctionPadding,
+		Y: overlay.bbox.Y + correctionPadding + overlay.titleTexture.TextHeight + correctionMargin,
+	}
+}
+
+func (overlay *textCorrectionOverlay) draw(ren *sdl.Renderer) {
+	if overlay.closed {
+		return
+	}
+	pkg.DrawRoundedFilledRectangle(ren, &overlay.bbox, correctionCornerRadius, correctionBackgroundColor)
+	ren.SetClipRect(&overlay.bbox)
+	overlay.titleTexture.Draw(ren, &sdl.Point{
+		X: overlay.bbox.X + correctionPadding,
+		Y: overlay.bbox.Y + correctionPadding,
+	})
+	par := overlay.paragraph
+	if par.StringTexture != nil {
+		par.StringTexture.Draw(ren, &par.TextStart)
+	}
+	if !overlay.recognizing {
+		xOffset, yOffset := par.GetOffsetByPosition(overlay.cursorPos)
+		pkg.DrawThickLine(
+			ren,
+			&sdl.Point{X: par.TextStart.X + xOffset, Y: par.TextStart.Y + yOffset},
+			&sdl.Point{X: par.TextStart.X + xOffset, Y: par.TextStart.Y + yOffset + pkg.FontHeight(par.Font)},
+			1,
+			sdl.Color{
+				R: correctionCursorColor.R, G: correctionCursorColor.G, B: correctionCursorColor.B,
+				A: uint8(overlay.cursorAnimation.CurrentValue()),
+			},
+		)
+	}
+	ren.SetClipRect(nil)
+}
+
+func (overlay *textCorrectionOverlay) callbacks() *gui.WindowCallbackSet {
+	callbacks := gui.NewWindowCallbackSet()
+
+	callbacks.MouseDown = append(callbacks.MouseDown, func(button uint8, x, y int32) bool {
+		if overlay.closed {
+			return false
+		}
+		click := sdl.Point{X: x, Y: y}
+		if !click.InRect(&overlay.bbox) {
+			overlay.close()
+			return true
+		}
+		if !overlay.recognizing && button == sdl.BUTTON_LEFT {
+			par := overlay.paragraph
+			overlay.moveCursor(par.GetPositionByOffset(x-par.TextStart.X, y-par.TextStart.Y))
+		}
+		return true
+	})
+
+	callbacks.MouseMove = append(callbacks.MouseMove, func(x, y int32) bool {
+		if overlay.closed {
+			return false
+		}
+		move := sdl.Point{X: x, Y: y}
+		if move.InRect(&overlay.bbox) && !overlay.recognizing {
+			sdl.SetCursor(gui.IBeamCursor)
+		} else {
+			sdl.SetCursor(gui.ArrowCursor)
+		}
+		return true
+	})
+
+	callbacks.MouseUp = append(callbacks.MouseUp, func(button uint8, x, y int32) bool {
+		return !overlay.closed
+	})
+
+	callbacks.MouseWheel = append(callbacks.MouseWheel, func(x, y int32) bool {
+		return !overlay.closed
+	})
+
+	callbacks.TextInput = append(callbacks.TextInput, func(text string) bool {
+		if overlay.closed {
+			return false
+		}
+		if runes := pkg.InputRunes(text); !overlay.recognizing && len(runes) > 0 {
+			overlay.insertRunes(runes...)
+		}
+		return true
+	})
+
+	callbacks.TextEditing = append(callbacks.TextEditing, func(text string, cursor, selectionLength int32) bool {
+		return !overlay.closed
+	})
+
+	callbacks.KeyDown = append(callbacks.KeyDown, func(keysym sdl.Keysym) bool {
+		if overlay.closed {
+			return false
+		}
+		if keysym.Sym == sdl.K_ESCAPE {
+			overlay.close()
+			return true
+		}
+		if overlay.recognizing {
+			return true
+		}
+		par := overlay.paragraph
+		ctrlPressed := keysym.Mod&sdl.KMOD_CTRL != 0
+		switch {
+		case (keysym.Sym == sdl.K_RETURN || keysym.Sym == sdl.K_KP_ENTER) && ctrlPressed:
+			overlay.onCopy(string(par.Text))
+			overlay.close()
+		case keysym.Sym == sdl.K_RETURN || keysym.Sym == sdl.K_KP_ENTER:
+			overlay.insertRunes('\n')
+		case keysym.Sym == sdl.K_BACKSPACE && overlay.cursorPos > 0:
+			overlay.popRunes(overlay.cursorPos-1, overlay.cursorPos)
+			overlay.moveCursor(overlay.cursorPos - 1)
+		case keysym.Sym == sdl.K_DELETE && overlay.cursorPos < len(par.Text):
+			overlay.popRunes(overlay.cursorPos, overlay.cursorPos+1)
+		case keysym.Sym == sdl.K_v && ctrlPressed:
+			if text, err := sdl.GetClipboardText(); err == nil {
+				overlay.insertRunes([]rune(text)...)
+			}
+		case len(par.Text) == 0:
+		case keysym.Sym == sdl.K_LEFT && ctrlPressed:
+			overlay.moveCursor(par.ClosestLeftWordPos(overlay.cursorPos))
+		case keysym.Sym == sdl.K_RIGHT && ctrlPressed:
+			overlay.moveCursor(par.ClosestRightWordPos(overlay.cursorPos))
+		case keysym.Sym == sdl.K_LEFT:
+			overlay.moveCursor(overlay.cursorPos - 1)
+		case keysym.Sym == sdl.K_RIGHT:
+			overlay.moveCursor(overlay.cursorPos + 1)
+		case keysym.Sym == sdl.K_UP:
+			overlay.moveCursor(par.UpperLinePos(overlay.cursorPos))
+		case keysym.Sym == sdl.K_DOWN:
+			overlay.moveCursor(par.LowerLinePos(overlay.cursorPos))
+		case keysym.Sym == sdl.K_HOME:
+			overlay.moveCursor(par.GetLinesBoundaries()[par.GetLineNumber(overlay.cursorPos)][0])
+		case keysym.Sym == sdl.K_END:
+			overlay.moveCursor(par.GetLinesBoundaries()[par.GetLineNumber(overlay.cursorPos)][1])
+		}
+		return true
+	})
+
+	callbacks.KeyUp = append(callbacks.KeyUp, func(keysym sdl.Keysym) bool {
+		return !overlay.closed
+	})
+
+	callbacks.Quit = append(callbacks.Quit, func() bool {
+		overlay.destroy()
+		return false
+	})
+	return callbacks
+}
+
+func (overlay *textCorrectionOverlay) moveCursor(newPos int) {
+	overlay.cursorPos = pkg.Clamp(0, newPos, len(overlay.paragraph.Text))
+	overlay.cursorAnimation.ReStart()
+}
+
+func (overlay *textCorrectionOverlay) insertRunes(runes ...rune) {
+	overlay.paragraph.InsertRunes(overlay.ren, overlay.cursorPos, runes...)
+	overlay.moveCursor(overlay.cursorPos + len(runes))
+	overlay.updateLayout()
+}
+
+func (overlay *textCorrectionOverlay) popRunes(startPos, endPos int) {
+	overlay.paragraph.PopRunes(overlay.ren, startPos, endPos)
+	overlay.updateLayout()
+}
+
+func (overlay *textCorrectionOverlay) close() {
+	overlay.closed = true
+	sdl.SetCursor(gui.ArrowCursor)
+	overlay.onClosed()
+}
+
+func (overlay *textCorrectionOverlay) destroy() {
+	if overlay.titleTexture == nil {
+		return
+	}
+	overlay.titleTexture.Destroy()
+	overlay.titleTexture = nil
+	if overlay.paragraph.StringTexture != nil {
+		overlay.paragraph.StringTexture.Destroy()
+	}
+	pkg.CloseFont(overlay.font)
+}
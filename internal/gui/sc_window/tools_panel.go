@@ -1,10 +1,13 @@
 package scWindow
 
 import (
+	"image"
 	"time"
 
+	"github.com/Wine1y/trigat/assets"
 	"github.com/Wine1y/trigat/internal/gui"
 	editTools "github.com/Wine1y/trigat/internal/gui/sc_window/edit_tools"
+	"github.com/Wine1y/trigat/internal/gui/sc_window/settings"
 	"github.com/Wine1y/trigat/pkg"
 	"github.com/veandco/go-sdl2/sdl"
 )
@@ -38,26 +41,34 @@ type ToolsPanel struct {
 	hoveredTool       *toolMeta
 	hoveredAt         time.Time
 	cropTool          editTools.ScreenshotCropTool
+	redactTool        *editTools.RedactTool
+	screenshot        *image.RGBA
 	actionsQueue      *editTools.ActionsQueue
 	onNewToolSelected func(tool editTools.ScreenshotEditTool)
 	handCursorSet     bool
 	panelRect         *sdl.Rect
+	view              *viewTransform
 }
 
+// Tools draw on the screenshot canvas, the renderer should target it while they are created
 func NewToolsPanel(
 	ren *sdl.Renderer,
+	screenshot *image.RGBA,
+	view *viewTransform,
 	onNewToolSelected func(tool editTools.ScreenshotEditTool),
-	saveCallback, copyCallback, searchCallback func(),
+	saveCallback, copyCallback, searchCallback, uploadCallback, extractTextCallback, decodeCodesCallback func(),
 ) *ToolsPanel {
-	selectionTool := editTools.NewSelectionTool(ren, saveCallback, copyCallback, searchCallback)
-	tools := []editTools.ScreenshotEditTool{
-		selectionTool,
+	selectionTool := editTools.NewSelectionTool(ren, saveCallback, copyCallback, searchCallback, uploadCallback, extractTextCallback, decodeCodesCallback)
+	redactTool := editTools.NewRedactTool(ren, screenshot)
+	annotationTools := []editTools.ScreenshotEditTool{
 		editTools.NewPaintTool(),
 		editTools.NewLinesTool(),
 		editTools.NewRectsTool(),
 		editTools.NewTextTool(ren),
-		editTools.NewPipetteTool(ren),
 	}
+	tools := []editTools.ScreenshotEditTool{selectionTool, redactTool}
+	tools = append(tools, annotationTools...)
+	tools = append(tools, editTools.NewEraserTool(annotationTools), editTools.NewPipetteTool(ren))
 	metas := make([]*toolMeta, len(tools))
 	for i, tool := range tools {
 		meta := newToolMeta(tool, ren)
@@ -67,13 +78,15 @@ func NewToolsPanel(
 		tools:             metas,
 		actionsQueue:      editTools.NewActionsQueue(),
 		cropTool:          selectionTool,
+		redactTool:        redactTool,
+		screenshot:        screenshot,
+		view:              view,
 		onNewToolSelected: onNewToolSelected,
 	}
 	if len(metas) > 0 {
 		panel.currentTool = metas[0]
 	}
-	vp := ren.GetViewport()
-	panel.resizePanel(vp.W, vp.H)
+	panel.resizePanel(view.windowW, view.windowH)
 	return &panel
 }
 
@@ -87,6 +100,15 @@ func (panel ToolsPanel) DrawToolsState(ren *sdl.Renderer) {
 	}
 }
 
+// Controls are drawn after the canvas is placed into the window
+func (panel ToolsPanel) DrawToolsControls(ren *sdl.Renderer) {
+	for _, meta := range panel.tools {
+		if controlsTool, hasControls := meta.tool.(editTools.ScreenshotControlsTool); hasControls {
+			controlsTool.RenderControls(ren, panel.view.toWindow)
+		}
+	}
+}
+
 func (panel ToolsPanel) RenderScreenshot(ren *sdl.Renderer) {
 	for _, meta := range panel.tools {
 		meta.tool.RenderScreenshot(ren)
@@ -100,6 +122,23 @@ func (panel ToolsPanel) CropScreenshot(surface *sdl.Surface) *sdl.Surface {
 	return surface
 }
 
+func (panel ToolsPanel) CropRect(ren *sdl.Renderer) *sdl.Rect {
+	if panel.cropTool != nil {
+		return panel.cropTool.CropRect()
+	}
+	vp := ren.GetViewport()
+	return &vp
+}
+
+func (panel *ToolsPanel) SuggestRedactions(suggestions []editTools.RedactionSuggestion) {
+	for _, meta := range panel.tools {
+		if meta.tool == editTools.ScreenshotEditTool(panel.redactTool) && panel.currentTool != meta {
+			panel.setActiveTool(meta)
+		}
+	}
+	panel.redactTool.SetSuggestions(suggestions)
+}
+
 func (panel *ToolsPanel) UndoLastAction() {
 	if panel.actionsQueue.CanUndo() {
 		panel.actionsQueue.Undo()
@@ -136,6 +175,11 @@ func (panel *ToolsPanel) drawTool(ren *sdl.Renderer, meta *toolMeta) {
 			for _, setting := range toolSettings {
 				setting.Render(ren)
 			}
+			for _, setting := range toolSettings {
+				if overlaySetting, isOverlay := setting.(settings.OverlaySetting); isOverlay {
+					overlaySetting.RenderOverlay(ren)
+				}
+			}
 		}
 	}
 	if meta == panel.currentTool {
@@ -164,6 +208,16 @@ func (panel *ToolsPanel) drawTool(ren *sdl.Renderer, meta *toolMeta) {
 func (panel *ToolsPanel) SetToolsCallbacks(callbacks *gui.WindowCallbackSet) {
 	callbacks.MouseDown = append(callbacks.MouseDown, func(button uint8, x, y int32) bool {
 		click := sdl.Point{X: x, Y: y}
+		if button == sdl.BUTTON_LEFT && sdl.GetModState()&sdl.KMOD_ALT != 0 && !panel.isPanelArea(click) {
+			//Alt+click samples the screenshot pixel as the color of the current tool
+			canvasX, canvasY := panel.view.toCanvas(x, y)
+			coloredTool, isColored := panel.currentTool.tool.(editTools.ScreenshotColoredTool)
+			if isColored && image.Pt(int(canvasX), int(canvasY)).In(panel.screenshot.Rect) {
+				pixel := panel.screenshot.RGBAAt(int(canvasX), int(canvasY))
+				coloredTool.SetToolColor(sdl.Color{R: pixel.R, G: pixel.G, B: pixel.B, A: pixel.A})
+				return true
+			}
+		}
 		for _, meta := range panel.tools {
 			if click.InRect(&meta.toolBBox) && button == sdl.BUTTON_LEFT {
 				panel.setActiveTool(meta)
@@ -183,6 +237,8 @@ func (panel *ToolsPanel) SetToolsCallbacks(callbacks *gui.WindowCallbackSet) {
 			if move.InRect(&meta.toolBBox) {
 				if panel.hoveredTool != meta {
 					panel.hoveredAt = time.Now()
+					//Settings appear without any input, so a frame is drawn once the delay passes
+					time.AfterFunc(panelSettingsShowDelay, gui.RequestRedraw)
 				}
 				panel.hoveredTool = meta
 				sdl.SetCursor(gui.HandCursor)
@@ -194,7 +250,9 @@ func (panel *ToolsPanel) SetToolsCallbacks(callbacks *gui.WindowCallbackSet) {
 			if panel.handCursorSet {
 				sdl.SetCursor(gui.ArrowCursor)
 			}
-			if move.InRect(&panel.hoveredTool.settingsBBox) && time.Since(panel.hoveredAt) >= panelSettingsShowDelay {
+			//Focused input keeps the settings open until it's unfocused by a click outside of it
+			isKept := move.InRect(&panel.hoveredTool.settingsBBox) || panel.hasFocusedSetting()
+			if isKept && time.Since(panel.hoveredAt) >= panelSettingsShowDelay {
 				return false
 			}
 			panel.hoveredTool = nil
@@ -223,14 +281,42 @@ func (panel *ToolsPanel) SetToolsCallbacks(callbacks *gui.WindowCallbackSet) {
 		return false
 	})
 
-	if panel.hoveredTool != nil && time.Since(panel.hoveredAt) >= panelSettingsShowDelay {
-		for _, setting := range panel.hoveredTool.tool.ToolSettings() {
+	if settingsTool := panel.ShownSettingsTool(); settingsTool != nil {
+		for _, setting := range settingsTool.ToolSettings() {
 			callbacks.Append(setting.SettingCallbacks())
 		}
 	}
 	if panel.currentTool != nil {
-		callbacks.Append(panel.currentTool.tool.ToolCallbacks(panel.actionsQueue))
+		if controlsTool, hasControls := panel.currentTool.tool.(editTools.ScreenshotControlsTool); hasControls {
+			callbacks.Append(controlsTool.ControlsCallbacks())
+		}
+		//Tools work in screenshot coordinates, independent of the zoom and panning
+		callbacks.Append(panel.currentTool.tool.ToolCallbacks(panel.actionsQueue).MapMouse(panel.view.toCanvas))
+	}
+}
+
+// Returns the tool whose settings are shown under the panel, or nil if none are shown
+func (panel ToolsPanel) ShownSettingsTool() editTools.ScreenshotEditTool {
+	if panel.hoveredTool == nil || time.Since(panel.hoveredAt) < panelSettingsShowDelay {
+		return nil
+	}
+	return panel.hoveredTool.tool
+}
+
+func (panel ToolsPanel) hasFocusedSetting() bool {
+	for _, setting := range panel.hoveredTool.tool.ToolSettings() {
+		if focusable, isFocusable := setting.(settings.FocusableSetting); isFocusable && focusable.IsFocused() {
+			return true
+		}
+	}
+	return false
+}
+
+func (panel ToolsPanel) isPanelArea(point sdl.Point) bool {
+	if point.InRect(panel.panelRect) {
+		return true
 	}
+	return panel.hoveredTool != nil && point.InRect(&panel.hoveredTool.settingsBBox)
 }
 
 func (panel *ToolsPanel) setActiveTool(toolMeta *toolMeta) {
@@ -293,9 +379,11 @@ type toolMeta struct {
 }
 
 func newToolMeta(tool editTools.ScreenshotEditTool, ren *sdl.Renderer) toolMeta {
+	icon := assets.GetScaledIcon(tool.ToolIcon())
+	defer icon.Free()
 	return toolMeta{
 		tool:     tool,
 		iconBBox: sdl.Rect{},
-		texture:  pkg.CreateTextureFromSurface(ren, tool.ToolIcon()),
+		texture:  pkg.CreateTextureFromSurface(ren, icon),
 	}
 }
@@ -0,0 +1,195 @@
+package scWindow
+
+import (
+	"math"
+
+	"github.com/Wine1y/trigat/internal/gui"
+	"github.com/Wine1y/trigat/pkg"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+const viewMinScale float64 = 0.1
+const viewMaxScale float64 = 16
+const viewZoomStep float64 = 1.25
+
+var viewBackgroundColor = sdl.Color{R: 30, G: 30, B: 30, A: 255}
+
+// viewTransform maps the screenshot canvas onto the window, canvas point p is shown at p*scale+offset.
+// Canvas is measured in screenshot pixels and the window in UI units
+type viewTransform struct {
+	scale            float64
+	offsetX, offsetY float64
+	canvasW, canvasH int32
+	windowW, windowH int32
+	uiScale          float64
+	isPanning        bool
+	panningButton    uint8
+	lastPanPoint     sdl.Point
+	lastMousePos     sdl.Point
+	spaceHeld        bool
+}
+
+func newViewTransform(canvasW, canvasH, windowW, windowH int32, uiScale float64) *viewTransform {
+	view := viewTransform{
+		scale:   1,
+		canvasW: canvasW, canvasH: canvasH,
+		windowW: windowW, windowH: windowH,
+		uiScale: uiScale,
+	}
+	view.fit()
+	return &view
+}
+
+// Returns the window area the canvas is drawn to
+func (view viewTransform) canvasRect() sdl.Rect {
+	return sdl.Rect{
+		X: int32(math.Round(view.offsetX)), Y: int32(math.Round(view.offsetY)),
+		W: int32(math.Round(float64(view.canvasW) * view.scale)), H: int32(math.Round(float64(view.canvasH) * view.scale)),
+	}
+}
+
+// Converts window coordinates into canvas ones, the result may be outside of the canvas
+func (view viewTransform) toCanvas(x, y int32) (int32, int32) {
+	rect := view.canvasRect()
+	return int32(math.Floor(float64(x-rect.X) * float64(view.canvasW) / float64(rect.W))),
+		int32(math.Floor(float64(y-rect.Y) * float64(view.canvasH) / float64(rect.H)))
+}
+
+// Converts canvas coordinates into window ones
+func (view viewTransform) toWindow(x, y int32) (int32, int32) {
+	return int32(math.Round(float64(x)*view.scale + view.offsetX)), int32(math.Round(float64(y)*view.scale + view.offsetY))
+}
+
+func (view viewTransform) toWindowRect(rect *sdl.Rect) sdl.Rect {
+	return sdl.Rect{
+		X: int32(math.Round(float64(rect.X)*view.scale + view.offsetX)),
+		Y: int32(math.Round(float64(rect.Y)*view.scale + view.offsetY)),
+		W: int32(math.Round(float64(rect.W) * view.scale)),
+		H: int32(math.Round(float64(rect.H) * view.scale)),
+	}
+}
+
+// Changes the scale keeping the canvas point under the window point in place
+func (view *viewTransform) zoomAt(x, y int32, scale float64) {
+	scale = pkg.Clamp(viewMinScale, scale, viewMaxScale)
+	canvasX := (float64(x) - view.offsetX) / view.scale
+	canvasY := (float64(y) - view.offsetY) / view.scale
+	view.scale = scale
+	view.offsetX = float64(x) - canvasX*scale
+	view.offsetY = float64(y) - canvasY*scale
+	view.clampOffset()
+}
+
+func (view *viewTransform) pan(dx, dy int32) {
+	view.offsetX += float64(dx)
+	view.offsetY += float64(dy)
+	view.clampOffset()
+}
+
+// Shows the whole canvas, as large as the window allows
+func (view *viewTransform) fit() {
+	view.scale = pkg.Clamp(
+		viewMinScale,
+		pkg.Min(float64(view.windowW)/float64(view.canvasW), float64(view.windowH)/float64(view.canvasH)),
+		viewMaxScale,
+	)
+	view.clampOffset()
+}
+
+// Shows one screenshot pixel on every display pixel, keeping the center of the window in place
+func (view *viewTransform) actualSize() {
+	view.zoomAt(view.windowW/2, view.windowH/2, 1/view.uiScale)
+}
+
+func (view *viewTransform) resize(windowW, windowH int32, uiScale float64) {
+	view.windowW, view.windowH = windowW, windowH
+	view.uiScale = uiScale
+	view.clampOffset()
+}
+
+// Canvas smaller than the window is centered, larger one can't be moved away from the window edges
+func (view *viewTransform) clampOffset() {
+	view.offsetX = clampViewOffset(view.offsetX, float64(view.canvasW)*view.scale, float64(view.windowW))
+	view.offsetY = clampViewOffset(view.offsetY, float64(view.canvasH)*view.scale, float64(view.windowH))
+}
+
+func clampViewOffset(offset, canvasSize, windowSize float64) float64 {
+	if math.Round(canvasSize) <= windowSize {
+		return (windowSize - canvasSize) / 2
+	}
+	return pkg.Clamp(windowSize-canvasSize, offset, 0)
+}
+
+func (view *viewTransform) draw(ren *sdl.Renderer, canvas *sdl.Texture) {
+	ren.SetDrawColor(viewBackgroundColor.R, viewBackgroundColor.G, viewBackgroundColor.B, viewBackgroundColor.A)
+	ren.Clear()
+	rect := view.canvasRect()
+	ren.Copy(canvas, nil, &rect)
+}
+
+// Space is ignored while isTyping reports that it's typed as text
+func (view *viewTransform) callbacks(isTyping func() bool) *gui.WindowCallbackSet {
+	callbacks := gui.NewWindowCallbackSet()
+
+	callbacks.MouseWheel = append(callbacks.MouseWheel, func(x, y int32) bool {
+		if sdl.GetModState()&sdl.KMOD_CTRL == 0 || y == 0 {
+			return false
+		}
+		view.zoomAt(view.lastMousePos.X, view.lastMousePos.Y, view.scale*math.Pow(viewZoomStep, float64(y)))
+		return true
+	})
+
+	callbacks.MouseDown = append(callbacks.MouseDown, func(button uint8, x, y int32) bool {
+		if view.isPanning || !(button == sdl.BUTTON_MIDDLE || (button == sdl.BUTTON_LEFT && view.spaceHeld)) {
+			return view.isPanning
+		}
+		view.isPanning = true
+		view.panningButton = button
+		view.lastPanPoint = sdl.Point{X: x, Y: y}
+		sdl.SetCursor(gui.SizeAllCursor)
+		return true
+	})
+
+	callbacks.MouseMove = append(callbacks.MouseMove, func(x, y int32) bool {
+		view.lastMousePos = sdl.Point{X: x, Y: y}
+		if !view.isPanning {
+			return false
+		}
+		view.pan(x-view.lastPanPoint.X, y-view.lastPanPoint.Y)
+		view.lastPanPoint = sdl.Point{X: x, Y: y}
+		return true
+	})
+
+	callbacks.MouseUp = append(callbacks.MouseUp, func(button uint8, x, y int32) bool {
+		if !view.isPanning {
+			return false
+		}
+		if button == view.panningButton {
+			view.isPanning = false
+			sdl.SetCursor(gui.ArrowCursor)
+		}
+		return true
+	})
+
+	callbacks.KeyDown = append(callbacks.KeyDown, func(keysym sdl.Keysym) bool {
+		switch {
+		case keysym.Sym == sdl.K_SPACE && !isTyping():
+			view.spaceHeld = true
+		case (keysym.Sym == sdl.K_0 || keysym.Sym == sdl.K_KP_0) && keysym.Mod&sdl.KMOD_CTRL != 0:
+			view.fit()
+			return true
+		case (keysym.Sym == sdl.K_1 || keysym.Sym == sdl.K_KP_1) && keysym.Mod&sdl.KMOD_CTRL != 0:
+			view.actualSize()
+			return true
+		}
+		return false
+	})
+
+	callbacks.KeyUp = append(callbacks.KeyUp, func(keysym sdl.Keysym) bool {
+		if keysym.Sym == sdl.K_SPACE {
+			view.spaceHeld = false
+		}
+		return false
+	})
+	return callbacks
+}
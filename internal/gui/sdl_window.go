@@ -1,11 +1,13 @@
 package gui
 
 import (
+	"bytes"
+	"math"
 	"runtime"
 	"time"
-	"unicode/utf8"
 
 	"github.com/Wine1y/trigat/config"
+	"github.com/Wine1y/trigat/pkg"
 	"github.com/veandco/go-sdl2/sdl"
 	"github.com/veandco/go-sdl2/ttf"
 )
@@ -17,6 +19,9 @@ var ArrowCursor *sdl.Cursor = nil
 var HandCursor *sdl.Cursor = nil
 var IBeamCursor *sdl.Cursor = nil
 var SizeAllCursor *sdl.Cursor = nil
+var SizeWECursor *sdl.Cursor = nil
+
+var pushedKeys = make(chan sdl.Keycode, 8)
 
 type SDLWindow struct {
 	win         *sdl.Window
@@ -24,6 +29,9 @@ type SDLWindow struct {
 	shouldClose bool
 	render      func(*sdl.Renderer)
 	callbacks   func() *WindowCallbackSet
+	pixelScale  float64
+	uiScale     float64
+	needsRedraw bool
 }
 
 func NewSDLWindow(
@@ -35,6 +43,8 @@ func NewSDLWindow(
 ) *SDLWindow {
 	runtime.LockOSThread()
 
+	//Candidate lists of input methods are drawn by the system
+	sdl.SetHint(sdl.HINT_IME_SHOW_UI, "1")
 	if err := sdl.Init(sdl.INIT_VIDEO); err != nil {
 		panic(err)
 	}
@@ -56,7 +66,7 @@ func NewSDLWindow(
 		}
 	}
 
-	win, err := sdl.CreateWindow(title, x, y, width, height, flags|sdl.WINDOW_HIDDEN)
+	win, err := sdl.CreateWindow(title, x, y, width, height, flags|sdl.WINDOW_HIDDEN|sdl.WINDOW_ALLOW_HIGHDPI)
 	if err != nil {
 		panic(err)
 	}
@@ -64,28 +74,66 @@ func NewSDLWindow(
 	if err != nil {
 		panic(err)
 	}
-	return &SDLWindow{
-		win:       win,
-		ren:       ren,
-		render:    renderCallback,
-		callbacks: callbacks,
+	window := SDLWindow{
+		win:         win,
+		ren:         ren,
+		render:      renderCallback,
+		callbacks:   callbacks,
+		needsRedraw: true,
+	}
+	window.updateScale()
+	return &window
+}
+
+// Everything drawn to the window and all mouse coordinates are in UI units, one unit is uiScale drawable pixels.
+// Render targets other than the window are drawn in pixels, SDL resets the render scale for them
+func (window *SDLWindow) updateScale() {
+	windowW, _ := window.win.GetSize()
+	drawableW, _, err := window.ren.GetOutputSize()
+	if err != nil {
+		panic(err)
+	}
+	window.pixelScale = float64(drawableW) / float64(windowW)
+	window.uiScale = window.pixelScale
+	//Without a separate drawable size the display scale is only known to the system
+	if window.pixelScale == 1 {
+		if displayIndex, err := window.win.GetDisplayIndex(); err == nil {
+			window.uiScale = systemUIScale(displayIndex)
+		}
+	}
+	if err := window.ren.SetScale(float32(window.uiScale), float32(window.uiScale)); err != nil {
+		panic(err)
+	}
+}
+
+// Converts window coordinates of events into UI units
+func (window SDLWindow) toUI(x, y int32) (int32, int32) {
+	scale := window.pixelScale / window.uiScale
+	return int32(math.Floor(float64(x) * scale)), int32(math.Floor(float64(y) * scale))
+}
+
+// Converts a rect in UI units into window coordinates, the ones events come in
+func (window SDLWindow) FromUI(rect sdl.Rect) sdl.Rect {
+	scale := window.uiScale / window.pixelScale
+	return sdl.Rect{
+		X: int32(math.Round(float64(rect.X) * scale)), Y: int32(math.Round(float64(rect.Y) * scale)),
+		W: int32(math.Round(float64(rect.W) * scale)), H: int32(math.Round(float64(rect.H) * scale)),
 	}
 }
 
+// Frames are only drawn after events, redraw requests and while animations are running, at most once per frame time
 func (window *SDLWindow) StartMainLoop() {
-	lastTick := time.Now()
+	var lastFrame time.Time
 	for {
-		window.shouldClose = window.handleEvents()
+		untilNextFrame := time.Duration(MILLISECONDS_PER_FRAME)*time.Millisecond - time.Since(lastFrame)
+		window.shouldClose = window.handleEvents(untilNextFrame)
 		if window.shouldClose {
 			break
 		}
-		window.render(window.ren)
-		window.ren.Present()
-		msPassed := time.Since(lastTick).Milliseconds()
-		if msPassed < MILLISECONDS_PER_FRAME {
-			time.Sleep(time.Millisecond * time.Duration(MILLISECONDS_PER_FRAME-msPassed))
+		if window.needsRedraw && time.Since(lastFrame).Milliseconds() >= MILLISECONDS_PER_FRAME {
+			lastFrame = time.Now()
+			window.redraw()
 		}
-		lastTick = time.Now()
 	}
 	for _, cb := range window.callbacks().Quit {
 		if cb() {
@@ -98,30 +146,58 @@ func (window *SDLWindow) StartMainLoop() {
 	ttf.Quit()
 }
 
-func (window *SDLWindow) handleEvents() bool {
+func (window *SDLWindow) redraw() {
+	window.needsRedraw = false
+	window.render(window.ren)
+	window.ren.Present()
+	//Running animations advance once per frame, so they keep the window redrawing until they end
+	if pkg.TakeAnimationFrameRequest() {
+		window.needsRedraw = true
+	}
+}
+
+// Sleeps until an event comes, or until the next frame if a redraw is pending, then handles all queued events
+func (window *SDLWindow) handleEvents(untilNextFrame time.Duration) bool {
+	var event sdl.Event
+	switch {
+	case !window.needsRedraw:
+		event = sdl.WaitEvent()
+	case untilNextFrame > 0:
+		//Rounded up, so a wait shorter than a millisecond still sleeps instead of spinning
+		event = sdl.WaitEventTimeout(int((untilNextFrame + time.Millisecond - 1) / time.Millisecond))
+	default:
+		event = sdl.PollEvent()
+	}
+	if event == nil {
+		return window.shouldClose
+	}
+	window.needsRedraw = true
 	callbackSet := window.callbacks()
-	for event := sdl.PollEvent(); event != nil; event = sdl.PollEvent() {
+	for ; event != nil; event = sdl.PollEvent() {
 		switch event.GetType() {
 		case sdl.QUIT:
 			return true
 		case sdl.MOUSEBUTTONDOWN:
 			event := event.(*sdl.MouseButtonEvent)
+			x, y := window.toUI(event.X, event.Y)
 			for _, cb := range callbackSet.MouseDown {
-				if cb(event.Button, event.X, event.Y) {
+				if cb(event.Button, x, y) {
 					break
 				}
 			}
 		case sdl.MOUSEBUTTONUP:
 			event := event.(*sdl.MouseButtonEvent)
+			x, y := window.toUI(event.X, event.Y)
 			for _, cb := range callbackSet.MouseUp {
-				if cb(event.Button, event.X, event.Y) {
+				if cb(event.Button, x, y) {
 					break
 				}
 			}
 		case sdl.MOUSEMOTION:
 			event := event.(*sdl.MouseMotionEvent)
+			x, y := window.toUI(event.X, event.Y)
 			for _, cb := range callbackSet.MouseMove {
-				if cb(event.X, event.Y) {
+				if cb(x, y) {
 					break
 				}
 			}
@@ -148,17 +224,42 @@ func (window *SDLWindow) handleEvents() bool {
 			}
 		case sdl.TEXTINPUT:
 			event := event.(*sdl.TextInputEvent)
+			text := eventText(event.Text[:])
 			for _, cb := range callbackSet.TextInput {
-				rn, _ := utf8.DecodeRune(event.Text[:])
-				if cb(rn) {
+				if cb(text) {
+					break
+				}
+			}
+		case sdl.TEXTEDITING:
+			//Input method composition, cursor and selection are counted in characters of the text
+			event := event.(*sdl.TextEditingEvent)
+			text := eventText(event.Text[:])
+			for _, cb := range callbackSet.TextEditing {
+				if cb(text, event.Start, event.Length) {
 					break
 				}
 			}
+		case sdl.FINGERDOWN, sdl.FINGERMOTION, sdl.FINGERUP:
+			//Pen and finger pressure is normalized to 0-1, lifting it reports 0
+			event := event.(*sdl.TouchFingerEvent)
+			pressure := event.Pressure
+			if event.Type == sdl.FINGERUP {
+				pressure = 0
+			}
+			for _, cb := range callbackSet.TouchPressure {
+				if cb(pressure) {
+					break
+				}
+			}
+		case sdl.USEREVENT:
+			window.handlePushedKeys(callbackSet)
 		case sdl.WINDOWEVENT:
 			event := event.(*sdl.WindowEvent)
 			if event.Event == sdl.WINDOWEVENT_RESIZED {
+				window.updateScale()
+				vp := window.ren.GetViewport()
 				for _, cb := range callbackSet.SizeChange {
-					if cb(event.Data1, event.Data2) {
+					if cb(vp.W, vp.H) {
 						break
 					}
 				}
@@ -168,16 +269,61 @@ func (window *SDLWindow) handleEvents() bool {
 	return window.shouldClose
 }
 
+// Event text is null-terminated unless it fills the whole buffer
+func eventText(buffer []byte) string {
+	if end := bytes.IndexByte(buffer, 0); end != -1 {
+		buffer = buffer[:end]
+	}
+	return string(buffer)
+}
+
 func (window SDLWindow) Renderer() *sdl.Renderer {
 	return window.ren
 }
 
+// Returns the number of drawable pixels in one UI unit
+func (window SDLWindow) UIScale() float64 {
+	return window.uiScale
+}
+
 func (window SDLWindow) SDLWin() *sdl.Window {
 	return window.win
 }
 
 func (window *SDLWindow) Close() {
 	window.shouldClose = true
+	RequestRedraw()
+}
+
+// Delivers a key press to the window callbacks, it's safe to call from any goroutine.
+// Keys grabbed by global hotkeys never reach the window, so hotkeys forward them with this
+func PushKeyDown(key sdl.Keycode) {
+	select {
+	case pushedKeys <- key:
+		RequestRedraw()
+	default:
+	}
+}
+
+func (window *SDLWindow) handlePushedKeys(callbackSet *WindowCallbackSet) {
+	for {
+		select {
+		case key := <-pushedKeys:
+			keysym := sdl.Keysym{Sym: key, Mod: uint16(sdl.GetModState())}
+			for _, cb := range callbackSet.KeyDown {
+				if cb(keysym) {
+					break
+				}
+			}
+		default:
+			return
+		}
+	}
+}
+
+// Wakes the main loop up to draw a new frame, it's safe to call from any goroutine
+func RequestRedraw() {
+	sdl.PushEvent(&sdl.UserEvent{Type: sdl.USEREVENT})
 }
 
 func loadSystemCursors() {
@@ -185,4 +331,5 @@ func loadSystemCursors() {
 	HandCursor = sdl.CreateSystemCursor(sdl.SYSTEM_CURSOR_HAND)
 	IBeamCursor = sdl.CreateSystemCursor(sdl.SYSTEM_CURSOR_IBEAM)
 	SizeAllCursor = sdl.CreateSystemCursor(sdl.SYSTEM_CURSOR_SIZEALL)
+	SizeWECursor = sdl.CreateSystemCursor(sdl.SYSTEM_CURSOR_SIZEWE)
 }
@@ -0,0 +1,6 @@
+package gui
+
+// Retina displays are reported through the drawable size, other displays are not scaled
+func systemUIScale(displayIndex int) float64 {
+	return 1
+}
@@ -0,0 +1,15 @@
+package gui
+
+import (
+	"os"
+	"strconv"
+)
+
+// Wayland reports the scale through the drawable size, on X11 the GDK_SCALE set for desktop apps is used
+func systemUIScale(displayIndex int) float64 {
+	scale, err := strconv.ParseFloat(os.Getenv("GDK_SCALE"), 64)
+	if err != nil || scale < 1 {
+		return 1
+	}
+	return scale
+}
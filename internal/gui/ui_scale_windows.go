@@ -0,0 +1,14 @@
+package gui
+
+import "github.com/veandco/go-sdl2/sdl"
+
+const defaultDisplayDPI float32 = 96
+
+// The app is DPI aware, so windows are sized in pixels and the display scale comes from its DPI
+func systemUIScale(displayIndex int) float64 {
+	ddpi, _, _, err := sdl.GetDisplayDPI(displayIndex)
+	if err != nil || ddpi <= defaultDisplayDPI {
+		return 1
+	}
+	return float64(ddpi / defaultDisplayDPI)
+}
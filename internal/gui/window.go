@@ -12,28 +12,32 @@ type Window interface {
 }
 
 type WindowCallbackSet struct {
-	MouseDown  []func(button uint8, x, y int32) bool
-	MouseMove  []func(x, y int32) bool
-	MouseUp    []func(button uint8, x, y int32) bool
-	MouseWheel []func(x, y int32) bool
-	KeyDown    []func(keysym sdl.Keysym) bool
-	KeyUp      []func(keysym sdl.Keysym) bool
-	TextInput  []func(rn rune) bool
-	SizeChange []func(w, h int32) bool
-	Quit       []func() bool
+	MouseDown     []func(button uint8, x, y int32) bool
+	MouseMove     []func(x, y int32) bool
+	MouseUp       []func(button uint8, x, y int32) bool
+	MouseWheel    []func(x, y int32) bool
+	KeyDown       []func(keysym sdl.Keysym) bool
+	KeyUp         []func(keysym sdl.Keysym) bool
+	TextInput     []func(text string) bool
+	TextEditing   []func(text string, cursor, selectionLength int32) bool
+	SizeChange    []func(w, h int32) bool
+	TouchPressure []func(pressure float32) bool
+	Quit          []func() bool
 }
 
 func NewWindowCallbackSet() *WindowCallbackSet {
 	return &WindowCallbackSet{
-		MouseDown:  make([]func(button uint8, x, y int32) bool, 0),
-		MouseMove:  make([]func(x, y int32) bool, 0),
-		MouseUp:    make([]func(button uint8, x, y int32) bool, 0),
-		MouseWheel: make([]func(x, y int32) bool, 0),
-		KeyDown:    make([]func(keysym sdl.Keysym) bool, 0),
-		KeyUp:      make([]func(keysym sdl.Keysym) bool, 0),
-		TextInput:  make([]func(rn rune) bool, 0),
-		SizeChange: make([]func(w, h int32) bool, 0),
-		Quit:       make([]func() bool, 0),
+		MouseDown:     make([]func(button uint8, x, y int32) bool, 0),
+		MouseMove:     make([]func(x, y int32) bool, 0),
+		MouseUp:       make([]func(button uint8, x, y int32) bool, 0),
+		MouseWheel:    make([]func(x, y int32) bool, 0),
+		KeyDown:       make([]func(keysym sdl.Keysym) bool, 0),
+		KeyUp:         make([]func(keysym sdl.Keysym) bool, 0),
+		TextInput:     make([]func(text string) bool, 0),
+		TextEditing:   make([]func(text string, cursor, selectionLength int32) bool, 0),
+		SizeChange:    make([]func(w, h int32) bool, 0),
+		TouchPressure: make([]func(pressure float32) bool, 0),
+		Quit:          make([]func() bool, 0),
 	}
 }
 
@@ -45,7 +49,9 @@ func (set *WindowCallbackSet) Append(another *WindowCallbackSet) {
 	set.KeyDown = append(set.KeyDown, another.KeyDown...)
 	set.KeyUp = append(set.KeyUp, another.KeyUp...)
 	set.TextInput = append(set.TextInput, another.TextInput...)
+	set.TextEditing = append(set.TextEditing, another.TextEditing...)
 	set.SizeChange = append(set.SizeChange, another.SizeChange...)
+	set.TouchPressure = append(set.TouchPressure, another.TouchPressure...)
 	set.Quit = append(set.Quit, another.Quit...)
 }
 
@@ -56,7 +62,36 @@ func (set *WindowCallbackSet) Reset() {
 	set.MouseWheel = make([]func(x, y int32) bool, 0)
 	set.KeyDown = make([]func(keysym sdl.Keysym) bool, 0)
 	set.KeyUp = make([]func(keysym sdl.Keysym) bool, 0)
-	set.TextInput = make([]func(rn rune) bool, 0)
+	set.TextInput = make([]func(text string) bool, 0)
+	set.TextEditing = make([]func(text string, cursor, selectionLength int32) bool, 0)
 	set.SizeChange = make([]func(w, h int32) bool, 0)
+	set.TouchPressure = make([]func(pressure float32) bool, 0)
 	set.Quit = make([]func() bool, 0)
 }
+
+// Returns a copy of the set whose mouse callbacks get the coordinates converted by mapPoint
+func (set *WindowCallbackSet) MapMouse(mapPoint func(x, y int32) (int32, int32)) *WindowCallbackSet {
+	mapped := NewWindowCallbackSet()
+	mapped.Append(set)
+	for i, cb := range set.MouseDown {
+		cb := cb
+		mapped.MouseDown[i] = func(button uint8, x, y int32) bool {
+			x, y = mapPoint(x, y)
+			return cb(button, x, y)
+		}
+	}
+	for i, cb := range set.MouseMove {
+		cb := cb
+		mapped.MouseMove[i] = func(x, y int32) bool {
+			return cb(mapPoint(x, y))
+		}
+	}
+	for i, cb := range set.MouseUp {
+		cb := cb
+		mapped.MouseUp[i] = func(button uint8, x, y int32) bool {
+			x, y = mapPoint(x, y)
+			return cb(button, x, y)
+		}
+	}
+	return mapped
+}
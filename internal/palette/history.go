@@ -0,0 +1,117 @@
+package palette
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/Wine1y/trigat/config"
+	"github.com/Wine1y/trigat/pkg"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+const colorHistoryFileName string = "colors.json"
+const maxColorHistorySize int = 32
+
+var colorHistoryMutex sync.Mutex
+
+func LoadColorHistory() ([]sdl.Color, error) {
+	colorHistoryMutex.Lock()
+	defer colorHistoryMutex.Unlock()
+	return readColorHistory()
+}
+
+// Returns the configured number of kept colors, limited so the history fits on the screen
+func ColorHistorySize() int {
+	return pkg.Clamp(0, config.GetPipetteConfig().HistorySize, maxColorHistorySize)
+}
+
+// Moves the color to the front of the history, returns the updated history
+func AddColorToHistory(color sdl.Color) ([]sdl.Color, error) {
+	historySize := ColorHistorySize()
+	if historySize <= 0 {
+		return make([]sdl.Color, 0), nil
+	}
+	colorHistoryMutex.Lock()
+	defer colorHistoryMutex.Unlock()
+	colors, err := readColorHistory()
+	if err != nil {
+		return nil, err
+	}
+	updated := append(make([]sdl.Color, 0, len(colors)+1), color)
+	for _, historyColor := range colors {
+		if historyColor != color {
+			updated = append(updated, historyColor)
+		}
+	}
+	if len(updated) > historySize {
+		updated = updated[:historySize]
+	}
+	if err := writeColorHistory(updated); err != nil {
+		return nil, err
+	}
+	return updated, nil
+}
+
+func ExportPalette(colors []sdl.Color) error {
+	options, success := pkg.RequestPaletteSavingOptions("Exporting palette", "palette")
+	if !success {
+		return nil
+	}
+	file, err := os.Create(options.Filepath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	name := filepath.Base(options.Filepath)
+	name = name[:len(name)-len(filepath.Ext(name))]
+	return options.Format.WritingFunction(name, colors, file)
+}
+
+func colorHistoryFilePath() string {
+	return filepath.Join(config.GetDataDir(), colorHistoryFileName)
+}
+
+func readColorHistory() ([]sdl.Color, error) {
+	data, err := os.ReadFile(colorHistoryFilePath())
+	if errors.Is(err, os.ErrNotExist) {
+		return make([]sdl.Color, 0), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var hexColors []string
+	if err := json.Unmarshal(data, &hexColors); err != nil {
+		return nil, err
+	}
+	colors := make([]sdl.Color, 0, len(hexColors))
+	for _, hexColor := range hexColors {
+		color, err := pkg.ParseHexColor(hexColor)
+		if err != nil {
+			return nil, err
+		}
+		colors = append(colors, color)
+	}
+	return colors, nil
+}
+
+func writeColorHistory(colors []sdl.Color) error {
+	if err := os.MkdirAll(config.GetDataDir(), 0700); err != nil {
+		return err
+	}
+	hexColors := make([]string, len(colors))
+	for i, color := range colors {
+		hexColors[i] = pkg.FormatHexAlphaColor(color)
+	}
+	data, err := json.MarshalIndent(hexColors, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmpPath := colorHistoryFilePath() + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, colorHistoryFilePath())
+}
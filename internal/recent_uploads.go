@@ -0,0 +1,111 @@
+package internal
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/Wine1y/trigat/internal/sharing"
+	"github.com/Wine1y/trigat/pkg"
+	"github.com/getlantern/systray"
+	"golang.design/x/clipboard"
+)
+
+const recentUploadsMenuLimit int = 10
+const recentUploadTimeFormat string = "Jan 2 15:04"
+
+type recentUploadsMenu struct {
+	menu  *systray.MenuItem
+	items []*recentUploadItem
+	mutex sync.Mutex
+}
+
+type recentUploadItem struct {
+	item       *systray.MenuItem
+	copyItem   *systray.MenuItem
+	openItem   *systray.MenuItem
+	deleteItem *systray.MenuItem
+	entry      *sharing.HistoryEntry
+}
+
+func newRecentUploadsMenu() *recentUploadsMenu {
+	uploadsMenu := recentUploadsMenu{
+		menu:  systray.AddMenuItem("Recent uploads", "Recently uploaded screenshots"),
+		items: make([]*recentUploadItem, recentUploadsMenuLimit),
+	}
+	for i := range uploadsMenu.items {
+		item := uploadsMenu.menu.AddSubMenuItem("", "")
+		uploadItem := &recentUploadItem{
+			item:       item,
+			copyItem:   item.AddSubMenuItem("Copy link", "Copy the image link to the clipboard"),
+			openItem:   item.AddSubMenuItem("Open in browser", "Open the image link in the browser"),
+			deleteItem: item.AddSubMenuItem("Delete from host", "Remove the image from the image hosting"),
+		}
+		item.Hide()
+		uploadsMenu.items[i] = uploadItem
+		go uploadsMenu.listenItemClicks(uploadItem)
+	}
+	sharing.OnHistoryChanged(uploadsMenu.update)
+	uploadsMenu.update()
+	return &uploadsMenu
+}
+
+func (uploadsMenu *recentUploadsMenu) update() {
+	entries, err := sharing.LoadHistory()
+	if err != nil {
+		println(fmt.Sprintf("Can't load upload history: %v", err))
+		return
+	}
+	uploadsMenu.mutex.Lock()
+	defer uploadsMenu.mutex.Unlock()
+	for i, uploadItem := range uploadsMenu.items {
+		if i >= len(entries) {
+			uploadItem.entry = nil
+			uploadItem.item.Hide()
+			continue
+		}
+		entry := entries[i]
+		uploadItem.entry = &entry
+		uploadItem.item.SetTitle(fmt.Sprintf("%v - %v", entry.Timestamp.Format(recentUploadTimeFormat), entry.URL))
+		uploadItem.item.SetTooltip(entry.URL)
+		if entry.DeleteURL == "" {
+			uploadItem.deleteItem.Disable()
+		} else {
+			uploadItem.deleteItem.Enable()
+		}
+		uploadItem.item.Show()
+	}
+	if len(entries) == 0 {
+		uploadsMenu.menu.Disable()
+	} else {
+		uploadsMenu.menu.Enable()
+	}
+}
+
+func (uploadsMenu *recentUploadsMenu) listenItemClicks(uploadItem *recentUploadItem) {
+	for {
+		select {
+		case <-uploadItem.copyItem.ClickedCh:
+			if entry := uploadsMenu.itemEntry(uploadItem); entry != nil {
+				clipboard.Write(clipboard.FmtText, []byte(entry.URL))
+			}
+		case <-uploadItem.openItem.ClickedCh:
+			if entry := uploadsMenu.itemEntry(uploadItem); entry != nil {
+				pkg.OpenUrlInBrowser(entry.URL)
+			}
+		case <-uploadItem.deleteItem.ClickedCh:
+			if entry := uploadsMenu.itemEntry(uploadItem); entry != nil {
+				if err := sharing.DeleteUpload(*entry); err != nil {
+					pkg.ShowErrorMessage("Can't delete %v: %v", entry.URL, err)
+				} else {
+					pkg.ShowInfoMessage("%v was deleted", entry.URL)
+				}
+			}
+		}
+	}
+}
+
+func (uploadsMenu *recentUploadsMenu) itemEntry(uploadItem *recentUploadItem) *sharing.HistoryEntry {
+	uploadsMenu.mutex.Lock()
+	defer uploadsMenu.mutex.Unlock()
+	return uploadItem.entry
+}
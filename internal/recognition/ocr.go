@@ -0,0 +1,47 @@
+package recognition
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"time"
+
+	"github.com/Wine1y/trigat/config"
+	"github.com/Wine1y/trigat/pkg"
+)
+
+const (
+	OCREngineTesseract string = "tesseract"
+)
+
+const defaultOCRTimeout time.Duration = time.Second * 30
+
+func NewOCREngine() (pkg.OCREngine, error) {
+	ocrConfig := config.GetOCRConfig()
+	switch ocrConfig.Engine {
+	case OCREngineTesseract, "":
+		return pkg.TesseractOCREngine{
+			Path:      ocrConfig.Tesseract.Path,
+			Languages: ocrConfig.Tesseract.Languages,
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown OCR engine %q", ocrConfig.Engine)
+}
+
+func RecognizeText(img image.Image) (*pkg.OCRResult, error) {
+	engine, err := NewOCREngine()
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), ocrTimeout())
+	defer cancel()
+	return engine.Recognize(ctx, img)
+}
+
+func ocrTimeout() time.Duration {
+	timeoutSeconds := config.GetOCRConfig().TimeoutSeconds
+	if timeoutSeconds <= 0 {
+		return defaultOCRTimeout
+	}
+	return time.Second * time.Duration(timeoutSeconds)
+}
@@ -0,0 +1,35 @@
+package recognition
+
+import (
+	"fmt"
+	"image"
+
+	"github.com/Wine1y/trigat/config"
+	"github.com/Wine1y/trigat/pkg"
+)
+
+func FindSensitiveText(img image.Image) ([]pkg.SensitiveTextMatch, error) {
+	patterns, err := sensitiveTextPatterns()
+	if err != nil {
+		return nil, err
+	}
+	result, err := RecognizeText(img)
+	if err != nil {
+		return nil, err
+	}
+	return pkg.FindSensitiveText(result, patterns), nil
+}
+
+func sensitiveTextPatterns() ([]pkg.SensitiveTextPattern, error) {
+	customPatterns := config.GetRedactionConfig().Patterns
+	patterns := make([]pkg.SensitiveTextPattern, 0, len(pkg.DefaultSensitiveTextPatterns)+len(customPatterns))
+	patterns = append(patterns, pkg.DefaultSensitiveTextPatterns...)
+	for _, customPattern := range customPatterns {
+		pattern, err := pkg.NewSensitiveTextPattern(customPattern.Name, customPattern.Pattern)
+		if err != nil {
+			return nil, fmt.Errorf("invalid redaction pattern %q: %v", customPattern.Name, err)
+		}
+		patterns = append(patterns, pattern)
+	}
+	return patterns, nil
+}
@@ -0,0 +1,167 @@
+package sharing
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/Wine1y/trigat/config"
+	"github.com/Wine1y/trigat/pkg"
+	"golang.org/x/image/draw"
+)
+
+const historyFileName string = "uploads.json"
+const thumbnailsDirName string = "thumbnails"
+const thumbnailMaxSide int = 256
+
+var historyMutex sync.Mutex
+var historyListeners []func()
+
+type HistoryEntry struct {
+	Timestamp     time.Time `json:"timestamp"`
+	ThumbnailPath string    `json:"thumbnail_path"`
+	URL           string    `json:"url"`
+	DeleteURL     string    `json:"delete_url"`
+	Backend       string    `json:"backend"`
+}
+
+func LoadHistory() ([]HistoryEntry, error) {
+	historyMutex.Lock()
+	defer historyMutex.Unlock()
+	return readHistory()
+}
+
+func OnHistoryChanged(listener func()) {
+	historyMutex.Lock()
+	defer historyMutex.Unlock()
+	historyListeners = append(historyListeners, listener)
+}
+
+func addHistoryEntry(result *pkg.UploadResult, backend string, thumbnail []byte) error {
+	historySize := config.GetUploadConfig().HistorySize
+	if historySize <= 0 {
+		return nil
+	}
+	historyMutex.Lock()
+	defer historyMutex.Unlock()
+	entries, err := readHistory()
+	if err != nil {
+		return err
+	}
+
+	timestamp := time.Now()
+	thumbnailsDir := filepath.Join(config.GetDataDir(), thumbnailsDirName)
+	if err := os.MkdirAll(thumbnailsDir, 0700); err != nil {
+		return err
+	}
+	thumbnailPath := filepath.Join(thumbnailsDir, timestamp.Format("20060102-150405.000")+".png")
+	if err := os.WriteFile(thumbnailPath, thumbnail, 0600); err != nil {
+		return err
+	}
+
+	entries = append([]HistoryEntry{{
+		Timestamp:     timestamp,
+		ThumbnailPath: thumbnailPath,
+		URL:           result.URL,
+		DeleteURL:     result.DeleteURL,
+		Backend:       backend,
+	}}, entries...)
+	if len(entries) > historySize {
+		for _, entry := range entries[historySize:] {
+			os.Remove(entry.ThumbnailPath)
+		}
+		entries = entries[:historySize]
+	}
+	if err := writeHistory(entries); err != nil {
+		return err
+	}
+	notifyHistoryListeners()
+	return nil
+}
+
+func removeHistoryEntry(removed HistoryEntry) error {
+	historyMutex.Lock()
+	defer historyMutex.Unlock()
+	entries, err := readHistory()
+	if err != nil {
+		return err
+	}
+	kept := make([]HistoryEntry, 0, len(entries))
+	for _, entry := range entries {
+		if entry.URL == removed.URL && entry.Timestamp.Equal(removed.Timestamp) {
+			os.Remove(entry.ThumbnailPath)
+			continue
+		}
+		kept = append(kept, entry)
+	}
+	if err := writeHistory(kept); err != nil {
+		return err
+	}
+	notifyHistoryListeners()
+	return nil
+}
+
+func historyFilePath() string {
+	return filepath.Join(config.GetDataDir(), historyFileName)
+}
+
+func readHistory() ([]HistoryEntry, error) {
+	data, err := os.ReadFile(historyFilePath())
+	if errors.Is(err, os.ErrNotExist) {
+		return make([]HistoryEntry, 0), nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var entries []HistoryEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+func writeHistory(entries []HistoryEntry) error {
+	if err := os.MkdirAll(config.GetDataDir(), 0700); err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(entries, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmpPath := historyFilePath() + ".tmp"
+	if err := os.WriteFile(tmpPath, data, 0600); err != nil {
+		return err
+	}
+	return os.Rename(tmpPath, historyFilePath())
+}
+
+func notifyHistoryListeners() {
+	for _, listener := range historyListeners {
+		go listener()
+	}
+}
+
+func newThumbnail(img image.Image) ([]byte, error) {
+	bounds := img.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	if w > thumbnailMaxSide || h > thumbnailMaxSide {
+		if w > h {
+			w, h = thumbnailMaxSide, h*thumbnailMaxSide/w
+		} else {
+			w, h = w*thumbnailMaxSide/h, thumbnailMaxSide
+		}
+	}
+	thumbnail := image.NewRGBA(image.Rect(0, 0, pkg.Max(w, 1), pkg.Max(h, 1)))
+	draw.ApproxBiLinear.Scale(thumbnail, thumbnail.Bounds(), img, bounds, draw.Src, nil)
+	buf := &bytes.Buffer{}
+	if err := png.Encode(buf, thumbnail); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
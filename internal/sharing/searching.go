@@ -0,0 +1,139 @@
+package sharing
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/png"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Wine1y/trigat/config"
+	"github.com/Wine1y/trigat/pkg"
+)
+
+const SearchEngineAsk string = "ask"
+
+const (
+	SearchModeUpload string = "upload"
+	SearchModeLocal  string = "local"
+	SearchModeDirect string = "direct"
+)
+
+const defaultLocalServerLifetime time.Duration = time.Minute * 2
+
+func SearchEngines() []pkg.SearchEngine {
+	searchConfig := config.GetSearchConfig()
+	engines := make([]pkg.SearchEngine, 0, len(pkg.DefaultSearchEngines)+len(searchConfig.CustomEngines))
+	engines = append(engines, pkg.DefaultSearchEngines...)
+	for _, engine := range searchConfig.CustomEngines {
+		engines = append(engines, pkg.SearchEngine{
+			Name:        engine.Name,
+			URLTemplate: engine.URLTemplate,
+			UploadURL:   engine.UploadURL,
+			UploadField: engine.UploadField,
+			ResultPath:  engine.ResultPath,
+		})
+	}
+
+	//Only engines usable with the configured mode are offered
+	usableEngines := make([]pkg.SearchEngine, 0, len(engines))
+	for _, engine := range engines {
+		if searchMode() == SearchModeDirect && !engine.SupportsDirectUpload() {
+			continue
+		}
+		if searchMode() != SearchModeDirect && engine.URLTemplate == "" {
+			continue
+		}
+		usableEngines = append(usableEngines, engine)
+	}
+	return usableEngines
+}
+
+func ConfiguredSearchEngine() (engine *pkg.SearchEngine, shouldAsk bool) {
+	engineName := config.GetSearchConfig().Engine
+	if strings.EqualFold(engineName, SearchEngineAsk) {
+		return nil, true
+	}
+	engines := SearchEngines()
+	for _, engine := range engines {
+		if strings.EqualFold(engine.Name, engineName) {
+			return &engine, false
+		}
+	}
+	if len(engines) == 0 {
+		return &pkg.GoogleLensSearchEngine, false
+	}
+	return &engines[0], false
+}
+
+func SearchImage(img image.Image, engine pkg.SearchEngine) (string, error) {
+	switch searchMode() {
+	case SearchModeUpload:
+		result, err := UploadImage(img)
+		if err != nil {
+			return "", err
+		}
+		return engine.SearchURL(result.URL)
+	case SearchModeLocal:
+		serverConfig := config.GetSearchConfig().LocalServer
+		if !isLocalServerReachable(serverConfig) {
+			return "", fmt.Errorf(
+				"search engines can't reach the local server on %v, set a network address in search.local_server.address or a search.local_server.public_url",
+				serverConfig.Address,
+			)
+		}
+		pngData := &bytes.Buffer{}
+		if err := png.Encode(pngData, img); err != nil {
+			return "", err
+		}
+		lifetime := time.Second * time.Duration(serverConfig.LifetimeSeconds)
+		if lifetime <= 0 {
+			lifetime = defaultLocalServerLifetime
+		}
+		server, err := pkg.ServeImageLocally(pngData.Bytes(), "image/png", serverConfig.Address, serverConfig.PublicURL, lifetime)
+		if err != nil {
+			return "", err
+		}
+		searchURL, err := engine.SearchURL(server.URL)
+		if err != nil {
+			server.Close()
+			return "", err
+		}
+		return searchURL, nil
+	case SearchModeDirect:
+		pngData := &bytes.Buffer{}
+		if err := png.Encode(pngData, img); err != nil {
+			return "", err
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), uploadTimeout())
+		defer cancel()
+		return engine.DirectSearchURL(ctx, pngData, &http.Client{Timeout: uploadTimeout()})
+	}
+	return "", fmt.Errorf("unknown search mode %q", searchMode())
+}
+
+func searchMode() string {
+	mode := strings.ToLower(config.GetSearchConfig().Mode)
+	if mode == "" {
+		return SearchModeUpload
+	}
+	return mode
+}
+
+// Local server is reachable if it has a public URL or doesn't listen on loopback only,
+// empty address listens on all interfaces with a random port
+func isLocalServerReachable(serverConfig config.LocalSearchServerConfig) bool {
+	if serverConfig.PublicURL != "" || serverConfig.Address == "" {
+		return true
+	}
+	host, _, err := net.SplitHostPort(serverConfig.Address)
+	if err != nil || strings.EqualFold(host, "localhost") {
+		return false
+	}
+	ip := net.ParseIP(host)
+	return ip == nil || !ip.IsLoopback()
+}
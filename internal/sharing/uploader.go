@@ -0,0 +1,121 @@
+package sharing
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"image/png"
+	"net/http"
+	"time"
+
+	"github.com/Wine1y/trigat/config"
+	"github.com/Wine1y/trigat/pkg"
+)
+
+const (
+	BackendImgBB     string = "imgbb"
+	BackendMultipart string = "multipart"
+	BackendS3        string = "s3"
+)
+
+const defaultUploadTimeout time.Duration = time.Second * 30
+
+func NewUploader() (pkg.Uploader, error) {
+	return newBackendUploader(config.GetUploadConfig().Backend)
+}
+
+func newBackendUploader(backend string) (pkg.Uploader, error) {
+	uploadConfig := config.GetUploadConfig()
+	client := &http.Client{Timeout: uploadTimeout()}
+	switch backend {
+	case BackendImgBB, "":
+		return pkg.ImgBBUploader{
+			Expiration: uploadConfig.ImgBB.Expiration,
+			Client:     client,
+		}, nil
+	case BackendMultipart:
+		multipartConfig := uploadConfig.Multipart
+		if multipartConfig.URL == "" {
+			return nil, fmt.Errorf("multipart upload URL is not configured")
+		}
+		return pkg.MultipartUploader{
+			URL:          multipartConfig.URL,
+			FieldName:    multipartConfig.FieldName,
+			Fields:       multipartConfig.Fields,
+			Headers:      multipartConfig.Headers,
+			ResultPath:   multipartConfig.ResultPath,
+			DeletePath:   multipartConfig.DeletePath,
+			DeleteMethod: multipartConfig.DeleteMethod,
+			Client:       client,
+		}, nil
+	case BackendS3:
+		s3Config := uploadConfig.S3
+		if s3Config.Endpoint == "" || s3Config.Bucket == "" {
+			return nil, fmt.Errorf("S3 endpoint and bucket are not configured")
+		}
+		return pkg.S3Uploader{
+			Endpoint:  s3Config.Endpoint,
+			Region:    s3Config.Region,
+			Bucket:    s3Config.Bucket,
+			AccessKey: s3Config.AccessKey,
+			SecretKey: s3Config.SecretKey,
+			KeyPrefix: s3Config.KeyPrefix,
+			PathStyle: s3Config.PathStyle,
+			ACL:       s3Config.ACL,
+			PublicURL: s3Config.PublicURL,
+			Client:    client,
+		}, nil
+	}
+	return nil, fmt.Errorf("unknown upload backend %q", backend)
+}
+
+func UploadImage(img image.Image) (*pkg.UploadResult, error) {
+	pngData := &bytes.Buffer{}
+	if err := png.Encode(pngData, img); err != nil {
+		return nil, err
+	}
+	thumbnail, err := newThumbnail(img)
+	if err != nil {
+		return nil, err
+	}
+	backend := config.GetUploadConfig().Backend
+	uploader, err := newBackendUploader(backend)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), uploadTimeout())
+	defer cancel()
+	result, err := uploader.Upload(ctx, pngData)
+	if err != nil {
+		return nil, err
+	}
+	if err := addHistoryEntry(result, backend, thumbnail); err != nil {
+		println(fmt.Sprintf("Can't save upload history: %v", err))
+	}
+	return result, nil
+}
+
+func DeleteUpload(entry HistoryEntry) error {
+	if entry.DeleteURL == "" {
+		return fmt.Errorf("%v has no delete URL", entry.URL)
+	}
+	uploader, err := newBackendUploader(entry.Backend)
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), uploadTimeout())
+	defer cancel()
+	if err := uploader.Delete(ctx, entry.DeleteURL); err != nil {
+		return err
+	}
+	return removeHistoryEntry(entry)
+}
+
+func uploadTimeout() time.Duration {
+	timeoutSeconds := config.GetUploadConfig().TimeoutSeconds
+	if timeoutSeconds <= 0 {
+		return defaultUploadTimeout
+	}
+	return time.Second * time.Duration(timeoutSeconds)
+}
@@ -2,6 +2,16 @@ package pkg
 
 import "time"
 
+// Set when a running animation gives a value, the frame after it is needed to keep the animation going
+var animationFrameRequested bool
+
+// Reports whether any animation has asked for the next frame since the last call
+func TakeAnimationFrameRequest() bool {
+	requested := animationFrameRequested
+	animationFrameRequested = false
+	return requested
+}
+
 type Animation struct {
 	startValue     int
 	endValue       int
@@ -43,6 +53,9 @@ func (animation *Animation) CurrentValue() int {
 		value = animation.getValue(currentFrame)
 	}
 	animation.currentFrame++
+	if !animation.IsEnded() {
+		animationFrameRequested = true
+	}
 	return value
 }
 
@@ -0,0 +1,172 @@
+package pkg
+
+import (
+	"image"
+	"image/draw"
+	"math"
+
+	"github.com/makiuchi-d/gozxing"
+	"github.com/makiuchi-d/gozxing/aztec"
+	"github.com/makiuchi-d/gozxing/datamatrix"
+	multiQRCode "github.com/makiuchi-d/gozxing/multi/qrcode"
+	"github.com/makiuchi-d/gozxing/oned"
+)
+
+const barcodeMaxCodesPerReader int = 16
+const barcodeMaskMargin int = 4
+const barcodeRowMatchRatio float64 = 0.8
+
+type DecodedBarcode struct {
+	Format string
+	Text   string
+	BBox   image.Rectangle
+}
+
+func DecodeBarcodes(img image.Image) ([]DecodedBarcode, error) {
+	gray := image.NewGray(image.Rect(0, 0, img.Bounds().Dx(), img.Bounds().Dy()))
+	draw.Draw(gray, gray.Bounds(), img, img.Bounds().Min, draw.Src)
+	hints := map[gozxing.DecodeHintType]interface{}{gozxing.DecodeHintType_TRY_HARDER: true}
+	decoder := barcodeDecoder{
+		gray:     gray,
+		hints:    hints,
+		barcodes: make([]DecodedBarcode, 0),
+		found:    make(map[string]bool),
+	}
+
+	//QR codes have a dedicated multiple barcodes reader, other readers find one code at a time
+	if bitmap, err := decoder.bitmap(); err == nil {
+		if results, err := multiQRCode.NewQRCodeMultiReader().DecodeMultiple(bitmap, hints); err == nil {
+			for _, result := range results {
+				decoder.mask(decoder.addResult(result))
+			}
+		}
+	}
+	readers := []gozxing.Reader{
+		oned.NewMultiFormatUPCEANReader(hints),
+		oned.NewCode128Reader(),
+		oned.NewCode39Reader(),
+		oned.NewCode93Reader(),
+		oned.NewCodaBarReader(),
+		oned.NewITFReader(),
+		datamatrix.NewDataMatrixReader(),
+		aztec.NewAztecReader(),
+	}
+	for _, reader := range readers {
+		decoder.decodeAll(reader)
+	}
+	return decoder.barcodes, nil
+}
+
+// Found codes are painted over, so the next decoding of the same image finds another code
+type barcodeDecoder struct {
+	gray     *image.Gray
+	source   gozxing.LuminanceSource
+	hints    map[gozxing.DecodeHintType]interface{}
+	barcodes []DecodedBarcode
+	found    map[string]bool
+}
+
+func (decoder *barcodeDecoder) bitmap() (*gozxing.BinaryBitmap, error) {
+	if decoder.source == nil {
+		decoder.source = gozxing.NewLuminanceSourceFromImage(decoder.gray)
+	}
+	return gozxing.NewBinaryBitmap(gozxing.NewHybridBinarizer(decoder.source))
+}
+
+func (decoder *barcodeDecoder) decodeAll(reader gozxing.Reader) {
+	for i := 0; i < barcodeMaxCodesPerReader; i++ {
+		bitmap, err := decoder.bitmap()
+		if err != nil {
+			return
+		}
+		result, err := reader.Decode(bitmap, decoder.hints)
+		if err != nil {
+			return
+		}
+		bbox := decoder.addResult(result)
+		//Codes without result points can't be masked, decoding again would find the same code
+		if !decoder.mask(bbox) {
+			return
+		}
+	}
+}
+
+func (decoder *barcodeDecoder) mask(bbox image.Rectangle) bool {
+	bbox = bbox.Inset(-barcodeMaskMargin).Intersect(decoder.gray.Bounds())
+	if bbox.Empty() {
+		return false
+	}
+	draw.Draw(decoder.gray, bbox, image.White, image.Point{}, draw.Src)
+	decoder.source = nil
+	return true
+}
+
+func (decoder *barcodeDecoder) addResult(result *gozxing.Result) image.Rectangle {
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, point := range result.GetResultPoints() {
+		if point == nil {
+			continue
+		}
+		minX, minY = math.Min(minX, point.GetX()), math.Min(minY, point.GetY())
+		maxX, maxY = math.Max(maxX, point.GetX()), math.Max(maxY, point.GetY())
+	}
+	if math.IsInf(minX, 1) {
+		return image.Rectangle{}
+	}
+	bbox := image.Rect(int(minX), int(minY), int(math.Ceil(maxX))+1, int(math.Ceil(maxY))+1)
+	bbox = expandLinearBBox(decoder.gray, bbox)
+
+	key := result.GetBarcodeFormat().String() + "\x00" + result.GetText()
+	if !decoder.found[key] {
+		decoder.found[key] = true
+		decoder.barcodes = append(decoder.barcodes, DecodedBarcode{
+			Format: result.GetBarcodeFormat().String(),
+			Text:   result.GetText(),
+			BBox:   bbox,
+		})
+	}
+	return bbox
+}
+
+// 1D readers report the ends of the scanned line, so the box is grown over the lines crossing the same bars
+func expandLinearBBox(gray *image.Gray, bbox image.Rectangle) image.Rectangle {
+	bounds := gray.Bounds()
+	switch {
+	case bbox.Dy() <= 1 && bbox.Dx() > 1:
+		at := func(along, across int) uint8 { return gray.GrayAt(along, across).Y }
+		bbox.Min.Y, bbox.Max.Y = expandBars(at, bbox.Min.X, bbox.Max.X, bbox.Min.Y, bounds.Min.Y, bounds.Max.Y)
+	case bbox.Dx() <= 1 && bbox.Dy() > 1:
+		at := func(along, across int) uint8 { return gray.GrayAt(across, along).Y }
+		bbox.Min.X, bbox.Max.X = expandBars(at, bbox.Min.Y, bbox.Max.Y, bbox.Min.X, bounds.Min.X, bounds.Max.X)
+	}
+	return bbox
+}
+
+// Returns the range of lines around the scanned one having the same dark and light pixels
+func expandBars(at func(along, across int) uint8, from, to, line, minLine, maxLine int) (int, int) {
+	darkest, lightest := uint8(255), uint8(0)
+	for i := from; i < to; i++ {
+		darkest, lightest = Min(darkest, at(i, line)), Max(lightest, at(i, line))
+	}
+	threshold := (int(darkest) + int(lightest)) / 2
+	isDark := func(i, line int) bool { return int(at(i, line)) < threshold }
+	matches := func(other int) bool {
+		same := 0
+		for i := from; i < to; i++ {
+			if isDark(i, other) == isDark(i, line) {
+				same++
+			}
+		}
+		return float64(same) >= float64(to-from)*barcodeRowMatchRatio
+	}
+
+	start, end := line, line+1
+	for start > minLine && matches(start-1) {
+		start--
+	}
+	for end < maxLine && matches(end) {
+		end++
+	}
+	return start, end
+}
@@ -0,0 +1,77 @@
+package pkg
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+	"testing"
+
+	"github.com/makiuchi-d/gozxing"
+	"github.com/makiuchi-d/gozxing/oned"
+	"github.com/makiuchi-d/gozxing/qrcode"
+)
+
+func drawTestBarcode(t *testing.T, img *image.Gray, writer gozxing.Writer, format gozxing.BarcodeFormat, text string, rect image.Rectangle) {
+	matrix, err := writer.Encode(text, format, rect.Dx(), rect.Dy(), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for y := 0; y < matrix.GetHeight(); y++ {
+		for x := 0; x < matrix.GetWidth(); x++ {
+			if matrix.Get(x, y) {
+				img.SetGray(rect.Min.X+x, rect.Min.Y+y, color.Gray{Y: 0})
+			}
+		}
+	}
+}
+
+func TestDecodeBarcodes(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 640, 400))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+	codes := []struct {
+		writer gozxing.Writer
+		format gozxing.BarcodeFormat
+		text   string
+		rect   image.Rectangle
+		linear bool
+	}{
+		{oned.NewCode128Writer(), gozxing.BarcodeFormat_CODE_128, "TRIGAT-1", image.Rect(20, 20, 320, 100), true},
+		{oned.NewCode128Writer(), gozxing.BarcodeFormat_CODE_128, "TRIGAT-2", image.Rect(20, 200, 320, 280), true},
+		{qrcode.NewQRCodeWriter(), gozxing.BarcodeFormat_QR_CODE, "https://example.com", image.Rect(400, 20, 600, 220), false},
+	}
+	for _, code := range codes {
+		drawTestBarcode(t, img, code.writer, code.format, code.text, code.rect)
+	}
+
+	barcodes, err := DecodeBarcodes(img)
+	if err != nil {
+		t.Fatalf("DecodeBarcodes() error = %v", err)
+	}
+	if len(barcodes) != len(codes) {
+		t.Fatalf("DecodeBarcodes() = %+v, want %v codes", barcodes, len(codes))
+	}
+	for _, code := range codes {
+		found := false
+		for _, barcode := range barcodes {
+			if barcode.Text != code.text || barcode.Format != code.format.String() {
+				continue
+			}
+			found = true
+			//Boxes of 1D codes are as tall as the bars, not only the scanned line
+			if !barcode.BBox.In(code.rect) || code.linear && barcode.BBox.Dy() < code.rect.Dy()*9/10 {
+				t.Errorf("%v box = %v, want the bars inside of %v", code.text, barcode.BBox, code.rect)
+			}
+		}
+		if !found {
+			t.Errorf("DecodeBarcodes() = %+v, want %v", barcodes, code.text)
+		}
+	}
+}
+
+func TestDecodeBarcodesEmpty(t *testing.T) {
+	img := image.NewGray(image.Rect(0, 0, 200, 100))
+	draw.Draw(img, img.Bounds(), image.White, image.Point{}, draw.Src)
+	if barcodes, err := DecodeBarcodes(img); err != nil || len(barcodes) != 0 {
+		t.Errorf("DecodeBarcodes() of an empty image = %+v, %v, want no codes", barcodes, err)
+	}
+}
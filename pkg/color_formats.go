@@ -0,0 +1,214 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+var ErrInvalidHexColor = errors.New("invalid hex color")
+var ErrInvalidColor = errors.New("invalid color, use #rrggbb, rgb(r, g, b) or hsl(h, s%, l%)")
+
+var colorFunctionRegexp = regexp.MustCompile(`^(?i)(rgba?|hsla?)?\s*\(?([^()]*)\)?$`)
+
+type ColorFormat struct {
+	ID     string
+	Name   string
+	Format func(color sdl.Color) string
+}
+
+var ColorFormats []ColorFormat = []ColorFormat{
+	{ID: "hex", Name: "HEX", Format: FormatHexColor},
+	{ID: "hexa", Name: "HEX + alpha", Format: FormatHexAlphaColor},
+	{ID: "rgb", Name: "rgb()", Format: func(color sdl.Color) string {
+		return fmt.Sprintf("rgb(%d, %d, %d)", color.R, color.G, color.B)
+	}},
+	{ID: "rgba", Name: "rgba()", Format: func(color sdl.Color) string {
+		return fmt.Sprintf("rgba(%d, %d, %d, %v)", color.R, color.G, color.B, roundTo(float64(color.A)/255, 2))
+	}},
+	{ID: "hsl", Name: "hsl()", Format: func(color sdl.Color) string {
+		h, s, l := RGBToHSL(color)
+		return fmt.Sprintf("hsl(%d, %d%%, %d%%)", int(math.Round(h)), int(math.Round(s*100)), int(math.Round(l*100)))
+	}},
+	{ID: "cmyk", Name: "CMYK", Format: func(color sdl.Color) string {
+		c, m, y, k := RGBToCMYK(color)
+		return fmt.Sprintf(
+			"cmyk(%d%%, %d%%, %d%%, %d%%)",
+			int(math.Round(c*100)), int(math.Round(m*100)), int(math.Round(y*100)), int(math.Round(k*100)),
+		)
+	}},
+	{ID: "go", Name: "Go", Format: func(color sdl.Color) string {
+		return fmt.Sprintf("color.RGBA{R: 0x%02x, G: 0x%02x, B: 0x%02x, A: 0x%02x}", color.R, color.G, color.B, color.A)
+	}},
+	{ID: "css", Name: "CSS variable", Format: func(color sdl.Color) string {
+		return fmt.Sprintf("--color-%02x%02x%02x: #%02x%02x%02x;", color.R, color.G, color.B, color.R, color.G, color.B)
+	}},
+}
+
+func FormatHexColor(color sdl.Color) string {
+	return fmt.Sprintf("#%02x%02x%02x", color.R, color.G, color.B)
+}
+
+func FormatHexAlphaColor(color sdl.Color) string {
+	return fmt.Sprintf("#%02x%02x%02x%02x", color.R, color.G, color.B, color.A)
+}
+
+// Accepts #rgb, #rgba, #rrggbb and #rrggbbaa with an optional leading #
+func ParseHexColor(hex string) (sdl.Color, error) {
+	hex = strings.TrimPrefix(strings.TrimSpace(hex), "#")
+	if len(hex) == 3 || len(hex) == 4 {
+		expanded := make([]byte, 0, len(hex)*2)
+		for i := 0; i < len(hex); i++ {
+			expanded = append(expanded, hex[i], hex[i])
+		}
+		hex = string(expanded)
+	}
+	if len(hex) == 6 {
+		hex += "ff"
+	}
+	if len(hex) != 8 {
+		return sdl.Color{}, ErrInvalidHexColor
+	}
+	value, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return sdl.Color{}, ErrInvalidHexColor
+	}
+	return sdl.Color{R: uint8(value >> 24), G: uint8(value >> 16), B: uint8(value >> 8), A: uint8(value)}, nil
+}
+
+// Accepts hex colors, rgb()/rgba(), hsl()/hsla() and bare "r, g, b" values
+func ParseColor(text string) (sdl.Color, error) {
+	text = strings.TrimSpace(text)
+	if color, err := ParseHexColor(text); err == nil {
+		return color, nil
+	}
+	match := colorFunctionRegexp.FindStringSubmatch(text)
+	if match == nil {
+		return sdl.Color{}, ErrInvalidColor
+	}
+	function := strings.ToLower(match[1])
+	args := strings.FieldsFunc(match[2], func(rn rune) bool {
+		return rn == ',' || rn == ' ' || rn == '/'
+	})
+	if len(args) != 3 && len(args) != 4 {
+		return sdl.Color{}, ErrInvalidColor
+	}
+	alpha := uint8(255)
+	if len(args) == 4 {
+		value, err := parseColorComponent(args[3], 1)
+		if err != nil {
+			return sdl.Color{}, err
+		}
+		alpha = uint8(math.Round(value * 255))
+	}
+
+	if strings.HasPrefix(function, "hsl") {
+		h, err := strconv.ParseFloat(strings.TrimSuffix(args[0], "deg"), 64)
+		if err != nil {
+			return sdl.Color{}, ErrInvalidColor
+		}
+		s, err := parseColorComponent(args[1], 100)
+		if err != nil {
+			return sdl.Color{}, err
+		}
+		l, err := parseColorComponent(args[2], 100)
+		if err != nil {
+			return sdl.Color{}, err
+		}
+		color := HSLToRGB(math.Mod(math.Mod(h, 360)+360, 360), s, l)
+		color.A = alpha
+		return color, nil
+	}
+	var channels [3]uint8
+	for i := range channels {
+		value, err := parseColorComponent(args[i], 255)
+		if err != nil {
+			return sdl.Color{}, err
+		}
+		channels[i] = uint8(math.Round(value * 255))
+	}
+	return sdl.Color{R: channels[0], G: channels[1], B: channels[2], A: alpha}, nil
+}
+
+// Parses a number or a percentage into the 0-1 range, scale is the maximum of a plain number
+func parseColorComponent(component string, scale float64) (float64, error) {
+	if strings.HasSuffix(component, "%") {
+		scale = 100
+		component = strings.TrimSuffix(component, "%")
+	}
+	value, err := strconv.ParseFloat(component, 64)
+	if err != nil || value < 0 || value > scale {
+		return 0, ErrInvalidColor
+	}
+	return value / scale, nil
+}
+
+func HSLToRGB(h, s, l float64) sdl.Color {
+	C := (1 - Abs(l*2-1)) * s
+	H := h / 60
+	X := C * (1 - Abs(math.Mod(H, 2)-1))
+	var r, g, b float64
+	switch {
+	case H >= 0 && H <= 1:
+		r, g, b = C, X, 0
+	case H >= 1 && H <= 2:
+		r, g, b = X, C, 0
+	case H >= 2 && H <= 3:
+		r, g, b = 0, C, X
+	case H >= 3 && H <= 4:
+		r, g, b = 0, X, C
+	case H >= 4 && H <= 5:
+		r, g, b = X, 0, C
+	case H >= 5 && H <= 6:
+		r, g, b = C, 0, X
+	}
+	m := l - C/2
+	return sdl.Color{
+		R: uint8(math.Round((r + m) * 255)),
+		G: uint8(math.Round((g + m) * 255)),
+		B: uint8(math.Round((b + m) * 255)),
+		A: 255,
+	}
+}
+
+func RGBToHSL(color sdl.Color) (h, s, l float64) {
+	r, g, b := float64(color.R)/255, float64(color.G)/255, float64(color.B)/255
+	max, min := Max(r, g, b), Min(r, g, b)
+	l = (max + min) / 2
+	delta := max - min
+	if delta == 0 {
+		return 0, 0, l
+	}
+	s = delta / (1 - Abs(2*l-1))
+	switch max {
+	case r:
+		h = 60 * math.Mod((g-b)/delta, 6)
+	case g:
+		h = 60 * ((b-r)/delta + 2)
+	default:
+		h = 60 * ((r-g)/delta + 4)
+	}
+	if h < 0 {
+		h += 360
+	}
+	return h, Clamp(0, s, 1), l
+}
+
+func RGBToCMYK(color sdl.Color) (c, m, y, k float64) {
+	r, g, b := float64(color.R)/255, float64(color.G)/255, float64(color.B)/255
+	k = 1 - Max(r, g, b)
+	if k == 1 {
+		return 0, 0, 0, 1
+	}
+	return (1 - r - k) / (1 - k), (1 - g - k) / (1 - k), (1 - b - k) / (1 - k), k
+}
+
+func roundTo(value float64, digits int) float64 {
+	multiplier := math.Pow(10, float64(digits))
+	return math.Round(value*multiplier) / multiplier
+}
@@ -0,0 +1,90 @@
+package pkg
+
+import (
+	"testing"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+func TestParseColor(t *testing.T) {
+	tests := []struct {
+		text    string
+		want    sdl.Color
+		wantErr bool
+	}{
+		{text: "#ff8000", want: sdl.Color{R: 255, G: 128, B: 0, A: 255}},
+		{text: "  ff8000 ", want: sdl.Color{R: 255, G: 128, B: 0, A: 255}},
+		{text: "#f80", want: sdl.Color{R: 255, G: 136, B: 0, A: 255}},
+		{text: "#f808", want: sdl.Color{R: 255, G: 136, B: 0, A: 136}},
+		{text: "#ff800080", want: sdl.Color{R: 255, G: 128, B: 0, A: 128}},
+		{text: "rgb(255, 128, 0)", want: sdl.Color{R: 255, G: 128, B: 0, A: 255}},
+		{text: "RGB(255 128 0)", want: sdl.Color{R: 255, G: 128, B: 0, A: 255}},
+		{text: "rgba(255, 128, 0, 0.5)", want: sdl.Color{R: 255, G: 128, B: 0, A: 128}},
+		{text: "rgb(100%, 50%, 0% / 50%)", want: sdl.Color{R: 255, G: 128, B: 0, A: 128}},
+		{text: "255, 128, 0", want: sdl.Color{R: 255, G: 128, B: 0, A: 255}},
+		{text: "hsl(120, 100%, 50%)", want: sdl.Color{R: 0, G: 255, B: 0, A: 255}},
+		{text: "hsl(480deg, 100%, 25%)", want: sdl.Color{R: 0, G: 128, B: 0, A: 255}},
+		{text: "hsl(-120, 100%, 50%)", want: sdl.Color{R: 0, G: 0, B: 255, A: 255}},
+		{text: "hsla(0, 0%, 100%, 0)", want: sdl.Color{R: 255, G: 255, B: 255, A: 0}},
+		{text: "", wantErr: true},
+		{text: "#ff80", want: sdl.Color{R: 255, G: 255, B: 136, A: 0}},
+		{text: "#ff80000", wantErr: true},
+		{text: "rgb(256, 0, 0)", wantErr: true},
+		{text: "rgb(-1, 0, 0)", wantErr: true},
+		{text: "rgb(255, 0)", wantErr: true},
+		{text: "rgba(255, 0, 0, 2)", wantErr: true},
+		{text: "hsl(red, 100%, 50%)", wantErr: true},
+		{text: "cmyk(0, 0, 0, 0)", wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.text, func(t *testing.T) {
+			color, err := ParseColor(test.text)
+			if test.wantErr {
+				if err == nil {
+					t.Errorf("ParseColor(%q) = %v, want an error", test.text, color)
+				}
+				return
+			}
+			if err != nil || color != test.want {
+				t.Errorf("ParseColor(%q) = %v, %v, want %v", test.text, color, err, test.want)
+			}
+		})
+	}
+}
+
+func TestHSLToRGB(t *testing.T) {
+	tests := []struct {
+		h, s, l float64
+		want    sdl.Color
+	}{
+		{0, 0, 0, sdl.Color{R: 0, G: 0, B: 0, A: 255}},
+		{0, 0, 1, sdl.Color{R: 255, G: 255, B: 255, A: 255}},
+		{0, 0, 0.5, sdl.Color{R: 128, G: 128, B: 128, A: 255}},
+		{0, 1, 0.5, sdl.Color{R: 255, G: 0, B: 0, A: 255}},
+		{60, 1, 0.5, sdl.Color{R: 255, G: 255, B: 0, A: 255}},
+		{120, 1, 0.5, sdl.Color{R: 0, G: 255, B: 0, A: 255}},
+		{180, 1, 0.5, sdl.Color{R: 0, G: 255, B: 255, A: 255}},
+		{240, 1, 0.5, sdl.Color{R: 0, G: 0, B: 255, A: 255}},
+		{300, 1, 0.5, sdl.Color{R: 255, G: 0, B: 255, A: 255}},
+		{30, 1, 0.5, sdl.Color{R: 255, G: 128, B: 0, A: 255}},
+		{210, 0.5, 0.25, sdl.Color{R: 32, G: 64, B: 96, A: 255}},
+	}
+	for _, test := range tests {
+		if color := HSLToRGB(test.h, test.s, test.l); color != test.want {
+			t.Errorf("HSLToRGB(%v, %v, %v) = %v, want %v", test.h, test.s, test.l, color, test.want)
+		}
+	}
+}
+
+// Colors survive the conversion to HSL and back
+func TestRGBToHSLRoundTrip(t *testing.T) {
+	for _, color := range []sdl.Color{
+		{R: 0, G: 0, B: 0, A: 255}, {R: 255, G: 255, B: 255, A: 255}, {R: 255, G: 128, B: 0, A: 255},
+		{R: 12, G: 200, B: 99, A: 255}, {R: 90, G: 20, B: 240, A: 255}, {R: 200, G: 30, B: 120, A: 255},
+	} {
+		h, s, l := RGBToHSL(color)
+		if converted := HSLToRGB(h, s, l); converted != color {
+			t.Errorf("%v is converted to hsl(%v, %v, %v) and back to %v", color, h, s, l, converted)
+		}
+	}
+}
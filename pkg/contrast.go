@@ -0,0 +1,92 @@
+package pkg
+
+import (
+	"math"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+const wcagAANormalRatio float64 = 4.5
+const wcagAALargeRatio float64 = 3
+const wcagAAANormalRatio float64 = 7
+const wcagAAALargeRatio float64 = 4.5
+
+// APCA 0.0.98G-4g constants
+const apcaMainTRC float64 = 2.4
+const apcaBlackThreshold float64 = 0.022
+const apcaBlackClamp float64 = 1.414
+const apcaNormalBackground float64 = 0.56
+const apcaNormalText float64 = 0.57
+const apcaReverseText float64 = 0.62
+const apcaReverseBackground float64 = 0.65
+const apcaScale float64 = 1.14
+const apcaLowOffset float64 = 0.027
+const apcaLowClip float64 = 0.1
+const apcaDeltaYMin float64 = 0.0005
+
+type WCAGContrast struct {
+	Ratio     float64
+	AANormal  bool
+	AALarge   bool
+	AAANormal bool
+	AAALarge  bool
+}
+
+func RelativeLuminance(color sdl.Color) float64 {
+	linear := func(channel uint8) float64 {
+		c := float64(channel) / 255
+		if c <= 0.04045 {
+			return c / 12.92
+		}
+		return math.Pow((c+0.055)/1.055, 2.4)
+	}
+	return 0.2126*linear(color.R) + 0.7152*linear(color.G) + 0.0722*linear(color.B)
+}
+
+func CheckWCAGContrast(foreground, background sdl.Color) WCAGContrast {
+	lighter, darker := RelativeLuminance(foreground), RelativeLuminance(background)
+	if darker > lighter {
+		lighter, darker = darker, lighter
+	}
+	ratio := (lighter + 0.05) / (darker + 0.05)
+	return WCAGContrast{
+		Ratio:     ratio,
+		AANormal:  ratio >= wcagAANormalRatio,
+		AALarge:   ratio >= wcagAALargeRatio,
+		AAANormal: ratio >= wcagAAANormalRatio,
+		AAALarge:  ratio >= wcagAAALargeRatio,
+	}
+}
+
+// Returns the APCA lightness contrast (Lc), positive for dark text on light background
+func APCAContrast(text, background sdl.Color) float64 {
+	textY, backgroundY := apcaLuminance(text), apcaLuminance(background)
+	if math.Abs(backgroundY-textY) < apcaDeltaYMin {
+		return 0
+	}
+	var contrast float64
+	if backgroundY > textY {
+		contrast = (math.Pow(backgroundY, apcaNormalBackground) - math.Pow(textY, apcaNormalText)) * apcaScale
+		if contrast < apcaLowClip {
+			return 0
+		}
+		contrast -= apcaLowOffset
+	} else {
+		contrast = (math.Pow(backgroundY, apcaReverseBackground) - math.Pow(textY, apcaReverseText)) * apcaScale
+		if contrast > -apcaLowClip {
+			return 0
+		}
+		contrast += apcaLowOffset
+	}
+	return contrast * 100
+}
+
+func apcaLuminance(color sdl.Color) float64 {
+	y := 0.2126729*math.Pow(float64(color.R)/255, apcaMainTRC) +
+		0.7151522*math.Pow(float64(color.G)/255, apcaMainTRC) +
+		0.0721750*math.Pow(float64(color.B)/255, apcaMainTRC)
+	if y < apcaBlackThreshold {
+		y += math.Pow(apcaBlackThreshold-y, apcaBlackClamp)
+	}
+	return y
+}
@@ -1,14 +1,42 @@
 package pkg
 
 import (
+	"image"
+	"math"
 	"reflect"
 	"unsafe"
 
 	"github.com/veandco/go-sdl2/gfx"
 	"github.com/veandco/go-sdl2/img"
 	"github.com/veandco/go-sdl2/sdl"
+	"golang.org/x/image/draw"
 )
 
+type StrokeStyle int
+
+const (
+	StrokeSolid StrokeStyle = iota
+	StrokeDashed
+	StrokeDotted
+	StrokeDashDot
+)
+
+// Dots are always as wide as the stroke, DashLength is only used by dashed styles
+type StrokePattern struct {
+	Style      StrokeStyle
+	DashLength int32
+}
+
+// Single piece of a stroke pattern, "on" pieces of zero length are dots
+type strokeSegment struct {
+	length float64
+	on     bool
+}
+
+type pathPoint struct {
+	X, Y float64
+}
+
 func LoadPNGSurface(pngData []byte) *sdl.Surface {
 	rwOps, err := sdl.RWFromMem(pngData)
 	if err != nil {
@@ -22,16 +50,43 @@ func LoadPNGSurface(pngData []byte) *sdl.Surface {
 	return surface
 }
 
+// Resamples the surface for the display scale, so it's copied pixel to pixel into the scaled UI, the caller frees it
+func ScaleSurface(surface *sdl.Surface, scale float64) *sdl.Surface {
+	converted, err := surface.ConvertFormat(uint32(sdl.PIXELFORMAT_RGBA32), 0)
+	if err != nil {
+		panic(err)
+	}
+	defer converted.Free()
+	source := &image.NRGBA{
+		Pix:    converted.Pixels(),
+		Stride: int(converted.Pitch),
+		Rect:   image.Rect(0, 0, int(converted.W), int(converted.H)),
+	}
+	w, h := Max(1, int(math.Round(float64(converted.W)*scale))), Max(1, int(math.Round(float64(converted.H)*scale)))
+	//Filtering is done on premultiplied colors, so transparent pixels don't darken the edges
+	premultiplied := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(premultiplied, premultiplied.Bounds(), source, source.Bounds(), draw.Src, nil)
+	scaled, err := sdl.CreateRGBSurfaceWithFormat(0, int32(w), int32(h), 32, uint32(sdl.PIXELFORMAT_RGBA32))
+	if err != nil {
+		panic(err)
+	}
+	straight := &image.NRGBA{Pix: scaled.Pixels(), Stride: int(scaled.Pitch), Rect: premultiplied.Rect}
+	draw.Draw(straight, straight.Rect, premultiplied, image.Point{}, draw.Src)
+	return scaled
+}
+
 func DrawPoint(ren *sdl.Renderer, point *sdl.Point, color sdl.Color) {
 	ren.SetDrawColor(color.R, color.G, color.B, color.A)
 	ren.DrawPoint(point.X, point.Y)
 }
 
+// Rows don't overlap, so translucent colors are uniform and the corners match DrawThickRoundedRectangle
 func DrawRoundedFilledRectangle(ren *sdl.Renderer, rect *sdl.Rect, radius int32, color sdl.Color) {
-	gfx.RoundedBoxColor(
+	normalized := NormalizedRect(rect)
+	fillRoundedBox(
 		ren,
-		rect.X, rect.Y,
-		rect.X+rect.W, rect.Y+rect.H,
+		normalized.X, normalized.Y,
+		normalized.X+normalized.W, normalized.Y+normalized.H,
 		radius, color,
 	)
 }
@@ -48,10 +103,129 @@ func DrawFilledRectangle(ren *sdl.Renderer, rect *sdl.Rect, color sdl.Color) {
 	gfx.BoxColor(ren, rect.X, rect.Y, rect.X+rect.W, rect.Y+rect.H, color)
 }
 
+// Border sides don't overlap and meet in mitred square corners,
+// so translucent borders have the same opacity everywhere
 func DrawThickRectangle(ren *sdl.Renderer, rect *sdl.Rect, width int32, color sdl.Color) {
-	for r := int32(0); r < width; r++ {
-		DrawRectangle(ren, &sdl.Rect{X: rect.X - r/2, Y: rect.Y - r/2, W: rect.W + r, H: rect.H + r}, color)
+	if width <= 0 {
+		return
+	}
+	normalized := NormalizedRect(rect)
+	x1, y1 := normalized.X-(width-1)/2, normalized.Y-(width-1)/2
+	x2, y2 := normalized.X+normalized.W+width/2, normalized.Y+normalized.H+width/2
+	if x2-x1+1 <= width*2 || y2-y1+1 <= width*2 {
+		gfx.BoxColor(ren, x1, y1, x2, y2, color)
+		return
 	}
+	gfx.BoxColor(ren, x1, y1, x2, y1+width-1, color)
+	gfx.BoxColor(ren, x1, y2-width+1, x2, y2, color)
+	gfx.BoxColor(ren, x1, y1+width, x1+width-1, y2-width, color)
+	gfx.BoxColor(ren, x2-width+1, y1+width, x2, y2-width, color)
+}
+
+// Border is drawn row by row between the outer and the inner rounded rectangles
+func DrawThickRoundedRectangle(ren *sdl.Renderer, rect *sdl.Rect, width int32, radius int32, color sdl.Color) {
+	if radius <= 0 {
+		DrawThickRectangle(ren, rect, width, color)
+		return
+	}
+	if width <= 0 {
+		return
+	}
+	x1, y1, x2, y2, radius := thickBorderBox(rect, width, radius)
+	ix1, iy1, ix2, iy2 := x1+width, y1+width, x2-width, y2-width
+	if ix2 < ix1 || iy2 < iy1 {
+		fillRoundedBox(ren, x1, y1, x2, y2, radius, color)
+		return
+	}
+	innerRadius := clampCornerRadius(ix1, iy1, ix2, iy2, radius-width)
+
+	drawRow := func(y int32) {
+		left, right := roundedRowBounds(x1, y1, x2, y2, radius, y)
+		if y < iy1 || y > iy2 {
+			gfx.BoxColor(ren, left, y, right, y, color)
+			return
+		}
+		innerLeft, innerRight := roundedRowBounds(ix1, iy1, ix2, iy2, innerRadius, y)
+		if innerLeft > left {
+			gfx.BoxColor(ren, left, y, innerLeft-1, y, color)
+		}
+		if innerRight < right {
+			gfx.BoxColor(ren, innerRight+1, y, right, y, color)
+		}
+	}
+	for y := y1; y < y1+radius; y++ {
+		drawRow(y)
+	}
+	for y := y2 - radius + 1; y <= y2; y++ {
+		drawRow(y)
+	}
+	//Inner corners never reach the straight part of the outer border
+	bandTop, bandBottom := y1+radius, y2-radius
+	if bandTop > bandBottom {
+		return
+	}
+	if iy1 > bandTop {
+		gfx.BoxColor(ren, x1, bandTop, x2, Min(iy1-1, bandBottom), color)
+	}
+	if iy2 < bandBottom {
+		gfx.BoxColor(ren, x1, Max(iy2+1, bandTop), x2, bandBottom, color)
+	}
+	sidesTop, sidesBottom := Max(iy1, bandTop), Min(iy2, bandBottom)
+	if sidesTop <= sidesBottom {
+		gfx.BoxColor(ren, x1, sidesTop, ix1-1, sidesBottom, color)
+		gfx.BoxColor(ren, ix2+1, sidesTop, x2, sidesBottom, color)
+	}
+}
+
+// Fills the area enclosed by the border of DrawThickRoundedRectangle, the fill and the border never overlap or leave a gap
+func DrawThickRoundedRectangleInside(ren *sdl.Renderer, rect *sdl.Rect, width int32, radius int32, color sdl.Color) {
+	width = Max(width, 0)
+	x1, y1, x2, y2, radius := thickBorderBox(rect, width, radius)
+	ix1, iy1, ix2, iy2 := x1+width, y1+width, x2-width, y2-width
+	if ix2 < ix1 || iy2 < iy1 {
+		return
+	}
+	fillRoundedBox(ren, ix1, iy1, ix2, iy2, radius-width, color)
+}
+
+// Returns the box covered by the border centered on the rect edges and the corner radius fitted into it
+func thickBorderBox(rect *sdl.Rect, width int32, radius int32) (int32, int32, int32, int32, int32) {
+	normalized := NormalizedRect(rect)
+	x1, y1 := normalized.X-(width-1)/2, normalized.Y-(width-1)/2
+	x2, y2 := normalized.X+normalized.W+width/2, normalized.Y+normalized.H+width/2
+	return x1, y1, x2, y2, clampCornerRadius(x1, y1, x2, y2, radius)
+}
+
+func fillRoundedBox(ren *sdl.Renderer, x1, y1, x2, y2, radius int32, color sdl.Color) {
+	radius = clampCornerRadius(x1, y1, x2, y2, radius)
+	for k := int32(0); k < radius; k++ {
+		left, right := roundedRowBounds(x1, y1, x2, y2, radius, y1+k)
+		gfx.BoxColor(ren, left, y1+k, right, y1+k, color)
+		gfx.BoxColor(ren, left, y2-k, right, y2-k, color)
+	}
+	if y1+radius <= y2-radius {
+		gfx.BoxColor(ren, x1, y1+radius, x2, y2-radius, color)
+	}
+}
+
+func clampCornerRadius(x1, y1, x2, y2, radius int32) int32 {
+	return Clamp(0, radius, Min((x2-x1+1)/2, (y2-y1+1)/2))
+}
+
+// Returns the horizontal bounds of the row, corner circles are sampled at pixel centers
+func roundedRowBounds(x1, y1, x2, y2, radius, y int32) (int32, int32) {
+	var row int32
+	switch {
+	case y < y1+radius:
+		row = y - y1
+	case y > y2-radius:
+		row = y2 - y
+	default:
+		return x1, x2
+	}
+	distance := float64(radius-row) - 0.5
+	inset := radius - int32(math.Round(math.Sqrt(float64(radius*radius)-distance*distance)))
+	return x1 + inset, x2 - inset
 }
 
 func DrawFilledCircle(ren *sdl.Renderer, center *sdl.Point, radius int32, color sdl.Color) {
@@ -68,6 +242,218 @@ func DrawThickCircle(ren *sdl.Renderer, center *sdl.Point, radius int32, width i
 	}
 }
 
+func DrawFilledTriangle(ren *sdl.Renderer, p1 *sdl.Point, p2 *sdl.Point, p3 *sdl.Point, color sdl.Color) {
+	gfx.FilledTrigonColor(ren, p1.X, p1.Y, p2.X, p2.Y, p3.X, p3.Y, color)
+}
+
+func DrawStyledLine(ren *sdl.Renderer, p1 *sdl.Point, p2 *sdl.Point, width int32, pattern StrokePattern, color sdl.Color) {
+	if pattern.Style == StrokeSolid {
+		DrawThickLine(ren, p1, p2, width, color)
+		return
+	}
+	path := []pathPoint{{X: float64(p1.X), Y: float64(p1.Y)}, {X: float64(p2.X), Y: float64(p2.Y)}}
+	drawStrokePath(ren, path, false, width, pattern.segments(width), color, nil)
+}
+
+// Border is centered on the rect edges like in DrawThickRoundedRectangle,
+// the pattern continues around the corners and dashes crossing a corner are mitred (or rounded with the corner)
+func DrawStyledRectangle(ren *sdl.Renderer, rect *sdl.Rect, width int32, radius int32, pattern StrokePattern, color sdl.Color) {
+	if pattern.Style == StrokeSolid {
+		DrawThickRoundedRectangle(ren, rect, width, radius, color)
+		return
+	}
+	if width <= 0 {
+		return
+	}
+	normalized := NormalizedRect(rect)
+	x1, y1 := float64(normalized.X), float64(normalized.Y)
+	x2, y2 := float64(normalized.X+normalized.W), float64(normalized.Y+normalized.H)
+	_, _, _, _, outerRadius := thickBorderBox(rect, width, radius)
+	//Corner arcs are drawn along the middle of the border
+	arcRadius := float64(outerRadius) - float64(width)/2
+	if arcRadius < 1 {
+		squareJoin := func(vertex pathPoint) {
+			x, y := int32(vertex.X), int32(vertex.Y)
+			gfx.BoxColor(ren, x-(width-1)/2, y-(width-1)/2, x+width/2, y+width/2, color)
+		}
+		path := []pathPoint{{X: x1, Y: y1}, {X: x2, Y: y1}, {X: x2, Y: y2}, {X: x1, Y: y2}}
+		drawStrokePath(ren, path, true, width, pattern.segments(width), color, squareJoin)
+		return
+	}
+	path := make([]pathPoint, 0)
+	corners := []struct {
+		center     pathPoint
+		startAngle float64
+	}{
+		{center: pathPoint{X: x2 - arcRadius, Y: y1 + arcRadius}, startAngle: -math.Pi / 2},
+		{center: pathPoint{X: x2 - arcRadius, Y: y2 - arcRadius}, startAngle: 0},
+		{center: pathPoint{X: x1 + arcRadius, Y: y2 - arcRadius}, startAngle: math.Pi / 2},
+		{center: pathPoint{X: x1 + arcRadius, Y: y1 + arcRadius}, startAngle: math.Pi},
+	}
+	arcSteps := Max(2, int(arcRadius/2))
+	for _, corner := range corners {
+		for step := 0; step <= arcSteps; step++ {
+			angle := corner.startAngle + math.Pi/2*float64(step)/float64(arcSteps)
+			path = append(path, pathPoint{
+				X: corner.center.X + arcRadius*math.Cos(angle),
+				Y: corner.center.Y + arcRadius*math.Sin(angle),
+			})
+		}
+	}
+	roundJoin := func(vertex pathPoint) {
+		if width > 2 {
+			gfx.FilledCircleColor(ren, int32(math.Round(vertex.X)), int32(math.Round(vertex.Y)), width/2, color)
+		}
+	}
+	drawStrokePath(ren, path, true, width, pattern.segments(width), color, roundJoin)
+}
+
+func (pattern StrokePattern) segments(width int32) []strokeSegment {
+	dash := float64(Max(pattern.DashLength, 1))
+	gap := dash/2 + float64(width)
+	dotGap := float64(width*2 + 1)
+	switch pattern.Style {
+	case StrokeDashed:
+		return []strokeSegment{{length: dash, on: true}, {length: gap}}
+	case StrokeDotted:
+		return []strokeSegment{{on: true}, {length: dotGap}}
+	case StrokeDashDot:
+		return []strokeSegment{{length: dash, on: true}, {length: gap}, {on: true}, {length: gap}}
+	}
+	return []strokeSegment{{length: 1, on: true}}
+}
+
+// Walks the path repeating the pattern, join is called for vertices covered by a dash (can be nil)
+func drawStrokePath(
+	ren *sdl.Renderer, path []pathPoint, closed bool,
+	width int32, pattern []strokeSegment, color sdl.Color,
+	join func(vertex pathPoint),
+) {
+	edges := len(path) - 1
+	if closed {
+		edges = len(path)
+	}
+	segment, segmentLeft := 0, pattern[0].length
+	for i := 0; i < edges; i++ {
+		from, to := path[i], path[(i+1)%len(path)]
+		edgeLength := math.Hypot(to.X-from.X, to.Y-from.Y)
+		if edgeLength == 0 {
+			continue
+		}
+		pointAt := func(distance float64) pathPoint {
+			return pathPoint{
+				X: from.X + (to.X-from.X)*distance/edgeLength,
+				Y: from.Y + (to.Y-from.Y)*distance/edgeLength,
+			}
+		}
+		position := 0.0
+		for position < edgeLength {
+			current := pattern[segment]
+			switch {
+			case current.on && current.length == 0:
+				drawStrokeDot(ren, pointAt(position), width, color)
+			case current.on:
+				step := math.Min(segmentLeft, edgeLength-position)
+				if position == 0 && join != nil && (closed || i > 0) {
+					join(from)
+				}
+				drawStrokePiece(ren, from, to, edgeLength, position, position+step, width, color)
+				position += step
+				segmentLeft -= step
+			default:
+				step := math.Min(segmentLeft, edgeLength-position)
+				position += step
+				segmentLeft -= step
+			}
+			if segmentLeft <= 1e-9 {
+				segment = (segment + 1) % len(pattern)
+				segmentLeft = pattern[segment].length
+			}
+		}
+	}
+}
+
+// Axis-aligned pieces are drawn with the same pixel bounds as DrawThickRectangle sides
+func drawStrokePiece(ren *sdl.Renderer, from, to pathPoint, edgeLength, start, end float64, width int32, color sdl.Color) {
+	first, last := int32(math.Round(start)), int32(math.Round(end))-1
+	switch {
+	case from.Y == to.Y && from.X == math.Trunc(from.X) && from.Y == math.Trunc(from.Y):
+		if last < first {
+			return
+		}
+		direction := int32(1)
+		if to.X < from.X {
+			direction = -1
+		}
+		x, y := int32(from.X), int32(from.Y)
+		gfx.BoxColor(ren, x+first*direction, y-(width-1)/2, x+last*direction, y+width/2, color)
+	case from.X == to.X && from.X == math.Trunc(from.X) && from.Y == math.Trunc(from.Y):
+		if last < first {
+			return
+		}
+		direction := int32(1)
+		if to.Y < from.Y {
+			direction = -1
+		}
+		x, y := int32(from.X), int32(from.Y)
+		gfx.BoxColor(ren, x-(width-1)/2, y+first*direction, x+width/2, y+last*direction, color)
+	default:
+		dx, dy := (to.X-from.X)/edgeLength, (to.Y-from.Y)/edgeLength
+		gfx.ThickLineColor(
+			ren,
+			int32(math.Round(from.X+dx*start)), int32(math.Round(from.Y+dy*start)),
+			int32(math.Round(from.X+dx*end)), int32(math.Round(from.Y+dy*end)),
+			width, color,
+		)
+	}
+}
+
+func drawStrokeDot(ren *sdl.Renderer, center pathPoint, width int32, color sdl.Color) {
+	x, y := int32(math.Round(center.X)), int32(math.Round(center.Y))
+	if width <= 3 {
+		gfx.BoxColor(ren, x-(width-1)/2, y-(width-1)/2, x+width/2, y+width/2, color)
+		return
+	}
+	gfx.FilledCircleColor(ren, x, y, width/2, color)
+}
+
+// Draws opaque shapes into a separate layer and blends the whole layer with the given alpha,
+// so overlapping shapes don't get darker where they intersect
+func DrawTranslucent(ren *sdl.Renderer, bbox *sdl.Rect, alpha uint8, draw func(offset sdl.Point)) {
+	if alpha == 255 {
+		draw(sdl.Point{})
+		return
+	}
+	if bbox.Empty() || alpha == 0 {
+		return
+	}
+	layer := RenderToTexture(ren, bbox, draw)
+	defer layer.Destroy()
+	layer.SetAlphaMod(alpha)
+	ren.Copy(layer, nil, bbox)
+}
+
+// Renders shapes into a new transparent texture of the bbox size, draw gets the offset of the bbox origin.
+// Copying the texture with an alpha mod blends it exactly like DrawTranslucent does
+func RenderToTexture(ren *sdl.Renderer, bbox *sdl.Rect, draw func(offset sdl.Point)) *sdl.Texture {
+	layer, err := ren.CreateTexture(uint32(sdl.PIXELFORMAT_RGBA32), sdl.TEXTUREACCESS_TARGET, bbox.W, bbox.H)
+	if err != nil {
+		panic(err)
+	}
+	previousTarget := ren.GetRenderTarget()
+	if err := ren.SetRenderTarget(layer); err != nil {
+		panic(err)
+	}
+	ren.SetDrawColor(0, 0, 0, 0)
+	ren.Clear()
+	draw(sdl.Point{X: -bbox.X, Y: -bbox.Y})
+	if err := ren.SetRenderTarget(previousTarget); err != nil {
+		panic(err)
+	}
+	layer.SetBlendMode(sdl.BLENDMODE_BLEND)
+	return layer
+}
+
 func CreateTextureFromSurface(ren *sdl.Renderer, surface *sdl.Surface) *sdl.Texture {
 	texture, err := ren.CreateTextureFromSurface(surface)
 	if err != nil {
@@ -104,3 +490,30 @@ func ReadRGBA32(ren *sdl.Renderer, rect *sdl.Rect) []uint8 {
 	}
 	return pixels
 }
+
+// Reads the rect of a target texture whatever the current render target is, pixels outside of the texture are transparent
+func ReadTextureRGBA32(ren *sdl.Renderer, texture *sdl.Texture, rect sdl.Rect) []uint8 {
+	pixels := make([]uint8, rect.W*rect.H*4)
+	_, _, w, h, err := texture.Query()
+	if err != nil {
+		panic(err)
+	}
+	visible, intersects := rect.Intersect(&sdl.Rect{W: w, H: h})
+	if !intersects {
+		return pixels
+	}
+	previousTarget := ren.GetRenderTarget()
+	if err := ren.SetRenderTarget(texture); err != nil {
+		panic(err)
+	}
+	visiblePixels := ReadRGBA32(ren, &visible)
+	if err := ren.SetRenderTarget(previousTarget); err != nil {
+		panic(err)
+	}
+	rowSize := visible.W * 4
+	for row := int32(0); row < visible.H; row++ {
+		offset := ((visible.Y-rect.Y+row)*rect.W + visible.X - rect.X) * 4
+		copy(pixels[offset:], visiblePixels[row*rowSize:(row+1)*rowSize])
+	}
+	return pixels
+}
@@ -0,0 +1,14 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+)
+
+func systemFontDirs() []string {
+	dirs := []string{"/System/Library/Fonts", "/Library/Fonts"}
+	if home, err := os.UserHomeDir(); err == nil {
+		dirs = append(dirs, filepath.Join(home, "Library", "Fonts"))
+	}
+	return dirs
+}
@@ -0,0 +1,91 @@
+package pkg
+
+import (
+	"encoding/xml"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+const fontconfigPath string = "/etc/fonts/fonts.conf"
+
+var defaultFontDirs = []string{"/usr/share/fonts", "/usr/local/share/fonts", "~/.local/share/fonts", "~/.fonts"}
+
+// Returns the font directories listed in the fontconfig configuration
+func systemFontDirs() []string {
+	configs := []string{fontconfigPath}
+	if extraConfigs, err := filepath.Glob(filepath.Join(filepath.Dir(fontconfigPath), "conf.d", "*.conf")); err == nil {
+		configs = append(configs, extraConfigs...)
+	}
+	dirs := make([]string, 0)
+	for _, config := range configs {
+		dirs = append(dirs, readFontconfigDirs(config)...)
+	}
+	if len(dirs) == 0 {
+		for _, dir := range defaultFontDirs {
+			dirs = append(dirs, expandFontDir(dir, ""))
+		}
+	}
+	return uniqueFontDirs(dirs)
+}
+
+func readFontconfigDirs(path string) []string {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil
+	}
+	defer file.Close()
+
+	dirs := make([]string, 0)
+	decoder := xml.NewDecoder(file)
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			return dirs
+		}
+		start, isStart := token.(xml.StartElement)
+		if !isStart || start.Name.Local != "dir" {
+			continue
+		}
+		var dir struct {
+			Prefix string `xml:"prefix,attr"`
+			Path   string `xml:",chardata"`
+		}
+		if err := decoder.DecodeElement(&dir, &start); err != nil {
+			return dirs
+		}
+		if path := expandFontDir(strings.TrimSpace(dir.Path), dir.Prefix); path != "" {
+			dirs = append(dirs, path)
+		}
+	}
+}
+
+func expandFontDir(dir string, prefix string) string {
+	home, _ := os.UserHomeDir()
+	switch {
+	case prefix == "xdg":
+		dataHome := os.Getenv("XDG_DATA_HOME")
+		if dataHome == "" && home != "" {
+			dataHome = filepath.Join(home, ".local", "share")
+		}
+		return filepath.Join(dataHome, dir)
+	case dir == "~" || strings.HasPrefix(dir, "~/"):
+		if home == "" {
+			return ""
+		}
+		return filepath.Join(home, dir[1:])
+	}
+	return dir
+}
+
+func uniqueFontDirs(dirs []string) []string {
+	seen := make(map[string]bool, len(dirs))
+	unique := make([]string, 0, len(dirs))
+	for _, dir := range dirs {
+		if dir != "" && !seen[dir] {
+			seen[dir] = true
+			unique = append(unique, dir)
+		}
+	}
+	return unique
+}
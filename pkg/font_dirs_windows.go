@@ -0,0 +1,17 @@
+package pkg
+
+import (
+	"os"
+	"path/filepath"
+)
+
+func systemFontDirs() []string {
+	dirs := make([]string, 0, 2)
+	if windir := os.Getenv("WINDIR"); windir != "" {
+		dirs = append(dirs, filepath.Join(windir, "Fonts"))
+	}
+	if localAppData := os.Getenv("LOCALAPPDATA"); localAppData != "" {
+		dirs = append(dirs, filepath.Join(localAppData, "Microsoft", "Windows", "Fonts"))
+	}
+	return dirs
+}
@@ -0,0 +1,233 @@
+package pkg
+
+import (
+	"encoding/binary"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/veandco/go-sdl2/ttf"
+)
+
+const maxFontNameTables uint16 = 512
+const maxFontNameRecords uint16 = 4096
+
+var fontFileExtensions = []string{".ttf", ".otf"}
+var regularFaceNames = []string{"regular", "book", "normal", "roman"}
+
+type FontFamily struct {
+	Name string
+	Path string
+	data []byte
+}
+
+func NewEmbeddedFontFamily(name string, fontData []byte) FontFamily {
+	return FontFamily{Name: name, data: fontData}
+}
+
+func (family FontFamily) Load(size int) (*ttf.Font, error) {
+	if family.data != nil {
+		return LoadFont(family.data, size), nil
+	}
+	return ttf.OpenFont(family.Path, size)
+}
+
+// Scans the system font directories and returns one font file per family, sorted by name
+func DiscoverFontFamilies() []FontFamily {
+	faces := make(map[string]fontFace)
+	for _, dir := range systemFontDirs() {
+		filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+			if err != nil || entry.IsDir() || !isFontFile(path) {
+				return nil
+			}
+			family, subfamily, err := readFontNames(path)
+			if err != nil || family == "" {
+				return nil
+			}
+			face := fontFace{path: path, isRegular: isRegularFace(subfamily)}
+			if current, ok := faces[family]; !ok || (face.isRegular && !current.isRegular) {
+				faces[family] = face
+			}
+			return nil
+		})
+	}
+	families := make([]FontFamily, 0, len(faces))
+	for name, face := range faces {
+		families = append(families, FontFamily{Name: name, Path: face.path})
+	}
+	sort.Slice(families, func(i, j int) bool {
+		return strings.ToLower(families[i].Name) < strings.ToLower(families[j].Name)
+	})
+	return families
+}
+
+type fontFace struct {
+	path      string
+	isRegular bool
+}
+
+func isFontFile(path string) bool {
+	ext := strings.ToLower(filepath.Ext(path))
+	for _, fontExt := range fontFileExtensions {
+		if ext == fontExt {
+			return true
+		}
+	}
+	return false
+}
+
+func isRegularFace(subfamily string) bool {
+	subfamily = strings.ToLower(subfamily)
+	for _, name := range regularFaceNames {
+		if subfamily == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Reads the family and subfamily names from the "name" table of a TrueType or OpenType file
+func readFontNames(path string) (string, string, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return "", "", err
+	}
+	defer file.Close()
+	info, err := file.Stat()
+	if err != nil {
+		return "", "", err
+	}
+
+	header := make([]byte, 12)
+	if _, err := file.ReadAt(header, 0); err != nil {
+		return "", "", err
+	}
+	numTables := binary.BigEndian.Uint16(header[4:6])
+	if numTables > maxFontNameTables {
+		return "", "", errors.New("invalid font table directory")
+	}
+	tables := make([]byte, 16*int(numTables))
+	if _, err := file.ReadAt(tables, 12); err != nil {
+		return "", "", err
+	}
+	var nameOffset, nameLength uint32
+	for i := 0; i < len(tables); i += 16 {
+		if string(tables[i:i+4]) == "name" {
+			nameOffset = binary.BigEndian.Uint32(tables[i+8 : i+12])
+			nameLength = binary.BigEndian.Uint32(tables[i+12 : i+16])
+			break
+		}
+	}
+	if nameLength < 6 {
+		return "", "", errors.New("font has no name table")
+	}
+	//Table length comes from the file, so it's checked before anything is allocated for it
+	if int64(nameOffset)+int64(nameLength) > info.Size() {
+		return "", "", errors.New("font name table is out of the file")
+	}
+	nameTable := make([]byte, nameLength)
+	if _, err := file.ReadAt(nameTable, int64(nameOffset)); err != nil {
+		return "", "", err
+	}
+
+	count := binary.BigEndian.Uint16(nameTable[2:4])
+	stringsOffset := int(binary.BigEndian.Uint16(nameTable[4:6]))
+	if count > maxFontNameRecords || 6+12*int(count) > len(nameTable) {
+		return "", "", errors.New("invalid font name table")
+	}
+	names := make(map[uint16]string)
+	namePriorities := make(map[uint16]int)
+	for i := 0; i < int(count); i++ {
+		record := nameTable[6+12*i : 6+12*(i+1)]
+		platformID := binary.BigEndian.Uint16(record[0:2])
+		languageID := binary.BigEndian.Uint16(record[4:6])
+		nameID := binary.BigEndian.Uint16(record[6:8])
+		length := int(binary.BigEndian.Uint16(record[8:10]))
+		offset := stringsOffset + int(binary.BigEndian.Uint16(record[10:12]))
+		if (nameID != 1 && nameID != 2 && nameID != 16 && nameID != 17) || offset+length > len(nameTable) {
+			continue
+		}
+		//English Windows names are preferred over other Windows names, which are preferred over Macintosh ones
+		var priority int
+		switch {
+		case platformID == 3 && languageID == 0x409:
+			priority = 3
+		case platformID == 3:
+			priority = 2
+		case platformID == 1 && languageID == 0:
+			priority = 1
+		default:
+			continue
+		}
+		if priority <= namePriorities[nameID] {
+			continue
+		}
+		names[nameID] = decodeFontName(nameTable[offset:offset+length], platformID)
+		namePriorities[nameID] = priority
+	}
+
+	//Typographic names group all weights of a family under one name
+	family, subfamily := names[16], names[17]
+	if family == "" {
+		family, subfamily = names[1], names[2]
+	}
+	if subfamily == "" {
+		subfamily = names[2]
+	}
+	return strings.TrimSpace(family), strings.TrimSpace(subfamily), nil
+}
+
+func decodeFontName(data []byte, platformID uint16) string {
+	if platformID != 3 {
+		runes := make([]rune, len(data))
+		for i, b := range data {
+			runes[i] = rune(b)
+		}
+		return string(runes)
+	}
+	units := make([]uint16, len(data)/2)
+	for i := range units {
+		units[i] = binary.BigEndian.Uint16(data[i*2 : i*2+2])
+	}
+	return string(utf16.Decode(units))
+}
+
+type FontCache struct {
+	fonts map[fontCacheKey]*ttf.Font
+}
+
+type fontCacheKey struct {
+	family string
+	path   string
+	size   int
+	style  int
+}
+
+func NewFontCache() *FontCache {
+	return &FontCache{fonts: make(map[fontCacheKey]*ttf.Font)}
+}
+
+func (cache *FontCache) Get(family FontFamily, size int, style int) (*ttf.Font, error) {
+	key := fontCacheKey{family: family.Name, path: family.Path, size: size, style: style}
+	if font, ok := cache.fonts[key]; ok {
+		return font, nil
+	}
+	font, err := family.Load(size)
+	if err != nil {
+		return nil, err
+	}
+	font.SetStyle(style)
+	cache.fonts[key] = font
+	return font, nil
+}
+
+func (cache *FontCache) Close() {
+	for key, font := range cache.fonts {
+		font.Close()
+		delete(cache.fonts, key)
+	}
+}
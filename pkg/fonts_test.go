@@ -0,0 +1,160 @@
+package pkg
+
+import (
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+	"unicode/utf16"
+)
+
+const testFontPath string = "../assets/fonts/defaultAppFont.ttf"
+
+type testFontName struct {
+	platformID uint16
+	languageID uint16
+	nameID     uint16
+	value      string
+}
+
+// Builds a font file with only the table directory and the "name" table,
+// nameLength overrides the length written to the directory when it's not zero
+func buildTestFont(names []testFontName, nameLength uint32) []byte {
+	records := make([]byte, 0, 12*len(names))
+	strs := make([]byte, 0)
+	for _, name := range names {
+		var encoded []byte
+		if name.platformID == 3 {
+			for _, unit := range utf16.Encode([]rune(name.value)) {
+				encoded = binary.BigEndian.AppendUint16(encoded, unit)
+			}
+		} else {
+			encoded = []byte(name.value)
+		}
+		record := make([]byte, 12)
+		binary.BigEndian.PutUint16(record[0:2], name.platformID)
+		binary.BigEndian.PutUint16(record[4:6], name.languageID)
+		binary.BigEndian.PutUint16(record[6:8], name.nameID)
+		binary.BigEndian.PutUint16(record[8:10], uint16(len(encoded)))
+		binary.BigEndian.PutUint16(record[10:12], uint16(len(strs)))
+		records = append(records, record...)
+		strs = append(strs, encoded...)
+	}
+	nameTable := make([]byte, 6)
+	binary.BigEndian.PutUint16(nameTable[2:4], uint16(len(names)))
+	binary.BigEndian.PutUint16(nameTable[4:6], uint16(6+len(records)))
+	nameTable = append(append(nameTable, records...), strs...)
+	if nameLength == 0 {
+		nameLength = uint32(len(nameTable))
+	}
+
+	font := make([]byte, 12+16)
+	binary.BigEndian.PutUint32(font[0:4], 0x00010000)
+	binary.BigEndian.PutUint16(font[4:6], 1)
+	copy(font[12:16], "name")
+	binary.BigEndian.PutUint32(font[20:24], uint32(len(font)))
+	binary.BigEndian.PutUint32(font[24:28], nameLength)
+	return append(font, nameTable...)
+}
+
+func TestReadFontNames(t *testing.T) {
+	tests := []struct {
+		name          string
+		font          []byte
+		wantFamily    string
+		wantSubfamily string
+		wantErr       bool
+	}{
+		{
+			name: "windows names",
+			font: buildTestFont([]testFontName{
+				{platformID: 3, languageID: 0x409, nameID: 1, value: "Test Sans"},
+				{platformID: 3, languageID: 0x409, nameID: 2, value: "Bold"},
+			}, 0),
+			wantFamily: "Test Sans", wantSubfamily: "Bold",
+		},
+		{
+			name: "typographic names group the weights",
+			font: buildTestFont([]testFontName{
+				{platformID: 3, languageID: 0x409, nameID: 1, value: "Test Sans Light"},
+				{platformID: 3, languageID: 0x409, nameID: 2, value: "Regular"},
+				{platformID: 3, languageID: 0x409, nameID: 16, value: "Test Sans"},
+				{platformID: 3, languageID: 0x409, nameID: 17, value: "Light"},
+			}, 0),
+			wantFamily: "Test Sans", wantSubfamily: "Light",
+		},
+		{
+			name: "typographic family without subfamily",
+			font: buildTestFont([]testFontName{
+				{platformID: 3, languageID: 0x409, nameID: 2, value: "Italic"},
+				{platformID: 3, languageID: 0x409, nameID: 16, value: "Test Sans"},
+			}, 0),
+			wantFamily: "Test Sans", wantSubfamily: "Italic",
+		},
+		{
+			name: "english names are preferred",
+			font: buildTestFont([]testFontName{
+				{platformID: 1, languageID: 0, nameID: 1, value: "Mac Name"},
+				{platformID: 3, languageID: 0x407, nameID: 1, value: "Deutscher Name"},
+				{platformID: 3, languageID: 0x409, nameID: 1, value: "English Name"},
+				{platformID: 3, languageID: 0x40C, nameID: 1, value: "Nom"},
+			}, 0),
+			wantFamily: "English Name",
+		},
+		{
+			name: "macintosh names",
+			font: buildTestFont([]testFontName{
+				{platformID: 1, languageID: 0, nameID: 1, value: " Old Style "},
+				{platformID: 1, languageID: 0, nameID: 2, value: "Regular"},
+			}, 0),
+			wantFamily: "Old Style", wantSubfamily: "Regular",
+		},
+		{
+			name: "unknown platforms are skipped",
+			font: buildTestFont([]testFontName{
+				{platformID: 0, languageID: 0, nameID: 1, value: "Unicode Name"},
+			}, 0),
+		},
+		{
+			name:    "name table out of the file",
+			font:    buildTestFont([]testFontName{{platformID: 3, languageID: 0x409, nameID: 1, value: "Test"}}, 0xFFFFFFF0),
+			wantErr: true,
+		},
+		{
+			name:    "no name table",
+			font:    buildTestFont(nil, 4),
+			wantErr: true,
+		},
+		{
+			name:    "truncated header",
+			font:    []byte{0, 1, 0, 0},
+			wantErr: true,
+		},
+	}
+	dir := t.TempDir()
+	for i, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(dir, string(rune('a'+i))+".ttf")
+			if err := os.WriteFile(path, test.font, 0o644); err != nil {
+				t.Fatal(err)
+			}
+			family, subfamily, err := readFontNames(path)
+			if test.wantErr {
+				if err == nil {
+					t.Errorf("readFontNames() = %q, %q, want an error", family, subfamily)
+				}
+				return
+			}
+			if err != nil || family != test.wantFamily || subfamily != test.wantSubfamily {
+				t.Errorf("readFontNames() = %q, %q, %v, want %q, %q", family, subfamily, err, test.wantFamily, test.wantSubfamily)
+			}
+		})
+	}
+}
+
+func TestReadEmbeddedFontNames(t *testing.T) {
+	family, _, err := readFontNames(testFontPath)
+	if err != nil || family == "" {
+		t.Errorf("readFontNames(%q) = %q, %v, want the family name", testFontPath, family, err)
+	}
+}
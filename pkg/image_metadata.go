@@ -0,0 +1,283 @@
+package pkg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/xml"
+	"fmt"
+	"hash/crc32"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf16"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+const xmpNamespace string = "https://github.com/Wine1y/trigat/xmp/1.0/"
+const jpegXMPHeader string = "http://ns.adobe.com/xap/1.0/\x00"
+const jpegMaxSegmentLength int = 0xFFFF
+
+var pngSignature = []byte("\x89PNG\r\n\x1a\n")
+
+type ImageMetadata struct {
+	CaptureTime  time.Time
+	DisplayIndex int
+	Selection    sdl.Rect
+	AppVersion   string
+	Comment      string
+}
+
+func (metadata ImageMetadata) software() string {
+	return fmt.Sprintf("Trigat %v", metadata.AppVersion)
+}
+
+func (metadata ImageMetadata) selectionString() string {
+	sel := metadata.Selection
+	return fmt.Sprintf("%v,%v,%v,%v", sel.X, sel.Y, sel.W, sel.H)
+}
+
+func (metadata ImageMetadata) xmpPacket() []byte {
+	packet := &bytes.Buffer{}
+	packet.WriteString("<?xpacket begin=\"\uFEFF\" id=\"W5M0MpCehiHzreSzNTczkc9d\"?>\n")
+	packet.WriteString("<x:xmpmeta xmlns:x=\"adobe:ns:meta/\">\n")
+	packet.WriteString(" <rdf:RDF xmlns:rdf=\"http://www.w3.org/1999/02/22-rdf-syntax-ns#\">\n")
+	packet.WriteString("  <rdf:Description rdf:about=\"\"\n")
+	packet.WriteString("    xmlns:xmp=\"http://ns.adobe.com/xap/1.0/\"\n")
+	packet.WriteString("    xmlns:dc=\"http://purl.org/dc/elements/1.1/\"\n")
+	fmt.Fprintf(packet, "    xmlns:trigat=\"%v\"\n", xmpNamespace)
+	fmt.Fprintf(packet, "    xmp:CreateDate=\"%v\"\n", metadata.CaptureTime.Format(time.RFC3339))
+	fmt.Fprintf(packet, "    xmp:CreatorTool=\"%v\"\n", xmlEscape(metadata.software()))
+	fmt.Fprintf(packet, "    trigat:DisplayIndex=\"%v\"\n", metadata.DisplayIndex)
+	fmt.Fprintf(packet, "    trigat:Selection=\"%v\"", metadata.selectionString())
+	if metadata.Comment == "" {
+		packet.WriteString("/>\n")
+	} else {
+		packet.WriteString(">\n")
+		packet.WriteString("   <dc:description><rdf:Alt><rdf:li xml:lang=\"x-default\">")
+		packet.WriteString(xmlEscape(metadata.Comment))
+		packet.WriteString("</rdf:li></rdf:Alt></dc:description>\n")
+		packet.WriteString("  </rdf:Description>\n")
+	}
+	packet.WriteString(" </rdf:RDF>\n")
+	packet.WriteString("</x:xmpmeta>\n")
+	packet.WriteString("<?xpacket end=\"w\"?>")
+	return packet.Bytes()
+}
+
+func InsertPNGMetadata(encoded []byte, metadata ImageMetadata) ([]byte, error) {
+	ihdrEnd := len(pngSignature) + 8 + 13 + 4
+	if len(encoded) < ihdrEnd || !bytes.Equal(encoded[:len(pngSignature)], pngSignature) {
+		return nil, fmt.Errorf("invalid PNG data")
+	}
+	chunks := &bytes.Buffer{}
+	writePNGTextChunk(chunks, "Creation Time", metadata.CaptureTime.Format(time.RFC1123Z))
+	writePNGTextChunk(chunks, "Software", metadata.software())
+	writePNGTextChunk(chunks, "Trigat Display Index", fmt.Sprint(metadata.DisplayIndex))
+	writePNGTextChunk(chunks, "Trigat Selection", metadata.selectionString())
+	if metadata.Comment != "" {
+		writePNGInternationalTextChunk(chunks, "Comment", metadata.Comment)
+	}
+	writePNGInternationalTextChunk(chunks, "XML:com.adobe.xmp", string(metadata.xmpPacket()))
+
+	result := make([]byte, 0, len(encoded)+chunks.Len())
+	result = append(result, encoded[:ihdrEnd]...)
+	result = append(result, chunks.Bytes()...)
+	result = append(result, encoded[ihdrEnd:]...)
+	return result, nil
+}
+
+func InsertJPEGMetadata(encoded []byte, metadata ImageMetadata) ([]byte, error) {
+	if len(encoded) < 2 || encoded[0] != 0xFF || encoded[1] != 0xD8 {
+		return nil, fmt.Errorf("invalid JPEG data")
+	}
+	exif := fitJPEGSegment(metadata, func(metadata ImageMetadata) []byte {
+		return append([]byte("Exif\x00\x00"), buildExif(metadata)...)
+	})
+	xmp := fitJPEGSegment(metadata, func(metadata ImageMetadata) []byte {
+		return append([]byte(jpegXMPHeader), metadata.xmpPacket()...)
+	})
+	segments := &bytes.Buffer{}
+	if err := writeJPEGSegment(segments, 0xE1, exif); err != nil {
+		return nil, err
+	}
+	if err := writeJPEGSegment(segments, 0xE1, xmp); err != nil {
+		return nil, err
+	}
+
+	result := make([]byte, 0, len(encoded)+segments.Len())
+	result = append(result, encoded[:2]...)
+	result = append(result, segments.Bytes()...)
+	result = append(result, encoded[2:]...)
+	return result, nil
+}
+
+// JPEG segments are limited to 64KB, so long comments are cut to the longest part that fits
+func fitJPEGSegment(metadata ImageMetadata, build func(metadata ImageMetadata) []byte) []byte {
+	data := build(metadata)
+	if len(data)+2 <= jpegMaxSegmentLength {
+		return data
+	}
+	comment := []rune(metadata.Comment)
+	fitting := sort.Search(len(comment)+1, func(length int) bool {
+		metadata.Comment = string(comment[:length])
+		return len(build(metadata))+2 > jpegMaxSegmentLength
+	}) - 1
+	metadata.Comment = string(comment[:Max(fitting, 0)])
+	println(fmt.Sprintf("Comment is too long for JPEG metadata, %v of %v characters are saved", Max(fitting, 0), len(comment)))
+	return build(metadata)
+}
+
+func writePNGChunk(buf *bytes.Buffer, chunkType string, data []byte) {
+	binary.Write(buf, binary.BigEndian, uint32(len(data)))
+	crc := crc32.NewIEEE()
+	crc.Write([]byte(chunkType))
+	crc.Write(data)
+	buf.WriteString(chunkType)
+	buf.Write(data)
+	binary.Write(buf, binary.BigEndian, crc.Sum32())
+}
+
+func writePNGTextChunk(buf *bytes.Buffer, keyword, text string) {
+	data := make([]byte, 0, len(keyword)+len(text)+1)
+	data = append(data, keyword...)
+	data = append(data, 0)
+	data = append(data, toLatin1(text)...)
+	writePNGChunk(buf, "tEXt", data)
+}
+
+func writePNGInternationalTextChunk(buf *bytes.Buffer, keyword, text string) {
+	data := make([]byte, 0, len(keyword)+len(text)+5)
+	data = append(data, keyword...)
+	//Null separator, compression flag, compression method, empty language tag and translated keyword
+	data = append(data, 0, 0, 0, 0, 0)
+	data = append(data, text...)
+	writePNGChunk(buf, "iTXt", data)
+}
+
+func writeJPEGSegment(buf *bytes.Buffer, marker byte, data []byte) error {
+	if len(data)+2 > jpegMaxSegmentLength {
+		return fmt.Errorf("JPEG segment is too large (%v bytes)", len(data))
+	}
+	buf.Write([]byte{0xFF, marker})
+	binary.Write(buf, binary.BigEndian, uint16(len(data)+2))
+	buf.Write(data)
+	return nil
+}
+
+type exifEntry struct {
+	tag       uint16
+	valueType uint16
+	count     uint32
+	value     []byte
+}
+
+const exifTypeASCII uint16 = 2
+const exifTypeLong uint16 = 4
+const exifTypeUndefined uint16 = 7
+
+func newExifASCIIEntry(tag uint16, value string) exifEntry {
+	data := append(toASCII(value), 0)
+	return exifEntry{tag: tag, valueType: exifTypeASCII, count: uint32(len(data)), value: data}
+}
+
+func newExifUserCommentEntry(tag uint16, value string) exifEntry {
+	data := []byte("UNICODE\x00")
+	for _, unit := range utf16.Encode([]rune(value)) {
+		data = binary.LittleEndian.AppendUint16(data, unit)
+	}
+	return exifEntry{tag: tag, valueType: exifTypeUndefined, count: uint32(len(data)), value: data}
+}
+
+func buildExif(metadata ImageMetadata) []byte {
+	exifTime := metadata.CaptureTime.Format("2006:01:02 15:04:05")
+	ifd0 := []exifEntry{
+		newExifASCIIEntry(0x010E, fmt.Sprintf("Display %v, selection %v", metadata.DisplayIndex, metadata.selectionString())),
+		newExifASCIIEntry(0x0131, metadata.software()),
+		newExifASCIIEntry(0x0132, exifTime),
+		{tag: 0x8769, valueType: exifTypeLong, count: 1},
+	}
+	exifIFD := []exifEntry{
+		newExifASCIIEntry(0x9003, exifTime),
+		newExifASCIIEntry(0x9011, metadata.CaptureTime.Format("-07:00")),
+	}
+	if metadata.Comment != "" {
+		exifIFD = append(exifIFD, newExifUserCommentEntry(0x9286, metadata.Comment))
+	}
+
+	const tiffHeaderSize uint32 = 8
+	ifd0Offset := tiffHeaderSize
+	exifIFDOffset := ifd0Offset + exifIFDSize(ifd0)
+	ifd0[len(ifd0)-1].value = binary.LittleEndian.AppendUint32(nil, exifIFDOffset)
+
+	tiff := &bytes.Buffer{}
+	tiff.WriteString("II")
+	binary.Write(tiff, binary.LittleEndian, uint16(42))
+	binary.Write(tiff, binary.LittleEndian, ifd0Offset)
+	writeExifIFD(tiff, ifd0, ifd0Offset)
+	writeExifIFD(tiff, exifIFD, exifIFDOffset)
+	return tiff.Bytes()
+}
+
+func exifIFDSize(entries []exifEntry) uint32 {
+	size := uint32(2 + len(entries)*12 + 4)
+	for _, entry := range entries {
+		if len(entry.value) > 4 {
+			size += uint32(len(entry.value) + len(entry.value)%2)
+		}
+	}
+	return size
+}
+
+func writeExifIFD(buf *bytes.Buffer, entries []exifEntry, offset uint32) {
+	dataOffset := offset + uint32(2+len(entries)*12+4)
+	data := &bytes.Buffer{}
+	binary.Write(buf, binary.LittleEndian, uint16(len(entries)))
+	for _, entry := range entries {
+		binary.Write(buf, binary.LittleEndian, entry.tag)
+		binary.Write(buf, binary.LittleEndian, entry.valueType)
+		binary.Write(buf, binary.LittleEndian, entry.count)
+		if len(entry.value) <= 4 {
+			inline := make([]byte, 4)
+			copy(inline, entry.value)
+			buf.Write(inline)
+			continue
+		}
+		binary.Write(buf, binary.LittleEndian, dataOffset+uint32(data.Len()))
+		data.Write(entry.value)
+		if len(entry.value)%2 != 0 {
+			data.WriteByte(0)
+		}
+	}
+	//No next IFD
+	binary.Write(buf, binary.LittleEndian, uint32(0))
+	buf.Write(data.Bytes())
+}
+
+func xmlEscape(text string) string {
+	escaped := &strings.Builder{}
+	xml.EscapeText(escaped, []byte(text))
+	return escaped.String()
+}
+
+func toLatin1(text string) []byte {
+	result := make([]byte, 0, len(text))
+	for _, rn := range text {
+		if rn > 0xFF {
+			rn = '?'
+		}
+		result = append(result, byte(rn))
+	}
+	return result
+}
+
+func toASCII(text string) []byte {
+	result := make([]byte, 0, len(text))
+	for _, rn := range text {
+		if rn > 0x7F {
+			rn = '?'
+		}
+		result = append(result, byte(rn))
+	}
+	return result
+}
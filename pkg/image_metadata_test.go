@@ -0,0 +1,292 @@
+package pkg
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/xml"
+	"hash/crc32"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"strings"
+	"testing"
+	"time"
+	"unicode/utf16"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+var testMetadata = ImageMetadata{
+	CaptureTime:  time.Date(2023, time.March, 14, 15, 9, 26, 0, time.FixedZone("", 3*60*60)),
+	DisplayIndex: 1,
+	Selection:    sdl.Rect{X: 10, Y: 20, W: 300, H: 200},
+	AppVersion:   "1.2.0",
+	Comment:      "Résumé <draft> & 日本語",
+}
+
+func testImage() *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, 16, 8))
+	for i := range img.Pix {
+		img.Pix[i] = uint8(i)
+	}
+	return img
+}
+
+// Reads the chunks after the signature, checking their CRC, and returns the text ones by keyword
+func readPNGTextChunks(t *testing.T, data []byte) map[string]string {
+	texts := make(map[string]string)
+	data = data[len(pngSignature):]
+	for len(data) >= 12 {
+		length := int(binary.BigEndian.Uint32(data))
+		chunkType, chunkData := string(data[4:8]), data[8:8+length]
+		if crc := binary.BigEndian.Uint32(data[8+length:]); crc != crc32.ChecksumIEEE(data[4:8+length]) {
+			t.Fatalf("%v chunk has a wrong CRC", chunkType)
+		}
+		keyword, text, _ := bytes.Cut(chunkData, []byte{0})
+		switch chunkType {
+		case "tEXt":
+			//Latin-1 is decoded rune by rune
+			runes := make([]rune, len(text))
+			for i, b := range text {
+				runes[i] = rune(b)
+			}
+			texts[string(keyword)] = string(runes)
+		case "iTXt":
+			if text[0] != 0 {
+				t.Fatalf("iTXt chunk %q is compressed", keyword)
+			}
+			_, text, _ = bytes.Cut(text[2:], []byte{0})
+			_, text, _ = bytes.Cut(text, []byte{0})
+			texts[string(keyword)] = string(text)
+		}
+		data = data[12+length:]
+	}
+	return texts
+}
+
+// Returns the APP1 segments before the scan data
+func readJPEGAppSegments(t *testing.T, data []byte) [][]byte {
+	segments := make([][]byte, 0)
+	data = data[2:]
+	for len(data) >= 4 && data[0] == 0xFF && data[1] != 0xDA {
+		length := int(binary.BigEndian.Uint16(data[2:]))
+		if length < 2 || 2+length > len(data) {
+			t.Fatalf("JPEG segment %X has an invalid length %v", data[1], length)
+		}
+		if data[1] == 0xE1 {
+			segments = append(segments, data[4:2+length])
+		}
+		data = data[2+length:]
+	}
+	return segments
+}
+
+// Reads a little endian IFD and returns the values by tag, following the Exif IFD pointer
+func readExifIFD(t *testing.T, tiff []byte, offset uint32, values map[uint16][]byte) {
+	count := int(binary.LittleEndian.Uint16(tiff[offset:]))
+	for i := 0; i < count; i++ {
+		entry := tiff[int(offset)+2+i*12:]
+		tag, valueType := binary.LittleEndian.Uint16(entry), binary.LittleEndian.Uint16(entry[2:])
+		size := binary.LittleEndian.Uint32(entry[4:])
+		if valueType == exifTypeLong {
+			size *= 4
+		}
+		value := entry[8:12]
+		if size > 4 {
+			valueOffset := binary.LittleEndian.Uint32(entry[8:])
+			if int(valueOffset+size) > len(tiff) {
+				t.Fatalf("Exif tag %X points out of the data", tag)
+			}
+			value = tiff[valueOffset : valueOffset+size]
+		}
+		values[tag] = value[:size]
+		if tag == 0x8769 {
+			readExifIFD(t, tiff, binary.LittleEndian.Uint32(value), values)
+		}
+	}
+}
+
+func checkXMPPacket(t *testing.T, packet string, metadata ImageMetadata) {
+	decoder := xml.NewDecoder(strings.NewReader(packet))
+	attributes := make(map[string]string)
+	description := ""
+	for {
+		token, err := decoder.Token()
+		if err != nil {
+			if err != io.EOF {
+				t.Fatalf("XMP packet is not well formed: %v", err)
+			}
+			break
+		}
+		switch token := token.(type) {
+		case xml.StartElement:
+			for _, attr := range token.Attr {
+				attributes[attr.Name.Local] = attr.Value
+			}
+			if token.Name.Local == "li" {
+				var text string
+				if err := decoder.DecodeElement(&text, &token); err != nil {
+					t.Fatalf("Can't read XMP description: %v", err)
+				}
+				description = text
+			}
+		}
+	}
+	want := map[string]string{
+		"CreateDate":   metadata.CaptureTime.Format(time.RFC3339),
+		"CreatorTool":  "Trigat " + metadata.AppVersion,
+		"DisplayIndex": "1",
+		"Selection":    "10,20,300,200",
+	}
+	for name, value := range want {
+		if attributes[name] != value {
+			t.Errorf("XMP %v = %q, want %q", name, attributes[name], value)
+		}
+	}
+	if description != metadata.Comment {
+		t.Errorf("XMP description = %q, want %q", description, metadata.Comment)
+	}
+}
+
+func TestInsertPNGMetadata(t *testing.T) {
+	encoded := &bytes.Buffer{}
+	if err := png.Encode(encoded, testImage()); err != nil {
+		t.Fatal(err)
+	}
+	data, err := InsertPNGMetadata(encoded.Bytes(), testMetadata)
+	if err != nil {
+		t.Fatalf("InsertPNGMetadata() error = %v", err)
+	}
+	if decoded, err := png.Decode(bytes.NewReader(data)); err != nil || decoded.Bounds() != testImage().Bounds() {
+		t.Fatalf("png.Decode() of the image with metadata = %v, want the original image", err)
+	}
+
+	texts := readPNGTextChunks(t, data)
+	want := map[string]string{
+		"Creation Time":        "Tue, 14 Mar 2023 15:09:26 +0300",
+		"Software":             "Trigat 1.2.0",
+		"Trigat Display Index": "1",
+		"Trigat Selection":     "10,20,300,200",
+		"Comment":              testMetadata.Comment,
+	}
+	for keyword, text := range want {
+		if texts[keyword] != text {
+			t.Errorf("PNG text %q = %q, want %q", keyword, texts[keyword], text)
+		}
+	}
+	checkXMPPacket(t, texts["XML:com.adobe.xmp"], testMetadata)
+
+	if _, err := InsertPNGMetadata([]byte("not a png"), testMetadata); err == nil {
+		t.Errorf("InsertPNGMetadata() of invalid data returned no error")
+	}
+}
+
+func TestInsertPNGMetadataWithoutComment(t *testing.T) {
+	encoded := &bytes.Buffer{}
+	if err := png.Encode(encoded, testImage()); err != nil {
+		t.Fatal(err)
+	}
+	metadata := testMetadata
+	metadata.Comment = ""
+	data, err := InsertPNGMetadata(encoded.Bytes(), metadata)
+	if err != nil {
+		t.Fatalf("InsertPNGMetadata() error = %v", err)
+	}
+	texts := readPNGTextChunks(t, data)
+	if comment, found := texts["Comment"]; found {
+		t.Errorf("PNG has a comment %q, want none", comment)
+	}
+	checkXMPPacket(t, texts["XML:com.adobe.xmp"], metadata)
+}
+
+func TestInsertJPEGMetadata(t *testing.T) {
+	encoded := &bytes.Buffer{}
+	if err := jpeg.Encode(encoded, testImage(), nil); err != nil {
+		t.Fatal(err)
+	}
+	data, err := InsertJPEGMetadata(encoded.Bytes(), testMetadata)
+	if err != nil {
+		t.Fatalf("InsertJPEGMetadata() error = %v", err)
+	}
+	if decoded, err := jpeg.Decode(bytes.NewReader(data)); err != nil || decoded.Bounds() != testImage().Bounds() {
+		t.Fatalf("jpeg.Decode() of the image with metadata = %v, want the original image", err)
+	}
+
+	var exif, xmp []byte
+	for _, segment := range readJPEGAppSegments(t, data) {
+		if bytes.HasPrefix(segment, []byte("Exif\x00\x00")) {
+			exif = segment[6:]
+		}
+		if bytes.HasPrefix(segment, []byte(jpegXMPHeader)) {
+			xmp = segment[len(jpegXMPHeader):]
+		}
+	}
+	if exif == nil || xmp == nil {
+		t.Fatalf("JPEG has no Exif or XMP segment")
+	}
+
+	if string(exif[:4]) != "II*\x00" {
+		t.Fatalf("Exif has an invalid TIFF header %q", exif[:4])
+	}
+	values := make(map[uint16][]byte)
+	readExifIFD(t, exif, binary.LittleEndian.Uint32(exif[4:]), values)
+	want := map[uint16]string{
+		0x010E: "Display 1, selection 10,20,300,200\x00",
+		0x0131: "Trigat 1.2.0\x00",
+		0x0132: "2023:03:14 15:09:26\x00",
+		0x9003: "2023:03:14 15:09:26\x00",
+		0x9011: "+03:00\x00",
+	}
+	for tag, value := range want {
+		if string(values[tag]) != value {
+			t.Errorf("Exif tag %X = %q, want %q", tag, values[tag], value)
+		}
+	}
+	if !bytes.HasPrefix(values[0x9286], []byte("UNICODE\x00")) {
+		t.Fatalf("Exif user comment %q has no UNICODE prefix", values[0x9286])
+	}
+	comment := values[0x9286][8:]
+	units := make([]uint16, len(comment)/2)
+	for i := range units {
+		units[i] = binary.LittleEndian.Uint16(comment[i*2:])
+	}
+	if text := string(utf16.Decode(units)); text != testMetadata.Comment {
+		t.Errorf("Exif user comment = %q, want %q", text, testMetadata.Comment)
+	}
+	checkXMPPacket(t, string(xmp), testMetadata)
+
+	if _, err := InsertJPEGMetadata([]byte("not a jpeg"), testMetadata); err == nil {
+		t.Errorf("InsertJPEGMetadata() of invalid data returned no error")
+	}
+}
+
+func TestInsertJPEGMetadataLongComment(t *testing.T) {
+	encoded := &bytes.Buffer{}
+	if err := jpeg.Encode(encoded, testImage(), nil); err != nil {
+		t.Fatal(err)
+	}
+	metadata := testMetadata
+	metadata.Comment = strings.Repeat("Long comment & <markup> ", 4000)
+	data, err := InsertJPEGMetadata(encoded.Bytes(), metadata)
+	if err != nil {
+		t.Fatalf("InsertJPEGMetadata() error = %v", err)
+	}
+	if _, err := jpeg.Decode(bytes.NewReader(data)); err != nil {
+		t.Fatalf("jpeg.Decode() of the image with metadata = %v", err)
+	}
+
+	segments := readJPEGAppSegments(t, data)
+	if len(segments) != 2 {
+		t.Fatalf("JPEG has %v APP1 segments, want Exif and XMP", len(segments))
+	}
+	for _, segment := range segments {
+		//Cut comments should still fill most of the segment
+		if len(segment)+2 < jpegMaxSegmentLength-100 {
+			t.Errorf("APP1 segment is %v bytes long, want the comment cut close to %v", len(segment), jpegMaxSegmentLength)
+		}
+	}
+	if xmp := string(segments[1][len(jpegXMPHeader):]); !strings.Contains(xmp, "Long comment &amp; &lt;markup&gt;") {
+		t.Errorf("XMP packet has no part of the comment")
+	}
+}
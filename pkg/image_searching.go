@@ -2,84 +2,132 @@ package pkg
 
 import (
 	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
 	"io"
 	"mime/multipart"
 	"net/http"
+	"net/url"
+	"strings"
 	"time"
 
 	"github.com/pkg/browser"
 )
 
-var imgBBUploadUrl = "https://imgbb.com/json"
-var googleSearchUrl = "https://lens.google.com/uploadbyurl"
+const searchURLPlaceholder string = "{url}"
+const searchTimestampPlaceholder string = "{timestamp}"
 
-type imgBBResponse struct {
-	Image struct {
-		URL       string `json:"url"`
-		DeleteUrl string `json:"delete_url"`
-	}
+var GoogleLensSearchEngine = SearchEngine{
+	Name:        "Google Lens",
+	URLTemplate: "https://lens.google.com/uploadbyurl?hl=en&st={timestamp}&url={url}",
+	UploadURL:   "https://lens.google.com/v3/upload?hl=en&st={timestamp}",
+	UploadField: "encoded_image",
+}
+var BingSearchEngine = SearchEngine{
+	Name:        "Bing Visual Search",
+	URLTemplate: "https://www.bing.com/images/search?view=detailv2&iss=sbi&form=SBIVSP&sbisrc=UrlPaste&q=imgurl:{url}",
+}
+var YandexSearchEngine = SearchEngine{
+	Name:        "Yandex Images",
+	URLTemplate: "https://yandex.com/images/search?rpt=imageview&url={url}",
+}
+var TinEyeSearchEngine = SearchEngine{
+	Name:        "TinEye",
+	URLTemplate: "https://tineye.com/search?url={url}",
+	UploadURL:   "https://tineye.com/search",
+	UploadField: "image",
 }
 
-func UploadImage(img io.Reader) (string, error) {
-	form, body, err := buildImgBBForm(img)
-	if err != nil {
-		return "", err
-	}
-	defer form.Close()
+var DefaultSearchEngines = []SearchEngine{
+	GoogleLensSearchEngine,
+	BingSearchEngine,
+	YandexSearchEngine,
+	TinEyeSearchEngine,
+}
 
-	resp, err := http.Post(imgBBUploadUrl, form.FormDataContentType(), body)
-	if err != nil {
-		panic(err)
-	}
-	if resp.StatusCode != 200 {
-		return "", fmt.Errorf("ImgBB returned %v status code", resp.StatusCode)
-	}
-	defer resp.Body.Close()
-	var respData imgBBResponse
-	if err := json.NewDecoder(resp.Body).Decode(&respData); err != nil {
-		return "", err
-	}
-	return respData.Image.URL, nil
+type SearchEngine struct {
+	Name        string
+	URLTemplate string
+	UploadURL   string
+	UploadField string
+	ResultPath  string
 }
 
-func GetSearchURL(imageURL string) string {
-	timestamp := time.Now().UnixMilli()
-	return fmt.Sprintf(
-		"%v?hl=en&re=df&vpw=1920&vph=1080&st=%v&url=%v",
-		googleSearchUrl,
-		timestamp,
-		imageURL,
-	)
+func (engine SearchEngine) SearchURL(imageURL string) (string, error) {
+	if !strings.Contains(engine.URLTemplate, searchURLPlaceholder) {
+		return "", fmt.Errorf("%v URL template has no %v placeholder", engine.Name, searchURLPlaceholder)
+	}
+	searchURL := strings.ReplaceAll(engine.URLTemplate, searchURLPlaceholder, url.QueryEscape(imageURL))
+	searchURL = strings.ReplaceAll(searchURL, searchTimestampPlaceholder, fmt.Sprint(time.Now().UnixMilli()))
+	return searchURL, nil
 }
 
-func OpenUrlInBrowser(url string) {
-	browser.OpenURL(url)
+func (engine SearchEngine) SupportsDirectUpload() bool {
+	return engine.UploadURL != "" && engine.UploadField != ""
 }
 
-func buildImgBBForm(img io.Reader) (*multipart.Writer, *bytes.Buffer, error) {
+// Results page is taken from the redirect location or from the JSON response if ResultPath is set
+func (engine SearchEngine) DirectSearchURL(ctx context.Context, img io.Reader, client *http.Client) (string, error) {
+	if !engine.SupportsDirectUpload() {
+		return "", fmt.Errorf("%v doesn't accept direct uploads", engine.Name)
+	}
 	body := &bytes.Buffer{}
 	form := multipart.NewWriter(body)
-	fileWriter, err := form.CreateFormFile("source", "screenshot.png")
+	fileWriter, err := form.CreateFormFile(engine.UploadField, uploadFileName)
 	if err != nil {
-		return nil, nil, err
+		return "", err
 	}
-	_, err = io.Copy(fileWriter, img)
-	if err != nil {
-		return nil, nil, err
+	if _, err := io.Copy(fileWriter, img); err != nil {
+		return "", err
 	}
-
-	if err := form.WriteField("upload-expiration", "PT5M"); err != nil {
-		return nil, nil, err
+	if err := form.Close(); err != nil {
+		return "", err
 	}
 
-	if err := form.WriteField("type", "file"); err != nil {
-		return nil, nil, err
+	uploadURL := strings.ReplaceAll(engine.UploadURL, searchTimestampPlaceholder, fmt.Sprint(time.Now().UnixMilli()))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, uploadURL, body)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	noRedirectClient := *httpClient(client)
+	noRedirectClient.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		return http.ErrUseLastResponse
+	}
+	resp, err := noRedirectClient.Do(req)
+	if err != nil {
+		return "", err
 	}
+	defer resp.Body.Close()
 
-	if err := form.WriteField("action", "upload"); err != nil {
-		return nil, nil, err
+	if resp.StatusCode >= 300 && resp.StatusCode <= 399 {
+		location, err := resp.Location()
+		if err != nil {
+			return "", err
+		}
+		return location.String(), nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("%v returned %v status code", engine.Name, resp.StatusCode)
+	}
+	if engine.ResultPath == "" {
+		return "", fmt.Errorf("%v didn't redirect to the results page", engine.Name)
+	}
+	respData, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
+	}
+	resultURL, err := LookupJSONPathString(respData, engine.ResultPath)
+	if err != nil {
+		return "", err
 	}
-	return form, body, nil
+	parsedURL, err := resp.Request.URL.Parse(resultURL)
+	if err != nil {
+		return "", err
+	}
+	return parsedURL.String(), nil
+}
+
+func OpenUrlInBrowser(url string) {
+	browser.OpenURL(url)
 }
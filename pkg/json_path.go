@@ -0,0 +1,87 @@
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+func LookupJSONPathString(data []byte, path string) (string, error) {
+	var document interface{}
+	if err := json.Unmarshal(data, &document); err != nil {
+		return "", err
+	}
+	value, err := LookupJSONPath(document, path)
+	if err != nil {
+		return "", err
+	}
+	str, ok := value.(string)
+	if !ok {
+		return "", fmt.Errorf("value at %v is not a string", path)
+	}
+	return str, nil
+}
+
+// Supports the dot and bracket subset of JSONPath: $.data.links[0].url, $['data']['url']
+func LookupJSONPath(document interface{}, path string) (interface{}, error) {
+	steps, err := parseJSONPath(path)
+	if err != nil {
+		return nil, err
+	}
+	current := document
+	for _, step := range steps {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			value, found := node[step]
+			if !found {
+				return nil, fmt.Errorf("key %q not found in %v", step, path)
+			}
+			current = value
+		case []interface{}:
+			index, err := strconv.Atoi(step)
+			if err != nil || index < 0 || index >= len(node) {
+				return nil, fmt.Errorf("invalid index %q in %v", step, path)
+			}
+			current = node[index]
+		default:
+			return nil, fmt.Errorf("can't resolve %q in %v", step, path)
+		}
+	}
+	return current, nil
+}
+
+func parseJSONPath(path string) ([]string, error) {
+	path = strings.TrimPrefix(strings.TrimSpace(path), "$")
+	steps := make([]string, 0)
+	for len(path) > 0 {
+		switch path[0] {
+		case '.':
+			path = path[1:]
+			end := strings.IndexAny(path, ".[")
+			if end == -1 {
+				end = len(path)
+			}
+			if end == 0 {
+				return nil, fmt.Errorf("empty key in JSON path")
+			}
+			steps = append(steps, path[:end])
+			path = path[end:]
+		case '[':
+			end := strings.IndexByte(path, ']')
+			if end == -1 {
+				return nil, fmt.Errorf("unclosed bracket in JSON path")
+			}
+			steps = append(steps, strings.Trim(path[1:end], "'\""))
+			path = path[end+1:]
+		default:
+			end := strings.IndexAny(path, ".[")
+			if end == -1 {
+				end = len(path)
+			}
+			steps = append(steps, path[:end])
+			path = path[end:]
+		}
+	}
+	return steps, nil
+}
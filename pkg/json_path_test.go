@@ -0,0 +1,77 @@
+package pkg
+
+import (
+	"testing"
+)
+
+const testJSONDocument string = `{
+	"data": {
+		"url": "https://example.com/a.png",
+		"links": [{"url": "https://example.com/0"}, {"url": "https://example.com/1"}],
+		"dotted.key": "dotted",
+		"size": 42
+	},
+	"items": ["first", "second"]
+}`
+
+func TestLookupJSONPathString(t *testing.T) {
+	tests := []struct {
+		path    string
+		want    string
+		wantErr bool
+	}{
+		{path: "$.data.url", want: "https://example.com/a.png"},
+		{path: "data.url", want: "https://example.com/a.png"},
+		{path: " $.data.url ", want: "https://example.com/a.png"},
+		{path: "$.data.links[1].url", want: "https://example.com/1"},
+		{path: "$['data']['links'][0]['url']", want: "https://example.com/0"},
+		{path: `$["data"]["url"]`, want: "https://example.com/a.png"},
+		{path: "$.data['dotted.key']", want: "dotted"},
+		{path: "$.items[0]", want: "first"},
+		{path: "$.data.missing", wantErr: true},
+		{path: "$.items[2]", wantErr: true},
+		{path: "$.items[-1]", wantErr: true},
+		{path: "$.items.first", wantErr: true},
+		{path: "$.data.url.host", wantErr: true},
+		{path: "$.data.size", wantErr: true},
+		{path: "$.data", wantErr: true},
+		{path: "$..url", wantErr: true},
+		{path: "$.data['url'", wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			value, err := LookupJSONPathString([]byte(testJSONDocument), test.path)
+			if test.wantErr {
+				if err == nil {
+					t.Errorf("LookupJSONPathString(%q) = %q, want an error", test.path, value)
+				}
+				return
+			}
+			if err != nil || value != test.want {
+				t.Errorf("LookupJSONPathString(%q) = %q, %v, want %q", test.path, value, err, test.want)
+			}
+		})
+	}
+}
+
+func TestLookupJSONPathStringInvalidDocument(t *testing.T) {
+	if value, err := LookupJSONPathString([]byte(`{"url": `), "$.url"); err == nil {
+		t.Errorf("LookupJSONPathString() of a broken document = %q, want an error", value)
+	}
+}
+
+func TestLookupJSONPath(t *testing.T) {
+	document := map[string]interface{}{
+		"size": 42.0,
+		"tags": []interface{}{"a", "b"},
+	}
+	if value, err := LookupJSONPath(document, "$.size"); err != nil || value != 42.0 {
+		t.Errorf("LookupJSONPath($.size) = %v, %v, want 42", value, err)
+	}
+	if value, err := LookupJSONPath(document, "$"); err != nil || value == nil {
+		t.Errorf("LookupJSONPath($) = %v, %v, want the document", value, err)
+	}
+	if value, err := LookupJSONPath(document, "$.tags[1]"); err != nil || value != "b" {
+		t.Errorf("LookupJSONPath($.tags[1]) = %v, %v, want \"b\"", value, err)
+	}
+}
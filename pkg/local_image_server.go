@@ -0,0 +1,113 @@
+package pkg
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const localImageTokenSize int = 16
+
+type LocalImageServer struct {
+	URL    string
+	server *http.Server
+}
+
+func ServeImageLocally(img []byte, contentType, address, publicURL string, lifetime time.Duration) (*LocalImageServer, error) {
+	tokenBytes := make([]byte, localImageTokenSize)
+	if _, err := rand.Read(tokenBytes); err != nil {
+		return nil, err
+	}
+	imagePath := fmt.Sprintf("/%v.png", hex.EncodeToString(tokenBytes))
+
+	listener, err := net.Listen("tcp", address)
+	if err != nil {
+		return nil, err
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc(imagePath, func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", contentType)
+		w.Header().Set("Cache-Control", "no-store")
+		w.Header().Set("Content-Length", fmt.Sprint(len(img)))
+		if r.Method == http.MethodGet {
+			w.Write(img)
+		}
+	})
+
+	baseURL := strings.TrimRight(publicURL, "/")
+	if baseURL == "" {
+		host, err := advertisedAddress(listener.Addr().(*net.TCPAddr))
+		if err != nil {
+			listener.Close()
+			return nil, err
+		}
+		baseURL = fmt.Sprintf("http://%v", host)
+	}
+	imageServer := LocalImageServer{
+		URL:    baseURL + imagePath,
+		server: &http.Server{Handler: mux, ReadHeaderTimeout: time.Second * 10},
+	}
+	go imageServer.server.Serve(listener)
+	time.AfterFunc(lifetime, func() { imageServer.Close() })
+	return &imageServer, nil
+}
+
+func (imageServer *LocalImageServer) Close() error {
+	return imageServer.server.Close()
+}
+
+// Servers listening on all interfaces are advertised with the address of a network interface,
+// search engines can't fetch images from 0.0.0.0 or [::]
+func advertisedAddress(addr *net.TCPAddr) (string, error) {
+	ip := addr.IP
+	if ip.IsUnspecified() {
+		var err error
+		if ip, err = interfaceIP(); err != nil {
+			return "", err
+		}
+	}
+	return net.JoinHostPort(ip.String(), strconv.Itoa(addr.Port)), nil
+}
+
+// Returns the first address of an active non-loopback interface, IPv4 addresses are preferred
+func interfaceIP() (net.IP, error) {
+	interfaces, err := net.Interfaces()
+	if err != nil {
+		return nil, err
+	}
+	var ipv6 net.IP
+	for _, iface := range interfaces {
+		if iface.Flags&net.FlagUp == 0 || iface.Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		addrs, err := iface.Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			ipNet, ok := addr.(*net.IPNet)
+			if !ok || !ipNet.IP.IsGlobalUnicast() {
+				continue
+			}
+			if ipNet.IP.To4() != nil {
+				return ipNet.IP, nil
+			}
+			if ipv6 == nil {
+				ipv6 = ipNet.IP
+			}
+		}
+	}
+	if ipv6 == nil {
+		return nil, fmt.Errorf("no network interface to serve the image on")
+	}
+	return ipv6, nil
+}
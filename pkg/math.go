@@ -1,6 +1,10 @@
 package pkg
 
-import "github.com/veandco/go-sdl2/sdl"
+import (
+	"math"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
 
 type SInteger interface {
 	int | int8 | int16 | int32 | int64
@@ -73,6 +77,36 @@ func RectIntoSquare(rect *sdl.Rect) {
 	}
 }
 
+// Returns the same rect with non-negative width and height
+func NormalizedRect(rect *sdl.Rect) sdl.Rect {
+	normalized := *rect
+	if normalized.W < 0 {
+		normalized.X, normalized.W = normalized.X+normalized.W, -normalized.W
+	}
+	if normalized.H < 0 {
+		normalized.Y, normalized.H = normalized.Y+normalized.H, -normalized.H
+	}
+	return normalized
+}
+
+// Returns the distance from the point to the closest point of the segment
+func SegmentDistance(px, py, ax, ay, bx, by float64) float64 {
+	dx, dy := bx-ax, by-ay
+	lengthSquared := dx*dx + dy*dy
+	if lengthSquared == 0 {
+		return math.Hypot(px-ax, py-ay)
+	}
+	t := Clamp(0, ((px-ax)*dx+(py-ay)*dy)/lengthSquared, 1)
+	return math.Hypot(px-ax-t*dx, py-ay-t*dy)
+}
+
+func CircleIntersectsRect(center sdl.Point, radius int32, rect *sdl.Rect) bool {
+	normalized := NormalizedRect(rect)
+	closestX := Clamp(normalized.X, center.X, normalized.X+normalized.W)
+	closestY := Clamp(normalized.Y, center.Y, normalized.Y+normalized.H)
+	return math.Hypot(float64(center.X-closestX), float64(center.Y-closestY)) <= float64(radius)
+}
+
 func Linspace(start, stop float64, num int) []float64 {
 	num_f := float64(num)
 	h := (stop - start) / (num_f - 1)
@@ -0,0 +1,61 @@
+package pkg
+
+import (
+	"math"
+	"testing"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+func TestSegmentDistance(t *testing.T) {
+	tests := []struct {
+		name                   string
+		px, py, ax, ay, bx, by float64
+		want                   float64
+	}{
+		{"on the segment", 5, 0, 0, 0, 10, 0, 0},
+		{"above the middle", 5, 3, 0, 0, 10, 0, 3},
+		{"behind the start", -3, 4, 0, 0, 10, 0, 5},
+		{"behind the end", 13, -4, 0, 0, 10, 0, 5},
+		{"diagonal segment", 0, 10, 0, 0, 10, 10, math.Sqrt(50)},
+		{"point segment", 3, 4, 0, 0, 0, 0, 5},
+		{"reversed segment", 5, 3, 10, 0, 0, 0, 3},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			distance := SegmentDistance(test.px, test.py, test.ax, test.ay, test.bx, test.by)
+			if math.Abs(distance-test.want) > 1e-9 {
+				t.Errorf("SegmentDistance = %v, want %v", distance, test.want)
+			}
+		})
+	}
+}
+
+func TestCircleIntersectsRect(t *testing.T) {
+	rect := sdl.Rect{X: 10, Y: 10, W: 20, H: 10}
+	tests := []struct {
+		name   string
+		center sdl.Point
+		radius int32
+		rect   sdl.Rect
+		want   bool
+	}{
+		{"center inside", sdl.Point{X: 15, Y: 15}, 1, rect, true},
+		{"touches the left side", sdl.Point{X: 5, Y: 15}, 5, rect, true},
+		{"misses the left side", sdl.Point{X: 4, Y: 15}, 5, rect, false},
+		{"touches the bottom side", sdl.Point{X: 20, Y: 25}, 5, rect, true},
+		{"misses the bottom side", sdl.Point{X: 20, Y: 26}, 5, rect, false},
+		{"reaches the corner", sdl.Point{X: 33, Y: 24}, 5, rect, true},
+		{"misses the corner", sdl.Point{X: 34, Y: 24}, 5, rect, false},
+		{"zero radius on the edge", sdl.Point{X: 30, Y: 20}, 0, rect, true},
+		{"negative size", sdl.Point{X: 15, Y: 15}, 1, sdl.Rect{X: 30, Y: 20, W: -20, H: -10}, true},
+		{"negative size outside", sdl.Point{X: 4, Y: 15}, 5, sdl.Rect{X: 30, Y: 20, W: -20, H: -10}, false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if intersects := CircleIntersectsRect(test.center, test.radius, &test.rect); intersects != test.want {
+				t.Errorf("CircleIntersectsRect(%v, %v, %v) = %v, want %v", test.center, test.radius, test.rect, intersects, test.want)
+			}
+		})
+	}
+}
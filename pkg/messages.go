@@ -0,0 +1,13 @@
+package pkg
+
+import "github.com/sqweek/dialog"
+
+const messageTitle string = "Trigat"
+
+func ShowErrorMessage(format string, args ...interface{}) {
+	dialog.Message(format, args...).Title(messageTitle).Error()
+}
+
+func ShowInfoMessage(format string, args ...interface{}) {
+	dialog.Message(format, args...).Title(messageTitle).Info()
+}
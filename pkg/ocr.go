@@ -0,0 +1,125 @@
+package pkg
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/png"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+const tesseractWordLevel int = 5
+const tesseractTSVColumns int = 12
+
+type OCRWord struct {
+	Text       string
+	BBox       image.Rectangle
+	Confidence float64
+	Line       int
+}
+
+type OCRResult struct {
+	Text  string
+	Words []OCRWord
+}
+
+type OCREngine interface {
+	Recognize(ctx context.Context, img image.Image) (*OCRResult, error)
+}
+
+type TesseractOCREngine struct {
+	Path      string
+	Languages string
+}
+
+func (engine TesseractOCREngine) Recognize(ctx context.Context, img image.Image) (*OCRResult, error) {
+	input := &bytes.Buffer{}
+	if err := png.Encode(input, img); err != nil {
+		return nil, err
+	}
+	path := engine.Path
+	if path == "" {
+		path = "tesseract"
+	}
+	args := []string{"stdin", "stdout"}
+	if engine.Languages != "" {
+		args = append(args, "-l", engine.Languages)
+	}
+	args = append(args, "tsv")
+
+	cmd := exec.CommandContext(ctx, path, args...)
+	cmd.Stdin = input
+	output, stderr := &bytes.Buffer{}, &bytes.Buffer{}
+	cmd.Stdout, cmd.Stderr = output, stderr
+	if err := cmd.Run(); err != nil {
+		if errors.Is(err, exec.ErrNotFound) {
+			return nil, fmt.Errorf("tesseract is not installed or %q is not in PATH", path)
+		}
+		if message := strings.TrimSpace(stderr.String()); message != "" {
+			return nil, fmt.Errorf("%v: %v", err, message)
+		}
+		return nil, err
+	}
+	return parseTesseractTSV(output.String())
+}
+
+func parseTesseractTSV(tsv string) (*OCRResult, error) {
+	result := OCRResult{Words: make([]OCRWord, 0)}
+	text := strings.Builder{}
+	lastBlock, lastLine, lineNumber := "", "", -1
+	for i, row := range strings.Split(tsv, "\n") {
+		columns := strings.Split(strings.TrimRight(row, "\r"), "\t")
+		if i == 0 || len(columns) < tesseractTSVColumns {
+			continue
+		}
+		level, err := strconv.Atoi(columns[0])
+		if err != nil {
+			return nil, fmt.Errorf("invalid tesseract output row %q", row)
+		}
+		wordText := strings.TrimSpace(strings.Join(columns[11:], "\t"))
+		if level != tesseractWordLevel || wordText == "" {
+			continue
+		}
+		bbox := [4]int{}
+		for j := range bbox {
+			if bbox[j], err = strconv.Atoi(columns[6+j]); err != nil {
+				return nil, fmt.Errorf("invalid tesseract output row %q", row)
+			}
+		}
+		confidence, err := strconv.ParseFloat(columns[10], 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tesseract output row %q", row)
+		}
+
+		//Words are grouped by page and block, then by paragraph and line
+		block := strings.Join(columns[1:3], ".")
+		line := strings.Join(columns[1:5], ".")
+		switch {
+		case lineNumber < 0:
+		case block != lastBlock:
+			text.WriteString("\n\n")
+		case line != lastLine:
+			text.WriteString("\n")
+		default:
+			text.WriteString(" ")
+		}
+		if line != lastLine {
+			lineNumber++
+		}
+		lastBlock, lastLine = block, line
+		text.WriteString(wordText)
+
+		result.Words = append(result.Words, OCRWord{
+			Text:       wordText,
+			BBox:       image.Rect(bbox[0], bbox[1], bbox[0]+bbox[2], bbox[1]+bbox[3]),
+			Confidence: confidence,
+			Line:       lineNumber,
+		})
+	}
+	result.Text = text.String()
+	return &result, nil
+}
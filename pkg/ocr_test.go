@@ -0,0 +1,111 @@
+package pkg
+
+import (
+	"image"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const testTSVHeader string = "level\tpage_num\tblock_num\tpar_num\tline_num\tword_num\tleft\ttop\twidth\theight\tconf\ttext"
+
+func testTSV(rows ...string) string {
+	return strings.Join(append([]string{testTSVHeader}, rows...), "\n") + "\n"
+}
+
+func TestParseTesseractTSV(t *testing.T) {
+	tests := []struct {
+		name  string
+		tsv   string
+		text  string
+		words []OCRWord
+	}{
+		{
+			name:  "empty output",
+			tsv:   "",
+			words: []OCRWord{},
+		},
+		{
+			name: "only layout levels",
+			tsv: testTSV(
+				"1\t1\t0\t0\t0\t0\t0\t0\t640\t480\t-1\t",
+				"2\t1\t1\t0\t0\t0\t10\t10\t100\t20\t-1\t",
+				"3\t1\t1\t1\t0\t0\t10\t10\t100\t20\t-1\t",
+				"4\t1\t1\t1\t1\t0\t10\t10\t100\t20\t-1\t",
+			),
+			words: []OCRWord{},
+		},
+		{
+			name: "words of a line",
+			tsv: testTSV(
+				"1\t1\t0\t0\t0\t0\t0\t0\t640\t480\t-1\t",
+				"4\t1\t1\t1\t1\t0\t10\t10\t100\t20\t-1\t",
+				"5\t1\t1\t1\t1\t1\t10\t12\t40\t18\t96.063751\tFile",
+				"5\t1\t1\t1\t1\t2\t55\t10\t55\t20\t91\tnot found",
+			),
+			text: "File not found",
+			words: []OCRWord{
+				{Text: "File", BBox: image.Rect(10, 12, 50, 30), Confidence: 96.063751, Line: 0},
+				{Text: "not found", BBox: image.Rect(55, 10, 110, 30), Confidence: 91, Line: 0},
+			},
+		},
+		{
+			name: "lines, paragraphs and blocks",
+			tsv: testTSV(
+				"5\t1\t1\t1\t1\t1\t0\t0\t10\t10\t90\tone",
+				"5\t1\t1\t1\t2\t1\t0\t20\t10\t10\t90\ttwo",
+				"5\t1\t1\t2\t1\t1\t0\t40\t10\t10\t90\tthree",
+				"5\t1\t2\t1\t1\t1\t0\t80\t10\t10\t90\tfour",
+				"5\t1\t2\t1\t1\t2\t20\t80\t10\t10\t90\tfive",
+			),
+			text: "one\ntwo\nthree\n\nfour five",
+			words: []OCRWord{
+				{Text: "one", BBox: image.Rect(0, 0, 10, 10), Confidence: 90, Line: 0},
+				{Text: "two", BBox: image.Rect(0, 20, 10, 30), Confidence: 90, Line: 1},
+				{Text: "three", BBox: image.Rect(0, 40, 10, 50), Confidence: 90, Line: 2},
+				{Text: "four", BBox: image.Rect(0, 80, 10, 90), Confidence: 90, Line: 3},
+				{Text: "five", BBox: image.Rect(20, 80, 30, 90), Confidence: 90, Line: 3},
+			},
+		},
+		{
+			name: "blank words and short rows are skipped",
+			tsv: testTSV(
+				"5\t1\t1\t1\t1\t1\t0\t0\t10\t10\t-1\t ",
+				"5\t1\t1\t1\t1\t2\t0\t0\t10\t10\t95\t",
+				"5\t1\t1\t1\t1\t3",
+				"5\t1\t1\t1\t2\t1\t0\t20\t10\t10\t0\tfaint\r",
+			),
+			text: "faint",
+			words: []OCRWord{
+				{Text: "faint", BBox: image.Rect(0, 20, 10, 30), Confidence: 0, Line: 0},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			result, err := parseTesseractTSV(test.tsv)
+			if err != nil {
+				t.Fatalf("parseTesseractTSV() error = %v", err)
+			}
+			if result.Text != test.text {
+				t.Errorf("parseTesseractTSV() text = %q, want %q", result.Text, test.text)
+			}
+			if !reflect.DeepEqual(result.Words, test.words) {
+				t.Errorf("parseTesseractTSV() words = %+v, want %+v", result.Words, test.words)
+			}
+		})
+	}
+}
+
+func TestParseTesseractTSVInvalidRows(t *testing.T) {
+	rows := []string{
+		"word\t1\t1\t1\t1\t1\t0\t0\t10\t10\t90\ttext",
+		"5\t1\t1\t1\t1\t1\tleft\t0\t10\t10\t90\ttext",
+		"5\t1\t1\t1\t1\t1\t0\t0\t10\t10\thigh\ttext",
+	}
+	for _, row := range rows {
+		if result, err := parseTesseractTSV(testTSV(row)); err == nil {
+			t.Errorf("parseTesseractTSV(%q) = %+v, want an error", row, result)
+		}
+	}
+}
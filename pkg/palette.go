@@ -0,0 +1,95 @@
+package pkg
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+
+	"github.com/sqweek/dialog"
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+const gimpPaletteColumns int = 8
+
+var PaletteFormats []PaletteFormat = []PaletteFormat{
+	{Name: "GIMP palette", AllowedExtensions: []string{".gpl"}, WritingFunction: WriteGIMPPalette},
+	{Name: "JSON", AllowedExtensions: []string{".json"}, WritingFunction: WriteJSONPalette},
+}
+
+type PaletteFormat struct {
+	Name              string
+	AllowedExtensions []string
+	WritingFunction   func(name string, colors []sdl.Color, writer io.Writer) error
+}
+
+type PaletteSavingOptions struct {
+	Filepath string
+	Format   PaletteFormat
+}
+
+type jsonPaletteColor struct {
+	Hex string `json:"hex"`
+	R   uint8  `json:"r"`
+	G   uint8  `json:"g"`
+	B   uint8  `json:"b"`
+	A   uint8  `json:"a"`
+}
+
+type jsonPalette struct {
+	Name   string             `json:"name"`
+	Colors []jsonPaletteColor `json:"colors"`
+}
+
+func WriteGIMPPalette(name string, colors []sdl.Color, writer io.Writer) error {
+	if _, err := fmt.Fprintf(writer, "GIMP Palette\nName: %s\nColumns: %d\n#\n", name, gimpPaletteColumns); err != nil {
+		return err
+	}
+	for _, color := range colors {
+		if _, err := fmt.Fprintf(writer, "%3d %3d %3d\t%s\n", color.R, color.G, color.B, FormatHexColor(color)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func WriteJSONPalette(name string, colors []sdl.Color, writer io.Writer) error {
+	palette := jsonPalette{Name: name, Colors: make([]jsonPaletteColor, len(colors))}
+	for i, color := range colors {
+		palette.Colors[i] = jsonPaletteColor{Hex: FormatHexColor(color), R: color.R, G: color.G, B: color.B, A: color.A}
+	}
+	encoder := json.NewEncoder(writer)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(palette)
+}
+
+func RequestPaletteSavingOptions(
+	dialogTitle,
+	dialogStartFileName string,
+) (
+	options *PaletteSavingOptions,
+	success bool,
+) {
+	dialogBuilder := dialog.File()
+	dialogBuilder.Title(dialogTitle)
+	dialogBuilder.SetStartFile(fmt.Sprintf("%s%s", dialogStartFileName, PaletteFormats[0].AllowedExtensions[0]))
+	for _, format := range PaletteFormats {
+		dialogBuilder.Filter(format.Name, format.AllowedExtensions...)
+	}
+	path, err := dialogBuilder.Save()
+	if err != nil {
+		return nil, false
+	}
+	ext := filepath.Ext(path)
+	for _, format := range PaletteFormats {
+		for _, allowedExt := range format.AllowedExtensions {
+			if allowedExt == ext {
+				return &PaletteSavingOptions{
+					Filepath: path,
+					Format:   format,
+				}, true
+			}
+		}
+	}
+	return nil, false
+}
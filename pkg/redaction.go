@@ -0,0 +1,187 @@
+package pkg
+
+import (
+	"image"
+	"regexp"
+	"strings"
+	"unicode"
+)
+
+const minPhoneDigits int = 7
+const maxPhoneDigits int = 15
+
+var dateRegexp = regexp.MustCompile(`^\d{4}[\-./]\d{1,2}[\-./]\d{1,2}|^\d{1,2}[\-./]\d{1,2}[\-./]\d{2,4}$`)
+
+type SensitiveTextPattern struct {
+	Kind     string
+	Regexp   *regexp.Regexp
+	validate func(match string) bool
+}
+
+type SensitiveTextMatch struct {
+	Kind string
+	Text string
+	BBox image.Rectangle
+}
+
+var DefaultSensitiveTextPatterns = []SensitiveTextPattern{
+	{
+		Kind:   "Email",
+		Regexp: regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`),
+	},
+	{
+		Kind:   "IP address",
+		Regexp: regexp.MustCompile(`\b(?:(?:25[0-5]|2[0-4]\d|1\d\d|[1-9]?\d)\.){3}(?:25[0-5]|2[0-4]\d|1\d\d|[1-9]?\d)\b`),
+	},
+	{
+		Kind:   "IP address",
+		Regexp: regexp.MustCompile(`(?i)\b(?:[0-9a-f]{1,4}:){7}[0-9a-f]{1,4}\b|(?:\b[0-9a-f]{1,4}(?::[0-9a-f]{1,4}){0,5})?::(?:[0-9a-f]{1,4}(?::[0-9a-f]{1,4}){0,5}\b)?`),
+		validate: func(match string) bool {
+			return strings.Count(match, ":") >= 2 && len(match) > 2
+		},
+	},
+	{
+		Kind:     "Phone number",
+		Regexp:   regexp.MustCompile(`(?:\+\d{1,3}[\s.\-]?)?(?:\(\d{1,4}\)[\s.\-]?)?\d{2,4}(?:[\s.\-]?\d{2,4}){1,4}`),
+		validate: isPhoneNumber,
+	},
+	{
+		Kind: "API key",
+		Regexp: regexp.MustCompile(
+			`\b(?:sk|pk|rk)_(?:live|test)_[A-Za-z0-9]{16,}\b|\bAKIA[0-9A-Z]{16}\b|\bgh[pousr]_[A-Za-z0-9]{36,}\b|` +
+				`\bxox[abposr]-[A-Za-z0-9\-]{10,}\b|\bAIza[0-9A-Za-z_\-]{35}\b|\bsk-[A-Za-z0-9_\-]{20,}\b|` +
+				`\beyJ[A-Za-z0-9_\-]{8,}\.[A-Za-z0-9_\-]{8,}\.[A-Za-z0-9_\-]{8,}`,
+		),
+	},
+	{
+		Kind:     "API key",
+		Regexp:   regexp.MustCompile(`\b[A-Za-z0-9_\-+/]{32,}={0,2}`),
+		validate: isRandomLookingToken,
+	},
+}
+
+func NewSensitiveTextPattern(kind string, expr string) (SensitiveTextPattern, error) {
+	compiled, err := regexp.Compile(expr)
+	if err != nil {
+		return SensitiveTextPattern{}, err
+	}
+	return SensitiveTextPattern{Kind: kind, Regexp: compiled}, nil
+}
+
+// Patterns are matched against whole OCR lines, so values split into several words are found too
+func FindSensitiveText(result *OCRResult, patterns []SensitiveTextPattern) []SensitiveTextMatch {
+	matches := make([]SensitiveTextMatch, 0)
+	found := make(map[image.Rectangle]bool)
+	for _, line := range groupOCRLines(result.Words) {
+		lineText, wordSpans := joinOCRLine(line)
+		for _, pattern := range patterns {
+			for _, span := range pattern.Regexp.FindAllStringIndex(lineText, -1) {
+				matchText := lineText[span[0]:span[1]]
+				if strings.TrimSpace(matchText) == "" {
+					continue
+				}
+				if pattern.validate != nil && !pattern.validate(matchText) {
+					continue
+				}
+				bbox := image.Rectangle{}
+				for i, wordSpan := range wordSpans {
+					if wordSpan[0] < span[1] && span[0] < wordSpan[1] {
+						bbox = bbox.Union(line[i].BBox)
+					}
+				}
+				if bbox.Empty() || found[bbox] {
+					continue
+				}
+				found[bbox] = true
+				matches = append(matches, SensitiveTextMatch{Kind: pattern.Kind, Text: matchText, BBox: bbox})
+			}
+		}
+	}
+	return matches
+}
+
+func groupOCRLines(words []OCRWord) [][]OCRWord {
+	lines := make([][]OCRWord, 0)
+	for i, word := range words {
+		if i == 0 || word.Line != words[i-1].Line {
+			lines = append(lines, make([]OCRWord, 0, 1))
+		}
+		lines[len(lines)-1] = append(lines[len(lines)-1], word)
+	}
+	return lines
+}
+
+func joinOCRLine(line []OCRWord) (string, [][2]int) {
+	text := strings.Builder{}
+	spans := make([][2]int, len(line))
+	for i, word := range line {
+		if i > 0 {
+			text.WriteString(" ")
+		}
+		spans[i][0] = text.Len()
+		text.WriteString(word.Text)
+		spans[i][1] = text.Len()
+	}
+	return text.String(), spans
+}
+
+func isPhoneNumber(match string) bool {
+	digits := 0
+	for _, rn := range match {
+		if unicode.IsDigit(rn) {
+			digits++
+		}
+	}
+	if digits < minPhoneDigits || digits > maxPhoneDigits {
+		return false
+	}
+	//Dates and times have the same shape as short phone numbers
+	return !dateRegexp.MatchString(match)
+}
+
+func isRandomLookingToken(match string) bool {
+	hasLetter, hasDigit, hasUpper, hasLower := false, false, false, false
+	for _, rn := range match {
+		switch {
+		case unicode.IsDigit(rn):
+			hasDigit = true
+		case unicode.IsUpper(rn):
+			hasLetter, hasUpper = true, true
+		case unicode.IsLower(rn):
+			hasLetter, hasLower = true, true
+		}
+	}
+	return hasLetter && hasDigit && (hasUpper && hasLower || len(match) >= 40)
+}
+
+// Pixelated short text can be recovered by pixelating guesses the same way, so it isn't the default style
+func PixelateImage(img *image.RGBA, rect image.Rectangle, blockSize int) *image.RGBA {
+	rect = rect.Intersect(img.Bounds())
+	blockSize = Max(blockSize, 1)
+	w, h := (rect.Dx()+blockSize-1)/blockSize, (rect.Dy()+blockSize-1)/blockSize
+	pixelated := image.NewRGBA(image.Rect(0, 0, Max(w, 1), Max(h, 1)))
+	for by := 0; by < h; by++ {
+		for bx := 0; bx < w; bx++ {
+			block := image.Rect(
+				rect.Min.X+bx*blockSize, rect.Min.Y+by*blockSize,
+				rect.Min.X+(bx+1)*blockSize, rect.Min.Y+(by+1)*blockSize,
+			).Intersect(rect)
+			var sum [4]int
+			for y := block.Min.Y; y < block.Max.Y; y++ {
+				offset := img.PixOffset(block.Min.X, y)
+				for x := block.Min.X; x < block.Max.X; x++ {
+					for c := 0; c < 4; c++ {
+						sum[c] += int(img.Pix[offset+c])
+					}
+					offset += 4
+				}
+			}
+			count := Max(block.Dx()*block.Dy(), 1)
+			offset := pixelated.PixOffset(bx, by)
+			for c := 0; c < 4; c++ {
+				pixelated.Pix[offset+c] = uint8(sum[c] / count)
+			}
+		}
+	}
+	return pixelated
+}
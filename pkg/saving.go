@@ -1,6 +1,7 @@
 package pkg
 
 import (
+	"bytes"
 	"fmt"
 	"image/gif"
 	"image/jpeg"
@@ -22,31 +23,61 @@ var SavingMethods []SavingMethod = []SavingMethod{
 	{Name: "BMP", AllowedExtensions: []string{".bmp"}, WritingFunction: WriteSurfaceToBMP},
 }
 
-func WriteSurfaceToPNG(surface *sdl.Surface, writer io.Writer) {
-	if err := png.Encode(writer, surface); err != nil {
+func WriteSurfaceToPNG(surface *sdl.Surface, writer io.Writer, metadata *ImageMetadata) {
+	if metadata == nil {
+		if err := png.Encode(writer, surface); err != nil {
+			panic(err)
+		}
+		return
+	}
+	buf := &bytes.Buffer{}
+	if err := png.Encode(buf, surface); err != nil {
+		panic(err)
+	}
+	data, err := InsertPNGMetadata(buf.Bytes(), *metadata)
+	if err != nil {
+		panic(err)
+	}
+	if _, err := writer.Write(data); err != nil {
 		panic(err)
 	}
 }
 
-func WriteSurfaceToJPEG(surface *sdl.Surface, writer io.Writer) {
-	if err := jpeg.Encode(writer, surface, &jpeg.Options{Quality: 100}); err != nil {
+func WriteSurfaceToJPEG(surface *sdl.Surface, writer io.Writer, metadata *ImageMetadata) {
+	options := &jpeg.Options{Quality: 100}
+	if metadata == nil {
+		if err := jpeg.Encode(writer, surface, options); err != nil {
+			panic(err)
+		}
+		return
+	}
+	buf := &bytes.Buffer{}
+	if err := jpeg.Encode(buf, surface, options); err != nil {
+		panic(err)
+	}
+	data, err := InsertJPEGMetadata(buf.Bytes(), *metadata)
+	if err != nil {
+		println(fmt.Sprintf("Can't add metadata to JPEG, saving it without metadata: %v", err))
+		data = buf.Bytes()
+	}
+	if _, err := writer.Write(data); err != nil {
 		panic(err)
 	}
 }
 
-func WriteSurfaceToGIF(surface *sdl.Surface, writer io.Writer) {
+func WriteSurfaceToGIF(surface *sdl.Surface, writer io.Writer, _ *ImageMetadata) {
 	if err := gif.Encode(writer, surface, &gif.Options{NumColors: 256}); err != nil {
 		panic(err)
 	}
 }
 
-func WriteSurfaceToWEBP(surface *sdl.Surface, writer io.Writer) {
+func WriteSurfaceToWEBP(surface *sdl.Surface, writer io.Writer, _ *ImageMetadata) {
 	if err := webp.Encode(writer, surface, &webp.Options{Lossless: true, Quality: 100}); err != nil {
 		panic(err)
 	}
 }
 
-func WriteSurfaceToBMP(surface *sdl.Surface, writer io.Writer) {
+func WriteSurfaceToBMP(surface *sdl.Surface, writer io.Writer, _ *ImageMetadata) {
 	if err := bmp.Encode(writer, surface); err != nil {
 		panic(err)
 	}
@@ -86,7 +117,7 @@ func RequestSavingOptions(
 type SavingMethod struct {
 	Name              string
 	AllowedExtensions []string
-	WritingFunction   func(surface *sdl.Surface, writer io.Writer)
+	WritingFunction   func(surface *sdl.Surface, writer io.Writer, metadata *ImageMetadata)
 }
 
 type SavingOptions struct {
@@ -1,10 +1,17 @@
 package pkg
 
 import (
+	"math"
+	"unicode"
+	"unicode/utf8"
+
 	"github.com/veandco/go-sdl2/sdl"
 	"github.com/veandco/go-sdl2/ttf"
 )
 
+// Display scales of fonts loaded with LoadScaledFont
+var fontScales = make(map[*ttf.Font]float64)
+
 func LoadFont(fontData []byte, size int) *ttf.Font {
 	fontRW, err := sdl.RWFromMem(fontData)
 	if err != nil {
@@ -17,12 +24,54 @@ func LoadFont(fontData []byte, size int) *ttf.Font {
 	return font
 }
 
+// Loads the font rasterized for the display scale, its sizes and string textures are still measured in UI units,
+// so the text is as large as the one of LoadFont and stays sharp when the renderer scales the UI
+func LoadScaledFont(fontData []byte, size int, scale float64) *ttf.Font {
+	font := LoadFont(fontData, int(math.Round(float64(size)*scale)))
+	if scale != 1 {
+		fontScales[font] = scale
+	}
+	return font
+}
+
+func CloseFont(font *ttf.Font) {
+	delete(fontScales, font)
+	font.Close()
+}
+
+func fontScale(font *ttf.Font) float64 {
+	if scale, isScaled := fontScales[font]; isScaled {
+		return scale
+	}
+	return 1
+}
+
+func FontHeight(font *ttf.Font) int32 {
+	return int32(math.Round(float64(font.Height()) / fontScale(font)))
+}
+
+func fontLineSkip(font *ttf.Font) int32 {
+	return int32(math.Round(float64(font.LineSkip()) / fontScale(font)))
+}
+
+// Typed text without control characters, format characters like the zero width joiner are kept for emoji sequences
+func InputRunes(text string) []rune {
+	runes := make([]rune, 0, len(text))
+	for _, rn := range text {
+		if rn != utf8.RuneError && !unicode.IsControl(rn) {
+			runes = append(runes, rn)
+		}
+	}
+	return runes
+}
+
 func SizeString(font *ttf.Font, text string) (int, int) {
 	w, h, err := font.SizeUTF8(text)
 	if err != nil {
 		panic(err)
 	}
-	return w, h
+	scale := fontScale(font)
+	return int(math.Round(float64(w) / scale)), int(math.Round(float64(h) / scale))
 }
 
 type StringTexture struct {
@@ -32,16 +81,20 @@ type StringTexture struct {
 }
 
 func NewStringTexture(ren *sdl.Renderer, font *ttf.Font, text string, color sdl.Color) *StringTexture {
-	w, _ := SizeString(font, text)
+	w, _, err := font.SizeUTF8(text)
+	if err != nil {
+		panic(err)
+	}
 	surface, err := font.RenderUTF8BlendedWrapped(text, color, w)
 	if err != nil {
 		panic(err)
 	}
 	defer surface.Free()
+	scale := fontScale(font)
 	return &StringTexture{
 		Texture:    CreateTextureFromSurface(ren, surface),
-		TextWidth:  surface.W,
-		TextHeight: surface.H,
+		TextWidth:  int32(math.Round(float64(surface.W) / scale)),
+		TextHeight: int32(math.Round(float64(surface.H) / scale)),
 	}
 }
 
@@ -54,6 +107,24 @@ func (text *StringTexture) Draw(ren *sdl.Renderer, leftTop *sdl.Point) {
 	)
 }
 
+// Draws the text cut to the max width
+func (text *StringTexture) DrawCropped(ren *sdl.Renderer, leftTop *sdl.Point, maxWidth int32) {
+	w := Min(text.TextWidth, maxWidth)
+	if w <= 0 {
+		return
+	}
+	//Texture of a scaled font is larger than the text size
+	_, _, textureW, textureH, err := text.Texture.Query()
+	if err != nil {
+		panic(err)
+	}
+	ren.Copy(
+		text.Texture,
+		&sdl.Rect{X: 0, Y: 0, W: w * textureW / text.TextWidth, H: textureH},
+		&sdl.Rect{X: leftTop.X, Y: leftTop.Y, W: w, H: text.TextHeight},
+	)
+}
+
 func (text *StringTexture) Destroy() {
 	text.Texture.Destroy()
 }
@@ -9,45 +9,111 @@ import (
 	"github.com/veandco/go-sdl2/ttf"
 )
 
+const (
+	TextBackgroundNone    string = "none"
+	TextBackgroundBox     string = "box"
+	TextBackgroundCallout string = "callout"
+)
+
+const (
+	TextEffectNone    string = "none"
+	TextEffectOutline string = "outline"
+	TextEffectShadow  string = "shadow"
+)
+
+const (
+	TextAlignLeft   string = "left"
+	TextAlignCenter string = "center"
+	TextAlignRight  string = "right"
+)
+
+const calloutMinTailWidth int32 = 4
+const calloutMaxTailWidth int32 = 16
+const minLineSpacing float64 = 1
+
 type TextParagraph struct {
 	Text          []rune
 	StringTexture *StringTexture
 	TextStart     sdl.Point
 	Color         sdl.Color
 	Font          *ttf.Font
+	Style         TextStyle
+	Decoration    TextDecoration
+	Layout        TextLayout
+	effectTexture *StringTexture
+	lines         [][2]int
+	boundaries    [][2]int
+	lineWidths    []int32
 	padding       int32
 }
 
+// Zero wrap width keeps lines unwrapped, zero line spacing means the font line skip
+type TextLayout struct {
+	WrapWidth   int32
+	Alignment   string
+	LineSpacing float64
+}
+
+// Background box, callout bubble and outline or shadow drawn under the text
+type TextDecoration struct {
+	Background        string
+	BackgroundColor   sdl.Color
+	BackgroundPadding int32
+	CornerRadius      int32
+	TailTarget        sdl.Point
+	Effect            string
+	EffectColor       sdl.Color
+}
+
+func (decoration TextDecoration) hasBackground() bool {
+	return decoration.Background == TextBackgroundBox || decoration.Background == TextBackgroundCallout
+}
+
+func (decoration TextDecoration) hasEffect() bool {
+	return decoration.Effect == TextEffectOutline || decoration.Effect == TextEffectShadow
+}
+
+type TextStyle struct {
+	Family    FontFamily
+	Size      int
+	Bold      bool
+	Italic    bool
+	Underline bool
+}
+
+func (style TextStyle) FontStyle() int {
+	fontStyle := ttf.STYLE_NORMAL
+	if style.Bold {
+		fontStyle |= ttf.STYLE_BOLD
+	}
+	if style.Italic {
+		fontStyle |= ttf.STYLE_ITALIC
+	}
+	if style.Underline {
+		fontStyle |= ttf.STYLE_UNDERLINE
+	}
+	return fontStyle
+}
+
 func NewTextParagraph(
 	textStart sdl.Point,
 	textColor sdl.Color,
 	textFont *ttf.Font,
 	paragraphPadding int32,
 ) *TextParagraph {
-	return &TextParagraph{
+	par := &TextParagraph{
 		Text:      make([]rune, 0, 1),
 		TextStart: textStart,
 		Color:     textColor,
 		Font:      textFont,
 		padding:   paragraphPadding,
 	}
+	par.updateLayout()
+	return par
 }
 
 func (par TextParagraph) GetBBox() *sdl.Rect {
-	w, h := int32(par.Font.Height()), int32(par.Font.Height())
-	if par.StringTexture != nil {
-		h = par.StringTexture.TextHeight
-		lines := par.GetLinesBoundaries()
-		for _, line := range lines {
-			lineW, _ := SizeString(par.Font, string(par.Text[line[0]:line[1]]))
-			if int32(lineW) > w {
-				w = int32(lineW)
-			}
-		}
-	}
-	if len(par.Text) > 0 && par.Text[len(par.Text)-1] == '\n' {
-		h += int32(par.Font.Height())
-	}
+	w, h := par.contentSize()
 	return &sdl.Rect{
 		X: par.TextStart.X - par.padding, Y: par.TextStart.Y - par.padding,
 		W: w + par.padding*2, H: h + par.padding*2,
@@ -62,24 +128,314 @@ func (par TextParagraph) GetPaddedBBox(padding int32) *sdl.Rect {
 	}
 }
 
-func (par *TextParagraph) SetColor(color sdl.Color) {
+func (par *TextParagraph) SetColor(ren *sdl.Renderer, color sdl.Color) {
+	if color == par.Color {
+		return
+	}
 	par.Color = color
+	par.updateTexture(ren)
+}
+
+func (par *TextParagraph) SetFont(ren *sdl.Renderer, font *ttf.Font, style TextStyle) {
+	par.Style = style
+	if font == par.Font {
+		return
+	}
+	par.Font = font
+	par.updateTexture(ren)
+}
+
+func (par *TextParagraph) SetDecoration(ren *sdl.Renderer, decoration TextDecoration) {
+	if decoration == par.Decoration {
+		return
+	}
+	effectChanged := decoration.Effect != par.Decoration.Effect || decoration.EffectColor != par.Decoration.EffectColor
+	par.Decoration = decoration
+	if effectChanged {
+		par.updateTexture(ren)
+	}
+}
+
+// Text area without the paragraph padding
+func (par TextParagraph) GetTextRect() sdl.Rect {
+	bbox := par.GetBBox()
+	return sdl.Rect{
+		X: bbox.X + par.padding, Y: bbox.Y + par.padding,
+		W: bbox.W - par.padding*2, H: bbox.H - par.padding*2,
+	}
+}
+
+func (par TextParagraph) GetBackgroundRect() sdl.Rect {
+	rect := par.GetTextRect()
+	padding := par.Decoration.BackgroundPadding
+	return sdl.Rect{X: rect.X - padding, Y: rect.Y - padding, W: rect.W + padding*2, H: rect.H + padding*2}
+}
+
+// Everything the paragraph draws, including the background and the callout tail
+func (par TextParagraph) GetOuterBBox() sdl.Rect {
+	bbox := *par.GetBBox()
+	if par.Decoration.hasBackground() {
+		background := par.GetBackgroundRect()
+		bbox = bbox.Union(&background)
+	}
+	if par.Decoration.Background == TextBackgroundCallout {
+		bbox = bbox.Union(&sdl.Rect{X: par.Decoration.TailTarget.X, Y: par.Decoration.TailTarget.Y, W: 1, H: 1})
+	}
+	if par.Decoration.hasEffect() {
+		effectSize := par.effectSize()
+		bbox = sdl.Rect{X: bbox.X - effectSize, Y: bbox.Y - effectSize, W: bbox.W + effectSize*2, H: bbox.H + effectSize*2}
+	}
+	return bbox
+}
+
+func (par *TextParagraph) Draw(ren *sdl.Renderer) {
+	if par.Decoration.hasBackground() {
+		par.drawBackground(ren)
+	}
+	if par.StringTexture == nil {
+		return
+	}
+	if par.effectTexture != nil {
+		par.drawEffect(ren)
+	}
+	par.StringTexture.Draw(ren, &par.TextStart)
+}
+
+func (par TextParagraph) drawBackground(ren *sdl.Renderer) {
+	decoration := par.Decoration
+	box := par.GetBackgroundRect()
+	bbox := box
+	tail, hasTail := [3]sdl.Point{}, false
+	if decoration.Background == TextBackgroundCallout {
+		tail, hasTail = calloutTail(box, decoration.CornerRadius, decoration.TailTarget)
+	}
+	if hasTail {
+		bbox = bbox.Union(&sdl.Rect{X: decoration.TailTarget.X, Y: decoration.TailTarget.Y, W: 1, H: 1})
+	}
+	opaque := sdl.Color{R: decoration.BackgroundColor.R, G: decoration.BackgroundColor.G, B: decoration.BackgroundColor.B, A: 255}
+	DrawTranslucent(ren, &bbox, decoration.BackgroundColor.A, func(offset sdl.Point) {
+		DrawRoundedFilledRectangle(
+			ren,
+			&sdl.Rect{X: box.X + offset.X, Y: box.Y + offset.Y, W: box.W, H: box.H},
+			decoration.CornerRadius,
+			opaque,
+		)
+		if hasTail {
+			DrawFilledTriangle(
+				ren,
+				&sdl.Point{X: tail[0].X + offset.X, Y: tail[0].Y + offset.Y},
+				&sdl.Point{X: tail[1].X + offset.X, Y: tail[1].Y + offset.Y},
+				&sdl.Point{X: tail[2].X + offset.X, Y: tail[2].Y + offset.Y},
+				opaque,
+			)
+		}
+	})
+}
+
+// Effect texture covers the text with the effect size around it
+func (par TextParagraph) drawEffect(ren *sdl.Renderer) {
+	effectSize := par.effectSize()
+	par.effectTexture.Draw(ren, &sdl.Point{X: par.TextStart.X - effectSize, Y: par.TextStart.Y - effectSize})
+}
+
+// Outline is the opaque text stamped around itself, shadow is a single shifted copy.
+// Stamps are rendered once, the whole effect is blended with the effect color alpha
+func (par TextParagraph) renderEffect(ren *sdl.Renderer) *StringTexture {
+	effectSize := par.effectSize()
+	offsets := make([]sdl.Point, 0)
+	switch par.Decoration.Effect {
+	case TextEffectOutline:
+		for dy := -effectSize; dy <= effectSize; dy++ {
+			for dx := -effectSize; dx <= effectSize; dx++ {
+				if (dx != 0 || dy != 0) && dx*dx+dy*dy <= effectSize*effectSize {
+					offsets = append(offsets, sdl.Point{X: dx, Y: dy})
+				}
+			}
+		}
+	case TextEffectShadow:
+		offsets = append(offsets, sdl.Point{X: effectSize, Y: effectSize})
+	}
+	opaque := par.Decoration.EffectColor
+	opaque.A = 255
+	stamp := par.renderLines(ren, opaque)
+	defer stamp.Destroy()
+	bbox := sdl.Rect{X: -effectSize, Y: -effectSize, W: stamp.TextWidth + effectSize*2, H: stamp.TextHeight + effectSize*2}
+	texture := RenderToTexture(ren, &bbox, func(offset sdl.Point) {
+		for _, effectOffset := range offsets {
+			stamp.Draw(ren, &sdl.Point{X: offset.X + effectOffset.X, Y: offset.Y + effectOffset.Y})
+		}
+	})
+	texture.SetAlphaMod(par.Decoration.EffectColor.A)
+	return &StringTexture{Texture: texture, TextWidth: bbox.W, TextHeight: bbox.H}
+}
+
+// Outline width and shadow distance grow with the font
+func (par TextParagraph) effectSize() int32 {
+	return Max(1, FontHeight(par.Font)/10)
+}
+
+// Returns the tail triangle going from the box side facing the target, there is no tail if the target is inside the box
+func calloutTail(box sdl.Rect, radius int32, target sdl.Point) ([3]sdl.Point, bool) {
+	if target.InRect(&box) {
+		return [3]sdl.Point{}, false
+	}
+	radius = Clamp(0, radius, Min(box.W, box.H)/2)
+	halfWidth := Clamp(calloutMinTailWidth, Min(box.W, box.H)/4, calloutMaxTailWidth)
+	centerX, centerY := float64(box.X)+float64(box.W)/2, float64(box.Y)+float64(box.H)/2
+	relX := (float64(target.X) - centerX) / float64(box.W)
+	relY := (float64(target.Y) - centerY) / float64(box.H)
+	//The base stays on the straight part of the side, the last pixel row of the box is shared to avoid gaps
+	baseCenter := func(value, from, length int32) int32 {
+		low, high := from+radius+halfWidth, from+length-radius-halfWidth
+		if low > high {
+			return from + length/2
+		}
+		return Clamp(low, value, high)
+	}
+	var base1, base2 sdl.Point
+	switch {
+	case math.Abs(relY) >= math.Abs(relX):
+		y := box.Y
+		if relY > 0 {
+			y = box.Y + box.H - 1
+		}
+		x := baseCenter(target.X, box.X, box.W)
+		base1, base2 = sdl.Point{X: x - halfWidth, Y: y}, sdl.Point{X: x + halfWidth, Y: y}
+	default:
+		x := box.X
+		if relX > 0 {
+			x = box.X + box.W - 1
+		}
+		y := baseCenter(target.Y, box.Y, box.H)
+		base1, base2 = sdl.Point{X: x, Y: y - halfWidth}, sdl.Point{X: x, Y: y + halfWidth}
+	}
+	return [3]sdl.Point{base1, base2, target}, true
+}
+
+func (par *TextParagraph) SetLayout(ren *sdl.Renderer, layout TextLayout) {
+	if layout == par.Layout {
+		return
+	}
+	par.Layout = layout
+	par.updateTexture(ren)
+}
+
+// Returns the first and the last cursor position of every line, the last position is right before the line break.
+// There is always at least one line, text ending with a newline ends with an empty line.
+// Lines never share a position, a line ending inside a word cut by the wrap gives the position after the cut to the next line
+func (par TextParagraph) GetLinesBoundaries() [][2]int {
+	return par.boundaries
 }
 
-func (par *TextParagraph) GetLinesBoundaries() [][2]int {
-	lines := make([][2]int, 0, 1)
-	i, lineStart := 0, 0
-	for i < len(par.Text) {
-		if par.Text[i] == '\n' || i == len(par.Text)-1 {
-			lines = append(lines, [2]int{lineStart, i})
+// Line offsets and selection boxes count from the text start
+func (par TextParagraph) lineX(lineNumber int) int32 {
+	contentW, _ := par.contentSize()
+	switch par.Layout.Alignment {
+	case TextAlignCenter:
+		return (contentW - par.lineWidths[lineNumber]) / 2
+	case TextAlignRight:
+		return contentW - par.lineWidths[lineNumber]
+	default:
+		return 0
+	}
+}
+
+func (par TextParagraph) lineHeight() int32 {
+	spacing := math.Max(par.Layout.LineSpacing, minLineSpacing)
+	return int32(math.Round(float64(fontLineSkip(par.Font)) * spacing))
+}
+
+// Wrapped paragraphs are exactly as wide as the wrap width, so the alignment follows the dragged edge
+func (par TextParagraph) contentSize() (int32, int32) {
+	w := FontHeight(par.Font)
+	if par.Layout.WrapWidth > 0 {
+		w = par.Layout.WrapWidth
+	} else {
+		for _, lineW := range par.lineWidths {
+			w = Max(w, lineW)
+		}
+	}
+	return w, par.lineHeight()*int32(len(par.lines)-1) + FontHeight(par.Font)
+}
+
+// Returns boxes covering the selected part of every line, a selected line break is shown as a narrow box
+func (par TextParagraph) GetSelectionRects(selStart, selEnd int) []sdl.Rect {
+	rects := make([]sdl.Rect, 0, 1)
+	for i, line := range par.lines {
+		from, to := Max(selStart, line[0]), Min(selEnd, line[1])
+		if from > to || (from == to && selEnd <= line[1]) {
+			continue
+		}
+		fromX, _ := SizeString(par.Font, string(par.Text[line[0]:from]))
+		toX, _ := SizeString(par.Font, string(par.Text[line[0]:to]))
+		w := int32(toX - fromX)
+		if selEnd > line[1] && i < len(par.lines)-1 {
+			w += FontHeight(par.Font) / 4
+		}
+		rects = append(rects, sdl.Rect{
+			X: par.TextStart.X + par.lineX(i) + int32(fromX), Y: par.TextStart.Y + par.lineHeight()*int32(i),
+			W: w, H: par.lineHeight(),
+		})
+	}
+	return rects
+}
+
+func (par *TextParagraph) updateLayout() {
+	par.lines = par.lines[:0]
+	lineStart := 0
+	for i := 0; i <= len(par.Text); i++ {
+		if i == len(par.Text) || par.Text[i] == '\n' {
+			par.wrapLine(lineStart, i)
 			lineStart = i + 1
 		}
-		i++
 	}
-	if len(lines) > 0 {
-		lines[len(lines)-1][1]++
+	par.lineWidths = make([]int32, len(par.lines))
+	par.boundaries = make([][2]int, len(par.lines))
+	for i, line := range par.lines {
+		w, _ := SizeString(par.Font, string(par.Text[line[0]:line[1]]))
+		par.lineWidths[i] = int32(w)
+		par.boundaries[i] = line
+		if i < len(par.lines)-1 && par.lines[i+1][0] == line[1] {
+			par.boundaries[i][1]--
+		}
 	}
-	return lines
+}
+
+// Breaks the text between two newlines on the last space that fits into the wrap width.
+// The space is replaced by the line break like a newline, words longer than the wrap width are cut
+func (par *TextParagraph) wrapLine(start, end int) {
+	for par.Layout.WrapWidth > 0 && start < end {
+		if w, _ := SizeString(par.Font, string(par.Text[start:end])); int32(w) <= par.Layout.WrapWidth {
+			break
+		}
+		fits, overflows := start+1, end
+		for overflows-fits > 1 {
+			middle := (fits + overflows) / 2
+			if w, _ := SizeString(par.Font, string(par.Text[start:middle])); int32(w) <= par.Layout.WrapWidth {
+				fits = middle
+			} else {
+				overflows = middle
+			}
+		}
+		if fits >= end {
+			break
+		}
+		lineEnd := fits
+		for space := fits; space > start; space-- {
+			if unicode.IsSpace(par.Text[space]) {
+				lineEnd = space
+				break
+			}
+		}
+		if lineEnd != fits || unicode.IsSpace(par.Text[fits]) {
+			par.lines = append(par.lines, [2]int{start, lineEnd})
+			start = lineEnd + 1
+			continue
+		}
+		par.lines = append(par.lines, [2]int{start, lineEnd})
+		start = lineEnd
+	}
+	par.lines = append(par.lines, [2]int{start, end})
 }
 
 func (par TextParagraph) GetLineNumber(position int) int {
@@ -116,29 +472,24 @@ func (par *TextParagraph) PopRunes(ren *sdl.Renderer, startPos, endPos int) {
 }
 
 func (par TextParagraph) GetOffsetByPosition(position int) (int32, int32) {
-	lines := par.GetLinesBoundaries()
-	if len(lines) == 0 {
-		return int32(0), int32(0)
-	}
 	lineNumber := par.GetLineNumber(position)
-	if position == len(par.Text) && par.Text[position-1] == '\n' {
-		return int32(0), int32((lineNumber + 1) * par.Font.LineSkip())
-	}
-	x, _ := SizeString(par.Font, string(par.Text[lines[lineNumber][0]:position]))
-	return int32(x), int32(lineNumber * par.Font.LineSkip())
+	x, _ := SizeString(par.Font, string(par.Text[par.lines[lineNumber][0]:position]))
+	return par.lineX(lineNumber) + int32(x), par.lineHeight() * int32(lineNumber)
 }
 
 func (par TextParagraph) GetPositionByOffset(xOffset int32, yOffset int32) int {
-	lineNumber := int(math.Floor(float64(yOffset) / float64(par.Font.LineSkip())))
+	lineNumber := int(math.Floor(float64(yOffset) / float64(par.lineHeight())))
 	lineNumber = Max(0, lineNumber)
-	lines := par.GetLinesBoundaries()
-	if lineNumber >= len(lines) {
+	if lineNumber >= len(par.lines) {
 		return len(par.Text)
 	}
-	lineStart, lineEnd := lines[lineNumber][0], lines[lineNumber][1]
-	w, _ := SizeString(par.Font, string(par.Text[lineStart:lineEnd]))
-	if int(xOffset) >= w {
-		return lineEnd + 1
+	lineStart, lineEnd := par.boundaries[lineNumber][0], par.boundaries[lineNumber][1]
+	xOffset -= par.lineX(lineNumber)
+	if xOffset <= 0 {
+		return lineStart
+	}
+	if xOffset >= par.lineWidths[lineNumber] {
+		return lineEnd
 	}
 	start, end := lineStart, lineEnd+1
 	for {
@@ -190,38 +541,76 @@ PostWordLoop:
 	return pos
 }
 
+// Cursor keeps its horizontal offset when moved between lines, which works for wrapped and aligned lines
 func (par TextParagraph) UpperLinePos(position int) int {
 	lineNumber := par.GetLineNumber(position)
-	lines := par.GetLinesBoundaries()
-	if lineNumber == 0 || len(lines) == 0 {
+	if lineNumber == 0 {
 		return 0
 	}
-	upperLine := lines[lineNumber-1]
-	lineOffset := position - lines[lineNumber][0]
-	upperLinePos := upperLine[0] + lineOffset
-	return Min(upperLinePos, upperLine[1])
+	x, y := par.GetOffsetByPosition(position)
+	return par.GetPositionByOffset(x, y-par.lineHeight()/2)
 }
 
 func (par TextParagraph) LowerLinePos(position int) int {
 	lineNumber := par.GetLineNumber(position)
-	lines := par.GetLinesBoundaries()
-	if lineNumber == len(lines)-1 || len(lines) == 0 {
+	if lineNumber == len(par.lines)-1 {
 		return len(par.Text)
 	}
-	lowerLine := lines[lineNumber+1]
-	lineOffset := position - lines[lineNumber][0]
-	lowerLinePos := lowerLine[0] + lineOffset
-	lowerLinePos = Min(lowerLinePos, lowerLine[1])
-	return lowerLinePos
+	x, y := par.GetOffsetByPosition(position)
+	return par.GetPositionByOffset(x, y+par.lineHeight()+par.lineHeight()/2)
 }
 
 func (par *TextParagraph) updateTexture(ren *sdl.Renderer) {
+	par.DestroyTextures()
+	par.updateLayout()
+	if len(par.Text) == 0 {
+		return
+	}
+	par.StringTexture = par.renderLines(ren, par.Color)
+	if par.Decoration.hasEffect() {
+		par.effectTexture = par.renderEffect(ren)
+	}
+}
+
+// Lines are rendered one by one and copied without blending, line spacing never lets them overlap.
+// Scaled fonts are rendered in display pixels, while the texture is still measured in UI units
+func (par TextParagraph) renderLines(ren *sdl.Renderer, color sdl.Color) *StringTexture {
+	w, h := par.contentSize()
+	scale := fontScale(par.Font)
+	toPixels := func(value int32) int32 {
+		return int32(math.Round(float64(value) * scale))
+	}
+	surface, err := sdl.CreateRGBSurfaceWithFormat(0, toPixels(w), toPixels(h), 32, uint32(sdl.PIXELFORMAT_RGBA32))
+	if err != nil {
+		panic(err)
+	}
+	defer surface.Free()
+	for i, line := range par.lines {
+		if line[0] == line[1] {
+			continue
+		}
+		lineSurface, err := par.Font.RenderUTF8Blended(string(par.Text[line[0]:line[1]]), color)
+		if err != nil {
+			panic(err)
+		}
+		lineSurface.SetBlendMode(sdl.BLENDMODE_NONE)
+		lineSurface.Blit(nil, surface, &sdl.Rect{X: toPixels(par.lineX(i)), Y: toPixels(par.lineHeight() * int32(i))})
+		lineSurface.Free()
+	}
+	return &StringTexture{
+		Texture:    CreateTextureFromSurface(ren, surface),
+		TextWidth:  w,
+		TextHeight: h,
+	}
+}
+
+func (par *TextParagraph) DestroyTextures() {
 	if par.StringTexture != nil {
 		par.StringTexture.Destroy()
-	}
-	if len(par.Text) == 0 {
 		par.StringTexture = nil
-		return
 	}
-	par.StringTexture = NewStringTexture(ren, par.Font, string(par.Text), par.Color)
+	if par.effectTexture != nil {
+		par.effectTexture.Destroy()
+		par.effectTexture = nil
+	}
 }
@@ -0,0 +1,246 @@
+package pkg
+
+import (
+	"os"
+	"testing"
+
+	"github.com/veandco/go-sdl2/sdl"
+	"github.com/veandco/go-sdl2/ttf"
+)
+
+const testFontSize int = 20
+
+var testFont *ttf.Font
+
+// Fonts read from memory while they're open, so the data is kept until the tests end
+var testFontData []byte
+
+func TestMain(m *testing.M) {
+	if err := ttf.Init(); err != nil {
+		panic(err)
+	}
+	var err error
+	if testFontData, err = os.ReadFile(testFontPath); err != nil {
+		panic(err)
+	}
+	testFont = LoadFont(testFontData, testFontSize)
+	code := m.Run()
+	testFont.Close()
+	ttf.Quit()
+	os.Exit(code)
+}
+
+// Layout is computed without textures, so paragraphs are tested without a renderer.
+// Wrap width is given as a text, so the cases don't depend on the font metrics
+func newTestParagraph(text string, wrapText string, alignment string) *TextParagraph {
+	par := NewTextParagraph(sdl.Point{}, sdl.Color{R: 255, G: 255, B: 255, A: 255}, testFont, 0)
+	par.Text = []rune(text)
+	par.Layout = TextLayout{Alignment: alignment, LineSpacing: 1}
+	if wrapText != "" {
+		w, _ := SizeString(testFont, wrapText)
+		par.Layout.WrapWidth = int32(w)
+	}
+	par.updateLayout()
+	return par
+}
+
+func textWidth(text string) int32 {
+	w, _ := SizeString(testFont, text)
+	return int32(w)
+}
+
+func TestWrapLine(t *testing.T) {
+	tests := []struct {
+		name           string
+		text           string
+		wrapText       string
+		wantLines      [][2]int
+		wantBoundaries [][2]int
+	}{
+		{"empty", "", "", [][2]int{{0, 0}}, [][2]int{{0, 0}}},
+		{"unwrapped", "hello world", "", [][2]int{{0, 11}}, [][2]int{{0, 11}}},
+		{"newlines", "ab\ncd", "", [][2]int{{0, 2}, {3, 5}}, [][2]int{{0, 2}, {3, 5}}},
+		{"trailing newline", "ab\n", "", [][2]int{{0, 2}, {3, 3}}, [][2]int{{0, 2}, {3, 3}}},
+		{"fits the wrap width", "hello world", "hello world", [][2]int{{0, 11}}, [][2]int{{0, 11}}},
+		{"wrapped on space", "hello world", "hello wor", [][2]int{{0, 5}, {6, 11}}, [][2]int{{0, 5}, {6, 11}}},
+		{"wrapped on every space", "aa bb cc", "aa b", [][2]int{{0, 2}, {3, 5}, {6, 8}}, [][2]int{{0, 2}, {3, 5}, {6, 8}}},
+		{"word cut", "abcdefgh", "abcd", [][2]int{{0, 4}, {4, 8}}, [][2]int{{0, 3}, {4, 8}}},
+		{"word cut twice", "abcdefghij", "abcd", [][2]int{{0, 4}, {4, 8}, {8, 10}}, [][2]int{{0, 3}, {4, 7}, {8, 10}}},
+		{"wrapped after newline", "ab\nhello world", "hello wor", [][2]int{{0, 2}, {3, 8}, {9, 14}}, [][2]int{{0, 2}, {3, 8}, {9, 14}}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			par := newTestParagraph(test.text, test.wrapText, TextAlignLeft)
+			if !equalLines(par.lines, test.wantLines) {
+				t.Errorf("lines = %v, want %v", par.lines, test.wantLines)
+			}
+			if boundaries := par.GetLinesBoundaries(); !equalLines(boundaries, test.wantBoundaries) {
+				t.Errorf("boundaries = %v, want %v", boundaries, test.wantBoundaries)
+			}
+		})
+	}
+}
+
+func TestGetLineNumber(t *testing.T) {
+	for _, text := range []string{"hello world", "abcdefghij", "ab\n\ncd ef"} {
+		par := newTestParagraph(text, "abcd", TextAlignLeft)
+		for position := 0; position <= len(par.Text); position++ {
+			matching := 0
+			for _, line := range par.GetLinesBoundaries() {
+				if position >= line[0] && position <= line[1] {
+					matching++
+				}
+			}
+			if matching != 1 {
+				t.Errorf("%q: position %v is in %v lines %v", text, position, matching, par.GetLinesBoundaries())
+			}
+		}
+	}
+}
+
+func TestGetOffsetByPosition(t *testing.T) {
+	lineHeight := int32(testFont.LineSkip())
+	tests := []struct {
+		name      string
+		text      string
+		wrapText  string
+		alignment string
+		position  int
+		wantX     int32
+		wantY     int32
+	}{
+		{"text start", "hello", "", TextAlignLeft, 0, 0, 0},
+		{"text end", "hello", "", TextAlignLeft, 5, textWidth("hello"), 0},
+		{"after newline", "ab\ncd", "", TextAlignLeft, 3, 0, lineHeight},
+		{"second line", "ab\ncd", "", TextAlignLeft, 4, textWidth("c"), lineHeight},
+		{"before wrapped space", "hello world", "hello wor", TextAlignLeft, 5, textWidth("hello"), 0},
+		{"after wrapped space", "hello world", "hello wor", TextAlignLeft, 6, 0, lineHeight},
+		{"before word cut", "abcdefgh", "abcd", TextAlignLeft, 3, textWidth("abc"), 0},
+		{"at word cut", "abcdefgh", "abcd", TextAlignLeft, 4, 0, lineHeight},
+		{"centered", "ab\nabcd", "", TextAlignCenter, 1, (textWidth("abcd")-textWidth("ab"))/2 + textWidth("a"), 0},
+		{"right aligned", "ab\nabcd", "", TextAlignRight, 2, textWidth("abcd"), 0},
+		{"right aligned wrap", "hello world", "hello world!", TextAlignRight, 0, textWidth("hello world!") - textWidth("hello world"), 0},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			par := newTestParagraph(test.text, test.wrapText, test.alignment)
+			if x, y := par.GetOffsetByPosition(test.position); x != test.wantX || y != test.wantY {
+				t.Errorf("GetOffsetByPosition(%v) = (%v, %v), want (%v, %v)", test.position, x, y, test.wantX, test.wantY)
+			}
+		})
+	}
+}
+
+func TestGetPositionByOffset(t *testing.T) {
+	lineHeight := int32(testFont.LineSkip())
+	tests := []struct {
+		name         string
+		text         string
+		wrapText     string
+		xOffset      int32
+		yOffset      int32
+		wantPosition int
+	}{
+		{"left of the text", "hello", "", -10, 0, 0},
+		{"right of the text", "hello", "", textWidth("hello") + 10, 0, 5},
+		{"above the text", "hello", "", textWidth("he"), -10, 2},
+		{"below the text", "ab\ncd", "", 0, lineHeight * 5, 5},
+		{"inside a character", "hello", "", textWidth("he") + 1, 0, 2},
+		{"second line", "ab\ncd", "", textWidth("c"), lineHeight + 1, 4},
+		{"right of a wrapped line", "hello world", "hello wor", textWidth("hello world"), 0, 5},
+		{"right of a cut line", "abcdefgh", "abcd", textWidth("abcd") + 10, 0, 3},
+		{"start of the next line after a cut", "abcdefgh", "abcd", 0, lineHeight, 4},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			par := newTestParagraph(test.text, test.wrapText, TextAlignLeft)
+			if position := par.GetPositionByOffset(test.xOffset, test.yOffset); position != test.wantPosition {
+				t.Errorf("GetPositionByOffset(%v, %v) = %v, want %v", test.xOffset, test.yOffset, position, test.wantPosition)
+			}
+		})
+	}
+}
+
+// Every position is found again by its own offset
+func TestPositionOffsetRoundTrip(t *testing.T) {
+	for _, alignment := range []string{TextAlignLeft, TextAlignCenter, TextAlignRight} {
+		for _, text := range []string{"hello world", "abcdefghij", "ab\n\ncd ef gh"} {
+			par := newTestParagraph(text, "abcd", alignment)
+			for position := 0; position <= len(par.Text); position++ {
+				x, y := par.GetOffsetByPosition(position)
+				if found := par.GetPositionByOffset(x, y); found != position {
+					t.Errorf("%s %q: position %v is at (%v, %v), which gives position %v", alignment, text, position, x, y, found)
+				}
+			}
+		}
+	}
+}
+
+func TestLinePos(t *testing.T) {
+	tests := []struct {
+		name      string
+		text      string
+		wrapText  string
+		position  int
+		wantUpper int
+		wantLower int
+	}{
+		{"first line", "abc\nabcdef\nab", "", 2, 0, 6},
+		{"keeps the column", "abc\nabcdef\nab", "", 6, 2, 13},
+		{"longer than the line above", "abc\nabcdef\nab", "", 9, 3, 13},
+		{"last line", "abc\nabcdef\nab", "", 12, 5, 13},
+		{"empty line", "ab\n\ncd", "", 3, 0, 4},
+		{"word cut", "aaaaaaaa", "aaaa", 1, 0, 5},
+		{"after word cut", "aaaaaaaa", "aaaa", 5, 1, 8},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			par := newTestParagraph(test.text, test.wrapText, TextAlignLeft)
+			if upper := par.UpperLinePos(test.position); upper != test.wantUpper {
+				t.Errorf("UpperLinePos(%v) = %v, want %v", test.position, upper, test.wantUpper)
+			}
+			if lower := par.LowerLinePos(test.position); lower != test.wantLower {
+				t.Errorf("LowerLinePos(%v) = %v, want %v", test.position, lower, test.wantLower)
+			}
+		})
+	}
+}
+
+func equalLines(lines, want [][2]int) bool {
+	if len(lines) != len(want) {
+		return false
+	}
+	for i := range lines {
+		if lines[i] != want[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestCalloutTail(t *testing.T) {
+	box := sdl.Rect{X: 0, Y: 0, W: 100, H: 60}
+	tests := []struct {
+		name     string
+		box      sdl.Rect
+		radius   int32
+		target   sdl.Point
+		want     [3]sdl.Point
+		wantTail bool
+	}{
+		{"target inside", box, 10, sdl.Point{X: 50, Y: 30}, [3]sdl.Point{}, false},
+		{"below", box, 10, sdl.Point{X: 50, Y: 100}, [3]sdl.Point{{X: 35, Y: 59}, {X: 65, Y: 59}, {X: 50, Y: 100}}, true},
+		{"above the corner", box, 10, sdl.Point{X: -50, Y: -100}, [3]sdl.Point{{X: 10, Y: 0}, {X: 40, Y: 0}, {X: -50, Y: -100}}, true},
+		{"right", box, 10, sdl.Point{X: 200, Y: 30}, [3]sdl.Point{{X: 99, Y: 15}, {X: 99, Y: 45}, {X: 200, Y: 30}}, true},
+		{"left of the corner", box, 10, sdl.Point{X: -50, Y: 0}, [3]sdl.Point{{X: 0, Y: 10}, {X: 0, Y: 40}, {X: -50, Y: 0}}, true},
+		{"side shorter than the tail", sdl.Rect{X: 0, Y: 0, W: 20, H: 20}, 10, sdl.Point{X: 50, Y: 50}, [3]sdl.Point{{X: 5, Y: 19}, {X: 15, Y: 19}, {X: 50, Y: 50}}, true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tail, ok := calloutTail(test.box, test.radius, test.target)
+			if ok != test.wantTail || tail != test.want {
+				t.Errorf("calloutTail(%v, %v, %v) = %v, %v, want %v, %v", test.box, test.radius, test.target, tail, ok, test.want, test.wantTail)
+			}
+		})
+	}
+}
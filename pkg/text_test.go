@@ -0,0 +1,67 @@
+package pkg
+
+import (
+	"testing"
+
+	"github.com/veandco/go-sdl2/sdl"
+)
+
+func TestScaledFontMeasuresInUIUnits(t *testing.T) {
+	scaledFont := LoadScaledFont(testFontData, testFontSize, 2)
+	defer CloseFont(scaledFont)
+	surface, err := sdl.CreateRGBSurfaceWithFormat(0, 200, 100, 32, uint32(sdl.PIXELFORMAT_RGBA32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer surface.Free()
+	ren, err := sdl.CreateSoftwareRenderer(surface)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ren.Destroy()
+
+	const text = "Scaled text"
+	w, h := SizeString(testFont, text)
+	scaledW, scaledH := SizeString(scaledFont, text)
+	if Abs(scaledW-w) > 2 || Abs(scaledH-h) > 2 {
+		t.Errorf("SizeString() of the scaled font = %v, %v, want about %v, %v", scaledW, scaledH, w, h)
+	}
+	if height := FontHeight(scaledFont); Abs(height-FontHeight(testFont)) > 1 {
+		t.Errorf("FontHeight() of the scaled font = %v, want about %v", height, FontHeight(testFont))
+	}
+
+	texture := NewStringTexture(ren, scaledFont, text, sdl.Color{R: 255, G: 255, B: 255, A: 255})
+	defer texture.Destroy()
+	_, _, textureW, textureH, err := texture.Texture.Query()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if texture.TextWidth != int32(scaledW) || Abs(textureW-texture.TextWidth*2) > 1 || Abs(textureH-texture.TextHeight*2) > 1 {
+		t.Errorf(
+			"NewStringTexture() measures %vx%v with a %vx%v texture, want %v wide with a twice larger texture",
+			texture.TextWidth, texture.TextHeight, textureW, textureH, scaledW,
+		)
+	}
+}
+
+func TestScaleSurface(t *testing.T) {
+	surface, err := sdl.CreateRGBSurfaceWithFormat(0, 38, 16, 32, uint32(sdl.PIXELFORMAT_RGBA32))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer surface.Free()
+	surface.FillRect(nil, sdl.MapRGBA(surface.Format, 200, 100, 50, 255))
+	for _, scale := range []float64{1, 1.25, 2} {
+		scaled := ScaleSurface(surface, scale)
+		wantW, wantH := int32(38*scale+0.5), int32(16*scale+0.5)
+		if scaled.W != wantW || scaled.H != wantH {
+			t.Errorf("ScaleSurface(%v) size = %vx%v, want %vx%v", scale, scaled.W, scaled.H, wantW, wantH)
+		}
+		pixels := scaled.Pixels()
+		center := int(scaled.H/2)*int(scaled.Pitch) + int(scaled.W/2)*4
+		if got := pixels[center : center+4]; got[0] != 200 || got[1] != 100 || got[2] != 50 || got[3] != 255 {
+			t.Errorf("ScaleSurface(%v) center pixel = %v, want [200 100 50 255]", scale, got)
+		}
+		scaled.Free()
+	}
+}
@@ -0,0 +1,369 @@
+package pkg
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/cookiejar"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+)
+
+const uploadFileName string = "screenshot.png"
+const uploadContentType string = "image/png"
+
+var imgBBUploadUrl = "https://imgbb.com/json"
+var imgBBAuthTokenRegexp = regexp.MustCompile(`auth_token\s*=\s*"([0-9a-f]+)"`)
+
+type UploadResult struct {
+	URL       string
+	DeleteURL string
+}
+
+type Uploader interface {
+	Upload(ctx context.Context, img io.Reader) (*UploadResult, error)
+	Delete(ctx context.Context, deleteURL string) error
+}
+
+type ImgBBUploader struct {
+	Expiration string
+	Client     *http.Client
+}
+
+type imgBBResponse struct {
+	Image struct {
+		URL       string `json:"url"`
+		DeleteUrl string `json:"delete_url"`
+	}
+}
+
+type imgBBStatusResponse struct {
+	StatusCode int `json:"status_code"`
+	Success    *struct {
+		Message string `json:"message"`
+	} `json:"success"`
+	Error *struct {
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func (uploader ImgBBUploader) Upload(ctx context.Context, img io.Reader) (*UploadResult, error) {
+	form, body, err := buildImgBBForm(img, uploader.Expiration)
+	if err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, imgBBUploadUrl, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	resp, err := httpClient(uploader.Client).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("ImgBB returned %v status code", resp.StatusCode)
+	}
+	var respData imgBBResponse
+	if err := json.NewDecoder(resp.Body).Decode(&respData); err != nil {
+		return nil, err
+	}
+	return &UploadResult{URL: respData.Image.URL, DeleteURL: respData.Image.DeleteUrl}, nil
+}
+
+// ImgBB has no public deletion API, so this follows the same requests as the delete page (ibb.co/<id>/<hash>)
+func (uploader ImgBBUploader) Delete(ctx context.Context, deleteURL string) error {
+	parsedURL, err := url.Parse(deleteURL)
+	if err != nil {
+		return err
+	}
+	pathParts := strings.Split(strings.Trim(parsedURL.Path, "/"), "/")
+	if len(pathParts) != 2 {
+		return fmt.Errorf("invalid ImgBB delete URL %v", deleteURL)
+	}
+
+	//The auth token is bound to the session cookie set by the delete page
+	client := *httpClient(uploader.Client)
+	if client.Jar == nil {
+		if client.Jar, err = cookiejar.New(nil); err != nil {
+			return err
+		}
+	}
+
+	pageReq, err := http.NewRequestWithContext(ctx, http.MethodGet, deleteURL, nil)
+	if err != nil {
+		return err
+	}
+	pageResp, err := client.Do(pageReq)
+	if err != nil {
+		return err
+	}
+	defer pageResp.Body.Close()
+	page, err := io.ReadAll(pageResp.Body)
+	if err != nil {
+		return err
+	}
+	tokenMatch := imgBBAuthTokenRegexp.FindSubmatch(page)
+	if tokenMatch == nil {
+		return fmt.Errorf("ImgBB delete page has no auth token, the image may already be deleted")
+	}
+
+	form := url.Values{}
+	form.Set("auth_token", string(tokenMatch[1]))
+	form.Set("pathname", parsedURL.Path)
+	form.Set("action", "delete")
+	form.Set("delete", "image")
+	form.Set("from", "resource")
+	form.Set("deleting[id]", pathParts[0])
+	form.Set("deleting[hash]", pathParts[1])
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, imgBBUploadUrl, strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("ImgBB returned %v status code", resp.StatusCode)
+	}
+	//Failed requests are reported in the body
+	var status imgBBStatusResponse
+	if err := json.NewDecoder(resp.Body).Decode(&status); err != nil {
+		return fmt.Errorf("can't read ImgBB deletion response: %v", err)
+	}
+	if status.Error != nil && status.Error.Message != "" {
+		return fmt.Errorf("ImgBB didn't delete the image: %v", status.Error.Message)
+	}
+	if status.StatusCode != http.StatusOK || status.Success == nil {
+		return fmt.Errorf("ImgBB didn't confirm the deletion (status code %v)", status.StatusCode)
+	}
+	return nil
+}
+
+func buildImgBBForm(img io.Reader, expiration string) (*multipart.Writer, *bytes.Buffer, error) {
+	body := &bytes.Buffer{}
+	form := multipart.NewWriter(body)
+	fileWriter, err := form.CreateFormFile("source", uploadFileName)
+	if err != nil {
+		return nil, nil, err
+	}
+	_, err = io.Copy(fileWriter, img)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if expiration != "" {
+		if err := form.WriteField("upload-expiration", expiration); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	if err := form.WriteField("type", "file"); err != nil {
+		return nil, nil, err
+	}
+
+	if err := form.WriteField("action", "upload"); err != nil {
+		return nil, nil, err
+	}
+	if err := form.Close(); err != nil {
+		return nil, nil, err
+	}
+	return form, body, nil
+}
+
+type MultipartUploader struct {
+	URL          string
+	FieldName    string
+	Fields       map[string]string
+	Headers      map[string]string
+	ResultPath   string
+	DeletePath   string
+	DeleteMethod string
+	Client       *http.Client
+}
+
+func (uploader MultipartUploader) Upload(ctx context.Context, img io.Reader) (*UploadResult, error) {
+	body := &bytes.Buffer{}
+	form := multipart.NewWriter(body)
+	fileWriter, err := form.CreateFormFile(uploader.FieldName, uploadFileName)
+	if err != nil {
+		return nil, err
+	}
+	if _, err := io.Copy(fileWriter, img); err != nil {
+		return nil, err
+	}
+	for name, value := range uploader.Fields {
+		if err := form.WriteField(name, value); err != nil {
+			return nil, err
+		}
+	}
+	if err := form.Close(); err != nil {
+		return nil, err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, uploader.URL, body)
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range uploader.Headers {
+		req.Header.Set(name, value)
+	}
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	resp, err := httpClient(uploader.Client).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("upload endpoint returned %v status code", resp.StatusCode)
+	}
+	respData, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if uploader.ResultPath == "" {
+		return &UploadResult{URL: strings.TrimSpace(string(respData))}, nil
+	}
+	imageURL, err := LookupJSONPathString(respData, uploader.ResultPath)
+	if err != nil {
+		return nil, err
+	}
+	result := &UploadResult{URL: imageURL}
+	if uploader.DeletePath != "" {
+		if result.DeleteURL, err = LookupJSONPathString(respData, uploader.DeletePath); err != nil {
+			return nil, err
+		}
+	}
+	return result, nil
+}
+
+func (uploader MultipartUploader) Delete(ctx context.Context, deleteURL string) error {
+	method := uploader.DeleteMethod
+	if method == "" {
+		method = http.MethodDelete
+	}
+	req, err := http.NewRequestWithContext(ctx, method, deleteURL, nil)
+	if err != nil {
+		return err
+	}
+	for name, value := range uploader.Headers {
+		req.Header.Set(name, value)
+	}
+	resp, err := httpClient(uploader.Client).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("delete endpoint returned %v status code", resp.StatusCode)
+	}
+	return nil
+}
+
+type S3Uploader struct {
+	Endpoint  string
+	Region    string
+	Bucket    string
+	AccessKey string
+	SecretKey string
+	KeyPrefix string
+	PathStyle bool
+	ACL       string
+	PublicURL string
+	Client    *http.Client
+}
+
+func (uploader S3Uploader) Upload(ctx context.Context, img io.Reader) (*UploadResult, error) {
+	payload, err := io.ReadAll(img)
+	if err != nil {
+		return nil, err
+	}
+	key, err := uploader.newObjectKey()
+	if err != nil {
+		return nil, err
+	}
+	objectURL := uploader.objectURL(key)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, objectURL, bytes.NewReader(payload))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", uploadContentType)
+	if uploader.ACL != "" {
+		req.Header.Set("X-Amz-Acl", uploader.ACL)
+	}
+	signAWSRequest(req, payload, uploader.Region, "s3", uploader.AccessKey, uploader.SecretKey, time.Now())
+	resp, err := httpClient(uploader.Client).Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("S3 endpoint returned %v status code", resp.StatusCode)
+	}
+	result := &UploadResult{URL: objectURL, DeleteURL: objectURL}
+	if uploader.PublicURL != "" {
+		result.URL = fmt.Sprintf("%v/%v", strings.TrimRight(uploader.PublicURL, "/"), key)
+	}
+	return result, nil
+}
+
+func (uploader S3Uploader) Delete(ctx context.Context, deleteURL string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, deleteURL, nil)
+	if err != nil {
+		return err
+	}
+	signAWSRequest(req, nil, uploader.Region, "s3", uploader.AccessKey, uploader.SecretKey, time.Now())
+	resp, err := httpClient(uploader.Client).Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("S3 endpoint returned %v status code", resp.StatusCode)
+	}
+	return nil
+}
+
+func (uploader S3Uploader) newObjectKey() (string, error) {
+	suffix := make([]byte, 4)
+	if _, err := rand.Read(suffix); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf(
+		"%v%v-%v.png",
+		uploader.KeyPrefix,
+		time.Now().Format("20060102-150405"),
+		hex.EncodeToString(suffix),
+	), nil
+}
+
+func (uploader S3Uploader) objectURL(key string) string {
+	endpoint := strings.TrimRight(uploader.Endpoint, "/")
+	if uploader.PathStyle {
+		return fmt.Sprintf("%v/%v/%v", endpoint, uploader.Bucket, key)
+	}
+	scheme, host, found := strings.Cut(endpoint, "://")
+	if !found {
+		scheme, host = "https", endpoint
+	}
+	return fmt.Sprintf("%v://%v.%v/%v", scheme, uploader.Bucket, host, key)
+}
+
+func httpClient(client *http.Client) *http.Client {
+	if client != nil {
+		return client
+	}
+	return http.DefaultClient
+}
@@ -0,0 +1,74 @@
+package pkg
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+const testImgBBSession string = "test-session"
+const testImgBBToken string = "0123abcd"
+
+// Serves a delete page setting the session cookie and a deletion endpoint accepting the token only with it
+func newTestImgBBServer(t *testing.T, deletionResponse string) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/abc123/def456", func(w http.ResponseWriter, r *http.Request) {
+		http.SetCookie(w, &http.Cookie{Name: "PHPSESSID", Value: testImgBBSession, Path: "/"})
+		fmt.Fprintf(w, `<script>PF.obj.config.auth_token = "%v";</script>`, testImgBBToken)
+	})
+	mux.HandleFunc("/json", func(w http.ResponseWriter, r *http.Request) {
+		cookie, err := r.Cookie("PHPSESSID")
+		if err != nil || cookie.Value != testImgBBSession || r.FormValue("auth_token") != testImgBBToken {
+			fmt.Fprint(w, `{"status_code":400,"error":{"message":"Request denied","code":403},"status_txt":"Bad Request"}`)
+			return
+		}
+		if r.FormValue("deleting[id]") != "abc123" || r.FormValue("deleting[hash]") != "def456" {
+			t.Errorf("ImgBB deletion form = %v, want the id and hash of the delete URL", r.Form)
+		}
+		fmt.Fprint(w, deletionResponse)
+	})
+	return httptest.NewServer(mux)
+}
+
+func TestImgBBUploaderDelete(t *testing.T) {
+	tests := []struct {
+		name     string
+		response string
+		wantErr  bool
+	}{
+		{
+			name:     "deleted",
+			response: `{"status_code":200,"success":{"message":"Image deleted","code":200},"status_txt":"OK"}`,
+		},
+		{
+			name:     "error in the body",
+			response: `{"status_code":404,"error":{"message":"Content not found","code":100},"status_txt":"Not Found"}`,
+			wantErr:  true,
+		},
+		{
+			name:     "no confirmation",
+			response: `{"status_code":200,"status_txt":"OK"}`,
+			wantErr:  true,
+		},
+		{
+			name:     "not json",
+			response: `<html>Maintenance</html>`,
+			wantErr:  true,
+		},
+	}
+	defaultUploadUrl := imgBBUploadUrl
+	defer func() { imgBBUploadUrl = defaultUploadUrl }()
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := newTestImgBBServer(t, test.response)
+			defer server.Close()
+			imgBBUploadUrl = server.URL + "/json"
+			err := ImgBBUploader{}.Delete(context.Background(), server.URL+"/abc123/def456")
+			if test.wantErr != (err != nil) {
+				t.Errorf("Delete() error = %v, want error %v", err, test.wantErr)
+			}
+		})
+	}
+}